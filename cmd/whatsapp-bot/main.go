@@ -2,88 +2,199 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"wedding-whatsapp/internal/api"
 	"wedding-whatsapp/internal/config"
 	"wedding-whatsapp/internal/handler"
+	"wedding-whatsapp/internal/importer"
 	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
 	"wedding-whatsapp/internal/storage"
 	"wedding-whatsapp/internal/whatsapp"
 )
 
 func main() {
+	force := flag.Bool("force", false, "start even if the configuration is invalid, e.g. for testing")
+	flag.Parse()
+
 	fmt.Println("🎉 Wedding WhatsApp RSVP Bot")
 	fmt.Println("============================")
 
-	// Load configuration
-	cfg := config.LoadConfig()
-
-	// Initialize storage
-	storagePath := fmt.Sprintf("%s/guests.json", cfg.WhatsAppDataDir)
-	guestStorage, err := storage.NewStorage(storagePath)
+	// Load configuration, with a set of settings for each wedding when the process manages several
+	configs, err := config.LoadWeddings()
 	if err != nil {
-		fmt.Printf("Error initializing storage: %v\n", err)
+		fmt.Printf("Invalid configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Initialize WhatsApp service
-	whatsappCfg := &whatsapp.Config{
-		DataDir: cfg.WhatsAppDataDir,
-	}
-	whatsappService, err := whatsapp.NewService(whatsappCfg)
-	if err != nil {
-		fmt.Printf("Error initializing WhatsApp service: %v\n", err)
-		os.Exit(1)
+	// Refuse to start with placeholder wedding details so they never end up in real invitations
+	for _, cfg := range configs {
+		if err := cfg.Validate(); err != nil {
+			if !*force {
+				fmt.Printf("Invalid configuration%s:\n%v\n\nFix the settings above or run with --force to start anyway.\n", weddingLabel(cfg), err)
+				os.Exit(1)
+			}
+			fmt.Printf("⚠️ Ignoring invalid configuration%s (--force):\n%v\n", weddingLabel(cfg), err)
+		}
 	}
 
-	// Initialize RSVP handler
-	rsvpHandler := handler.NewRSVPHandler(whatsappService, guestStorage, &handler.Config{
-		WeddingDate:     "05.01.2026",
-		WeddingLocation: "אולמי אמרה נס ציונה",
-		BrideName:       "ענת מגן",
-		GroomName:       "דוד מדינרדזה",
-	})
-
-	// Set message handler
-	whatsappService.SetMessageHandler(rsvpHandler.HandleMessage)
+	// Settings of the process as a whole, like the API address, are taken from the first wedding
+	cfg := configs[0]
 
-	// Connect to WhatsApp
-	fmt.Println("Connecting to WhatsApp...")
-	if err := whatsappService.Connect(); err != nil {
-		fmt.Printf("Error connecting to WhatsApp: %v\n", err)
+	// Phone numbers without a country code are interpreted using the default region
+	if err := phone.SetDefaultRegion(cfg.DefaultRegion); err != nil {
+		fmt.Printf("Error configuring phone numbers: %v\n", err)
 		os.Exit(1)
 	}
+	// Data files are created with the same permissions for every wedding
+	storage.SetPermissions(cfg.FileMode, cfg.DirMode)
 
-	fmt.Println("\n✅ Connected to WhatsApp!")
-	fmt.Println("The bot is now listening for RSVP responses.\n")
+	weddings := make([]*wedding, 0, len(configs))
+	for _, weddingCfg := range configs {
+		w, err := startWedding(weddingCfg)
+		if err != nil {
+			fmt.Printf("Error%s: %v\n", weddingLabel(weddingCfg), err)
+			if errors.Is(err, whatsapp.ErrQRTimeout) {
+				fmt.Println("Run the bot again to get a new QR code, or set QR_TIMEOUT to wait longer.")
+			}
+			shutdown(nil, weddings)
+			os.Exit(1)
+		}
+		weddings = append(weddings, w)
+	}
 
-	// Start interactive CLI
-	go startCLI(rsvpHandler, guestStorage, cfg)
+	// Start the HTTP API and the web RSVP links alongside the CLI, each on its own address
+	apiWeddings := make([]api.Wedding, 0, len(weddings))
+	for _, w := range weddings {
+		apiWeddings = append(apiWeddings, api.Wedding{ID: w.name(), Storage: w.storage, RSVPHandler: w.rsvpHandler, WhatsApp: w.whatsappService, Config: w.cfg})
+	}
+	var servers []*api.Server
+	if cfg.APIAddr != "" {
+		servers = append(servers, startServer(api.NewServer(cfg.APIAddr, cfg.APIToken, apiWeddings), "HTTP API"))
+		fmt.Printf("🌐 HTTP API listening on %s\n", cfg.APIAddr)
+	}
+	if cfg.RSVPAddr != "" {
+		servers = append(servers, startServer(api.NewRSVPServer(cfg.RSVPAddr, apiWeddings), "web RSVP links"))
+		fmt.Printf("🔗 Web RSVP links served on %s\n", cfg.RSVPAddr)
+	}
 
-	// Wait for interrupt signal
+	// Wait for interrupt signal, or the Exit command which goes through the same shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	// Start interactive CLI
+	go startCLI(weddings, c)
+
 	<-c
 
 	fmt.Println("\n\nShutting down...")
-	whatsappService.Disconnect()
+	shutdown(servers, weddings)
 	fmt.Println("Goodbye! 👋")
 }
 
-func startCLI(rsvpHandler *handler.RSVPHandler, storage *storage.Storage, cfg *config.Config) {
+// startServer serves requests in the background, printing the error if the server stops unexpectedly
+func startServer(server *api.Server, name string) *api.Server {
+	go func() {
+		if err := server.Start(); err != nil {
+			fmt.Printf("Error serving %s: %v\n", name, err)
+		}
+	}()
+	return server
+}
+
+// shutdownTimeout bounds how long shutdown waits for in-flight work before disconnecting anyway
+const shutdownTimeout = 15 * time.Second
+
+// shutdown stops taking new work, waits for in-flight requests, retries and incoming messages
+// (including the storage writes they make) to finish, then disconnects from WhatsApp
+func shutdown(servers []*api.Server, weddings []*wedding) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			fmt.Printf("⚠️ Error stopping HTTP server: %v\n", err)
+		}
+	}
+
+	for _, w := range weddings {
+		w.shutdown(ctx)
+	}
+}
+
+// openStorage creates the guest store for the configured backend
+func openStorage(cfg *config.Config) (storage.Store, error) {
+	switch cfg.StorageBackend {
+	case "json":
+		return storage.NewStorage(cfg.GuestsPath())
+	case "sqlite":
+		return storage.NewSQLiteStorage(cfg.GuestsPath())
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected \"json\" or \"sqlite\")", cfg.StorageBackend)
+	}
+}
+
+func startCLI(weddings []*wedding, quit chan<- os.Signal) {
 	scanner := bufio.NewScanner(os.Stdin)
 
+	// Commands act on the active wedding, which can be switched when there are several
+	active := weddings[0]
 	for {
+		rsvpHandler, whatsappService, storage, cfg := active.rsvpHandler, active.whatsappService, active.storage, active.cfg
+
 		fmt.Println("\nCommands:")
+		if len(weddings) > 1 {
+			fmt.Printf("(wedding: %s)\n", active.name())
+		}
 		fmt.Println("  1. Send invitation")
 		fmt.Println("  2. View all guests")
 		fmt.Println("  3. View guests by status")
-		fmt.Println("  4. Exit")
-		fmt.Print("\nEnter command (1-4): ")
+		fmt.Println("  4. Send day-of reminders")
+		fmt.Println("  5. Send invitations from CSV")
+		fmt.Println("  6. Re-normalize all numbers")
+		fmt.Println("  7. Send RSVP reminders")
+		fmt.Println("  8. Export to CSV")
+		fmt.Println("  9. Search guests")
+		fmt.Println("  10. Edit guest")
+		fmt.Println("  11. Delete guest")
+		fmt.Println("  12. View statistics")
+		fmt.Println("  13. Find duplicate guests")
+		fmt.Println("  14. View guest details")
+		fmt.Println("  15. Resend invitations to unreached guests")
+		fmt.Println("  16. Group guests into a household")
+		fmt.Println("  17. Import guests from contacts (.vcf)")
+		fmt.Println("  18. Back up guest data")
+		fmt.Println("  19. Restore guest data from backup")
+		fmt.Println("  20. Batch update RSVP status")
+		fmt.Println("  21. Filter guests by invitation/RSVP date")
+		fmt.Println("  22. Guests who read the invitation but haven't replied")
+		fmt.Println("  23. View messages from unknown numbers")
+		fmt.Println("  24. Broadcast message")
+		fmt.Println("  25. Assign table")
+		fmt.Println("  26. Seating report")
+		fmt.Println("  27. Switch wedding")
+		fmt.Println("  28. Mark never-messaged guests as not invited")
+		fmt.Println("  29. Send invitations to all not-invited guests")
+		fmt.Println("  30. Show configuration")
+		fmt.Println("  31. Allow messaging a guest again")
+		fmt.Println("  32. Simulate a guest's message")
+		fmt.Println("  33. Send test invitation")
+		fmt.Println("  34. View guests with send failures")
+		fmt.Println("  35. Schedule a broadcast")
+		fmt.Println("  36. Exit")
+		fmt.Print("\nEnter command (1-36): ")
 
 		if !scanner.Scan() {
 			break
@@ -93,106 +204,1551 @@ func startCLI(rsvpHandler *handler.RSVPHandler, storage *storage.Storage, cfg *c
 
 		switch command {
 		case "1":
-			sendInvitation(scanner, rsvpHandler)
+			sendInvitation(scanner, rsvpHandler, storage)
 		case "2":
-			viewAllGuests(storage)
+			viewAllGuests(scanner, storage)
 		case "3":
 			viewGuestsByStatus(scanner, storage)
 		case "4":
+			sendDayOfReminders(rsvpHandler)
+		case "5":
+			sendInvitationsFromCSV(scanner, rsvpHandler)
+		case "6":
+			renormalizeNumbers(storage)
+		case "7":
+			sendReminders(scanner, rsvpHandler)
+		case "8":
+			exportCSV(scanner, storage)
+		case "9":
+			searchGuests(scanner, storage)
+		case "10":
+			editGuest(scanner, storage)
+		case "11":
+			deleteGuest(scanner, storage)
+		case "12":
+			viewStatistics(storage)
+		case "13":
+			findDuplicates(storage)
+		case "14":
+			viewGuestDetails(scanner, storage)
+		case "15":
+			resendUnreached(rsvpHandler)
+		case "16":
+			setHousehold(scanner, storage)
+		case "17":
+			importVCF(scanner, storage, whatsappService)
+		case "18":
+			backupGuests(scanner, storage, cfg)
+		case "19":
+			restoreGuests(scanner, storage, cfg)
+		case "20":
+			batchUpdateStatus(scanner, storage)
+		case "21":
+			filterGuestsByDate(scanner, storage)
+		case "22":
+			viewReadUnanswered(storage)
+		case "23":
+			viewUnknownContacts(storage)
+		case "24":
+			broadcastMessage(scanner, rsvpHandler)
+		case "25":
+			assignTable(scanner, storage)
+		case "26":
+			viewSeating(storage, cfg.TableCapacity)
+		case "27":
+			active = switchWedding(scanner, weddings, active)
+		case "28":
+			markUnsentNotInvited(scanner, rsvpHandler)
+		case "29":
+			inviteNotInvited(scanner, rsvpHandler, storage)
+		case "30":
+			showConfig(cfg)
+		case "31":
+			allowContact(scanner, rsvpHandler, storage)
+		case "32":
+			simulateMessage(scanner, whatsappService)
+		case "33":
+			sendTestInvitation(scanner, rsvpHandler)
+		case "34":
+			viewSendFailures(storage)
+		case "35":
+			scheduleBroadcast(scanner, rsvpHandler)
+		case "36":
 			fmt.Println("Exiting...")
-			os.Exit(0)
+			quit <- os.Interrupt
+			return
 		default:
 			fmt.Println("Invalid command. Please try again.")
 		}
 	}
 }
 
-func sendInvitation(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+// switchWedding asks which wedding the CLI commands should act on, keeping the active one if none is picked
+func switchWedding(scanner *bufio.Scanner, weddings []*wedding, active *wedding) *wedding {
+	if len(weddings) == 1 {
+		fmt.Println("Only one wedding is configured; list several in WEDDINGS to switch between them.")
+		return active
+	}
+
+	fmt.Println("\nWeddings:")
+	for i, w := range weddings {
+		marker := ""
+		if w == active {
+			marker = " (active)"
+		}
+		fmt.Printf("  %d. %s%s\n", i+1, w.name(), marker)
+	}
+	fmt.Printf("Enter choice (1-%d): ", len(weddings))
+	if !scanner.Scan() {
+		return active
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || choice < 1 || choice > len(weddings) {
+		fmt.Println("Invalid choice.")
+		return active
+	}
+
+	fmt.Printf("✅ Now managing %s.\n", weddings[choice-1].name())
+	return weddings[choice-1]
+}
+
+func sendInvitation(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler, guestStorage storage.Store) {
 	fmt.Print("Enter guest name: ")
 	if !scanner.Scan() {
 		return
 	}
 	name := strings.TrimSpace(scanner.Text())
 
-	fmt.Print("Enter phone number (with country code, e.g., 1234567890): ")
+	fmt.Print("Enter phone number (with country code, e.g., 1234567890), or press Enter for a guest already on the list: ")
 	if !scanner.Scan() {
 		return
 	}
 	phoneNumber := strings.TrimSpace(scanner.Text())
 
+	// Look the name up among the stored guests, which may be only part of it
+	if phoneNumber == "" {
+		guest, err := resolveGuest(scanner, guestStorage, name)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		name, phoneNumber = guest.Name, guest.PhoneNumber
+	}
+
 	// Normalize phone number
 	phoneNumber = strings.ReplaceAll(phoneNumber, "+", "")
 	phoneNumber = strings.ReplaceAll(phoneNumber, " ", "")
 	phoneNumber = strings.ReplaceAll(phoneNumber, "-", "")
 
-	fmt.Printf("\nSending invitation to %s (%s)...\n", name, phoneNumber)
-	if err := rsvpHandler.SendInvitation(phoneNumber, name); err != nil {
+	// A new number under a name already on the list may be a typo for the existing guest's number
+	if _, err := guestStorage.GetGuest(phoneNumber); err != nil {
+		if namesakes := guestStorage.GetGuestsByName(name); len(namesakes) > 0 {
+			fmt.Printf("⚠️ %d guest(s) named %s are already on the list:\n", len(namesakes), name)
+			for _, guest := range namesakes {
+				fmt.Printf("  %s (%s) - %s\n", guest.Name, guest.DisplayPhone(), guest.RSVPStatus)
+			}
+			fmt.Printf("Add %s as a new guest anyway? (y/N): ", phone.Format(phoneNumber))
+			if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+				fmt.Println("Cancelled.")
+				return
+			}
+		}
+	}
+
+	fmt.Print("Enter a personal note to add to the invitation (optional, press Enter to skip): ")
+	if !scanner.Scan() {
+		return
+	}
+	customMessage := strings.TrimSpace(scanner.Text())
+
+	fmt.Print("Whose guest is this, e.g. bride or groom (optional, press Enter to skip): ")
+	if !scanner.Scan() {
+		return
+	}
+	side := strings.TrimSpace(scanner.Text())
+
+	tier, ok := promptTier(scanner, rsvpHandler)
+	if !ok {
+		return
+	}
+
+	fmt.Printf("\nSending invitation to %s (%s)...\n", name, phone.Format(phoneNumber))
+	err := rsvpHandler.SendInvitation(phoneNumber, name, customMessage, side, tier, false)
+	if errors.Is(err, handler.ErrAlreadyInvited) {
+		fmt.Printf("⚠️ %v. Send it again anyway? (y/N): ", err)
+		if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+			fmt.Println("Cancelled.")
+			return
+		}
+		err = rsvpHandler.SendInvitation(phoneNumber, name, customMessage, side, tier, true)
+	}
+	switch {
+	case errors.Is(err, whatsapp.ErrNotOnWhatsApp):
+		fmt.Printf("❌ %s isn't on WhatsApp. Check the number, or reach %s another way.\n", phone.Format(phoneNumber), name)
+	case errors.Is(err, whatsapp.ErrNotInContacts):
+		fmt.Printf("❌ WhatsApp won't deliver to %s yet: save the number in the phone's contacts (with country code), wait for contacts to sync, or ask %s to message you first.\n", phone.Format(phoneNumber), name)
+	case err != nil:
 		fmt.Printf("❌ Error sending invitation: %v\n", err)
-	} else {
+	default:
 		fmt.Printf("✅ Invitation sent successfully!\n")
 	}
 }
 
-func viewAllGuests(storage *storage.Storage) {
-	guests := storage.GetAllGuests()
-	if len(guests) == 0 {
-		fmt.Println("\nNo guests found.")
+func sendTestInvitation(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+	fmt.Print("Enter your phone number (with country code): ")
+	if !scanner.Scan() {
 		return
 	}
+	phoneNumber := strings.TrimSpace(scanner.Text())
 
-	fmt.Printf("\n📋 All Guests (%d total):\n", len(guests))
-	fmt.Println(strings.Repeat("-", 60))
-	for _, guest := range guests {
-		fmt.Printf("Name: %s\n", guest.Name)
-		fmt.Printf("Phone: %s\n", guest.PhoneNumber)
-		fmt.Printf("Status: %s\n", guest.RSVPStatus)
-		if !guest.RSVPDate.IsZero() {
-			fmt.Printf("RSVP Date: %s\n", guest.RSVPDate.Format("2006-01-02 15:04:05"))
-		}
-		fmt.Println(strings.Repeat("-", 60))
+	tier, ok := promptTier(scanner, rsvpHandler)
+	if !ok {
+		return
+	}
+
+	fmt.Printf("\nSending a test invitation to %s...\n", phone.Format(whatsapp.NormalizePhoneNumber(phoneNumber)))
+	messageID, err := rsvpHandler.SendTestInvitation(phoneNumber, tier)
+	switch {
+	case errors.Is(err, whatsapp.ErrOwnNumber):
+		fmt.Println("❌ That's the number the bot is linked to; use another phone to see the invitation as a guest would.")
+	case errors.Is(err, whatsapp.ErrNotOnWhatsApp):
+		fmt.Println("❌ That number isn't on WhatsApp.")
+	case err != nil:
+		fmt.Printf("❌ Error sending test invitation: %v\n", err)
+	case messageID == "":
+		fmt.Println("🌙 It's quiet hours, so the test invitation will go out when they end.")
+	default:
+		fmt.Println("✅ Test invitation sent. No guest was added; replies to it are treated like any message from a number outside the guest list.")
 	}
 }
 
-func viewGuestsByStatus(scanner *bufio.Scanner, storage *storage.Storage) {
-	fmt.Println("\nSelect status:")
+// promptTier asks which tier's invitation to send when any tier has its own, returning "" for the
+// standard invitation. It reports false when input ended.
+func promptTier(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) (string, bool) {
+	tiers := rsvpHandler.InvitationTiers()
+	if len(tiers) == 0 {
+		return "", true
+	}
+
+	fmt.Printf("Invitation tier (%s, or press Enter for the standard invitation): ", strings.Join(tiers, ", "))
+	if !scanner.Scan() {
+		return "", false
+	}
+	return strings.TrimSpace(scanner.Text()), true
+}
+
+func sendInvitationsFromCSV(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+	fmt.Print("Enter CSV file path (columns: name,phone and optionally side, e.g. bride or groom, and tier, e.g. formal): ")
+	if !scanner.Scan() {
+		return
+	}
+	path := strings.TrimSpace(scanner.Text())
+
+	fmt.Printf("\nSending invitations from %s...\n", path)
+	progress := newSendProgress()
+	if _, err := rsvpHandler.SendInvitationsFromCSV(path, progress.update); err != nil {
+		fmt.Printf("❌ Error reading CSV: %v\n", err)
+		return
+	}
+	progress.printSummary()
+}
+
+func sendReminders(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+	fmt.Print("Remind guests who haven't replied after how many days? ")
+	if !scanner.Scan() {
+		return
+	}
+	days, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || days < 0 {
+		fmt.Println("Invalid number of days.")
+		return
+	}
+
+	fmt.Println("\nSending RSVP reminders to pending guests...")
+	progress := newSendProgress()
+	if _, err := rsvpHandler.SendReminders(time.Duration(days)*24*time.Hour, progress.update); err != nil && progress.failed == 0 {
+		fmt.Printf("❌ Error sending reminders: %v\n", err)
+	}
+	progress.printSummary()
+}
+
+func broadcastMessage(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+	status, message, ok := promptBroadcast(scanner)
+	if !ok {
+		return
+	}
+
+	fmt.Printf("Send this to every %s guest? (y/N): ", status)
+	if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	fmt.Printf("\nSending to %s guests...\n", status)
+	progress := newSendProgress()
+	if _, err := rsvpHandler.BroadcastToStatus(status, message, progress.update); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	progress.printSummary()
+}
+
+// promptBroadcast asks for the status of the guests to broadcast to and the message
+func promptBroadcast(scanner *bufio.Scanner) (models.RSVPStatus, string, bool) {
+	fmt.Println("Send to guests with which status?")
 	fmt.Println("  1. Pending")
 	fmt.Println("  2. Accepted")
 	fmt.Println("  3. Declined")
-	fmt.Print("Enter choice (1-3): ")
-
+	fmt.Println("  4. Maybe")
+	fmt.Print("Enter choice (1-4): ")
 	if !scanner.Scan() {
-		return
+		return "", "", false
 	}
 
-	choice := strings.TrimSpace(scanner.Text())
 	var status models.RSVPStatus
-
-	switch choice {
+	switch strings.TrimSpace(scanner.Text()) {
 	case "1":
 		status = models.RSVPPending
 	case "2":
 		status = models.RSVPAccepted
 	case "3":
 		status = models.RSVPDeclined
+	case "4":
+		status = models.RSVPMaybe
 	default:
 		fmt.Println("Invalid choice.")
+		return "", "", false
+	}
+
+	fmt.Println("Enter the message; {{.GuestName}}, {{.WeddingDate}}, {{.WeddingLocation}} and {{.PartySize}} are filled in per guest.")
+	fmt.Print("Use \\n for a line break: ")
+	if !scanner.Scan() {
+		return "", "", false
+	}
+	return status, strings.ReplaceAll(strings.TrimSpace(scanner.Text()), `\n`, "\n"), true
+}
+
+// scheduleBroadcast lists the scheduled broadcasts, to cancel one, or schedules a new one
+func scheduleBroadcast(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+	if scheduled := rsvpHandler.ScheduledBroadcasts(); len(scheduled) > 0 {
+		fmt.Printf("\n📅 Scheduled broadcasts (%d total):\n", len(scheduled))
+		fmt.Println(strings.Repeat("-", 60))
+		for _, broadcast := range scheduled {
+			fmt.Printf("[%s] %s to %s guests: %s\n", broadcast.ID, broadcast.SendAt.Format("2006-01-02 15:04"), broadcast.Status,
+				strings.ReplaceAll(broadcast.Message, "\n", " "))
+		}
+		fmt.Println(strings.Repeat("-", 60))
+
+		fmt.Print("Enter an ID to cancel that broadcast, or press Enter to schedule a new one: ")
+		if !scanner.Scan() {
+			return
+		}
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			if err := rsvpHandler.CancelScheduledBroadcast(id); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				return
+			}
+			fmt.Println("✅ Broadcast cancelled.")
+			return
+		}
+	}
+
+	status, message, ok := promptBroadcast(scanner)
+	if !ok {
 		return
 	}
 
-	guests := storage.GetGuestsByStatus(status)
+	fmt.Print("Send at (YYYY-MM-DD HH:MM): ")
+	if !scanner.Scan() {
+		return
+	}
+	at, err := time.ParseInLocation("2006-01-02 15:04", strings.TrimSpace(scanner.Text()), time.Local)
+	if err != nil {
+		fmt.Println("Invalid time, expected YYYY-MM-DD HH:MM.")
+		return
+	}
+	if at.Before(time.Now()) {
+		fmt.Println("That time has already passed.")
+		return
+	}
+
+	broadcast, err := rsvpHandler.ScheduleBroadcast(at, status, message)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Broadcast %s will be sent to every %s guest at %s, even if the bot is restarted in between.\n",
+		broadcast.ID, status, at.Format("2006-01-02 15:04"))
+}
+
+func markUnsentNotInvited(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler) {
+	fmt.Print("Move pending guests who were never sent anything to not invited? (y/N): ")
+	if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	marked, err := rsvpHandler.MarkUnsentNotInvited()
+	if err != nil {
+		fmt.Printf("❌ Some guests couldn't be updated: %v\n", err)
+	}
+	fmt.Printf("✅ Marked %d guest(s) as not invited.\n", marked)
+}
+
+func inviteNotInvited(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler, guestStorage storage.Store) {
+	guests := guestStorage.GetGuestsByStatus(models.RSVPNotInvited)
 	if len(guests) == 0 {
-		fmt.Printf("\nNo guests with status '%s'.\n", string(status))
+		fmt.Println("\nNo guests are waiting for an invitation.")
 		return
 	}
 
-	fmt.Printf("\n📋 Guests with status '%s' (%d total):\n", string(status), len(guests))
+	fmt.Printf("Send the invitation to %d guest(s) who haven't been invited yet? (y/N): ", len(guests))
+	if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	progress := newSendProgress()
+	if _, err := rsvpHandler.InviteNotInvited(progress.update); err != nil && progress.failed+progress.skipped == 0 {
+		fmt.Printf("❌ Error sending invitations: %v\n", err)
+	}
+	progress.printSummary()
+}
+
+func showConfig(cfg *config.Config) {
+	fmt.Printf("\n⚙️ Configuration%s\n", weddingLabel(cfg))
 	fmt.Println(strings.Repeat("-", 60))
+	for _, setting := range cfg.Settings() {
+		value := setting.Value
+		if value == "" {
+			value = "(not set)"
+		}
+		fmt.Printf("%-32s %s  [%s]\n", setting.Name, value, setting.Source)
+	}
+	fmt.Println(strings.Repeat("-", 60))
+}
+
+func resendUnreached(rsvpHandler *handler.RSVPHandler) {
+	fmt.Println("\nResending invitations to pending guests whose messages weren't delivered...")
+	progress := newSendProgress()
+	if _, err := rsvpHandler.ResendUnreached(progress.update); err != nil && progress.failed == 0 {
+		fmt.Printf("❌ Error resending invitations: %v\n", err)
+	}
+	progress.printSummary()
+}
+
+func sendDayOfReminders(rsvpHandler *handler.RSVPHandler) {
+	fmt.Println("\nSending day-of reminders to accepted guests...")
+	progress := newSendProgress()
+	if _, err := rsvpHandler.SendDayOfReminders(progress.update); err != nil && progress.failed == 0 {
+		fmt.Printf("❌ Error sending day-of reminders: %v\n", err)
+	}
+	progress.printSummary()
+}
+
+func importVCF(scanner *bufio.Scanner, guestStorage storage.Store, whatsappService *whatsapp.Service) {
+	fmt.Print("Enter .vcf file path: ")
+	if !scanner.Scan() {
+		return
+	}
+	path := strings.TrimSpace(scanner.Text())
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("❌ Error opening file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	guests, err := importer.ParseVCF(file)
+	if err != nil {
+		fmt.Printf("❌ Error reading contacts: %v\n", err)
+		return
+	}
+
+	// Existing guests are left alone so their RSVP isn't reset
+	added, existing := 0, 0
 	for _, guest := range guests {
-		fmt.Printf("Name: %s\n", guest.Name)
-		fmt.Printf("Phone: %s\n", guest.PhoneNumber)
-		if !guest.RSVPDate.IsZero() {
-			fmt.Printf("RSVP Date: %s\n", guest.RSVPDate.Format("2006-01-02 15:04:05"))
+		if whatsappService.IsOwnNumber(guest.PhoneNumber) {
+			fmt.Printf("⏭️  Skipping %s (%s): this is the bot's own number\n", guest.Name, guest.DisplayPhone())
+			continue
 		}
-		fmt.Println(strings.Repeat("-", 60))
+		err := guestStorage.AddGuest(guest)
+		if errors.Is(err, storage.ErrGuestExists) {
+			existing++
+			continue
+		}
+		if err != nil {
+			fmt.Printf("❌ Error adding %s (%s): %v\n", guest.Name, guest.DisplayPhone(), err)
+			continue
+		}
+		added++
+	}
+	fmt.Printf("✅ Imported %d guest(s) as not invited, %d already on the list.\n", added, existing)
+}
+
+func backupGuests(scanner *bufio.Scanner, guestStorage storage.Store, cfg *config.Config) {
+	defaultPath := storage.DefaultBackupPath(cfg.WhatsAppDataDir)
+	fmt.Printf("Enter backup file path [%s]: ", defaultPath)
+	if !scanner.Scan() {
+		return
+	}
+	path := strings.TrimSpace(scanner.Text())
+	if path == "" {
+		path = defaultPath
+	}
+
+	if err := guestStorage.Backup(path); err != nil {
+		fmt.Printf("❌ Error backing up guests: %v\n", err)
+		return
+	}
+	fmt.Printf("💾 Backed up %d guest(s) to %s\n", len(guestStorage.GetAllGuests()), path)
+}
+
+func restoreGuests(scanner *bufio.Scanner, guestStorage storage.Store, cfg *config.Config) {
+	fmt.Print("Enter backup file path to restore: ")
+	if !scanner.Scan() {
+		return
+	}
+	path := strings.TrimSpace(scanner.Text())
+
+	fmt.Print("This replaces ALL current guest data. Continue? (y/N): ")
+	if !scanner.Scan() {
+		return
+	}
+	if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	// Keep the current data so a wrong restore can be undone
+	currentPath := storage.DefaultBackupPath(cfg.WhatsAppDataDir)
+	if err := guestStorage.Backup(currentPath); err != nil {
+		fmt.Printf("❌ Error backing up current guests, nothing was restored: %v\n", err)
+		return
+	}
+	fmt.Printf("💾 Current guests backed up to %s\n", currentPath)
+
+	if err := guestStorage.Restore(path); err != nil {
+		fmt.Printf("❌ Error restoring guests, nothing was changed: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Restored %d guest(s) from %s\n", len(guestStorage.GetAllGuests()), path)
+}
+
+func renormalizeNumbers(storage storage.Store) {
+	fmt.Println("\nRe-normalizing stored phone numbers...")
+	changes, backupPath, err := storage.RenormalizePhoneNumbers(whatsapp.NormalizePhoneNumber)
+	if backupPath != "" {
+		fmt.Printf("💾 Backup written to %s\n", backupPath)
+	}
+	if err != nil {
+		fmt.Printf("❌ Error re-normalizing numbers: %v\n", err)
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Println("✅ All numbers are already normalized.")
+		return
+	}
+
+	fmt.Println(strings.Repeat("-", 60))
+	for _, change := range changes {
+		if change.Merged {
+			fmt.Printf("%s: %s -> %s (merged with existing guest)\n", change.Name, phone.Format(change.OldNumber), phone.Format(change.NewNumber))
+		} else {
+			fmt.Printf("%s: %s -> %s\n", change.Name, phone.Format(change.OldNumber), phone.Format(change.NewNumber))
+		}
+	}
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("✅ Updated %d number(s).\n", len(changes))
+}
+
+func exportCSV(scanner *bufio.Scanner, storage storage.Store) {
+	fmt.Print("Enter output file name (e.g., guests.csv): ")
+	if !scanner.Scan() {
+		return
+	}
+	path := strings.TrimSpace(scanner.Text())
+	if path == "" {
+		fmt.Println("Invalid file name.")
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("❌ Error creating file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	if err := storage.ExportCSV(file); err != nil {
+		fmt.Printf("❌ Error exporting guests: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Exported guests to %s\n", path)
+}
+
+func searchGuests(scanner *bufio.Scanner, storage storage.Store) {
+	fmt.Print("Enter name or phone to search for: ")
+	if !scanner.Scan() {
+		return
+	}
+	query := strings.TrimSpace(scanner.Text())
+
+	guests := storage.SearchGuests(query)
+	if len(guests) == 0 {
+		fmt.Printf("\nNo guests matching '%s'.\n", query)
+		return
+	}
+
+	fmt.Printf("\n🔍 Guests matching '%s' (%d found):\n", query, len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, guest := range guests {
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		fmt.Printf("Status: %s\n", guest.RSVPStatus)
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func editGuest(scanner *bufio.Scanner, guestStorage storage.Store) {
+	fmt.Print("Enter phone number or name of the guest to edit: ")
+	if !scanner.Scan() {
+		return
+	}
+	guest, err := resolveGuest(scanner, guestStorage, scanner.Text())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	updated := *guest
+
+	fmt.Printf("Name [%s]: ", guest.Name)
+	if !scanner.Scan() {
+		return
+	}
+	if name := strings.TrimSpace(scanner.Text()); name != "" {
+		updated.Name = name
+	}
+
+	fmt.Printf("Phone [%s]: ", guest.DisplayPhone())
+	if !scanner.Scan() {
+		return
+	}
+	if phone := strings.TrimSpace(scanner.Text()); phone != "" {
+		updated.PhoneNumber = whatsapp.NormalizePhoneNumber(phone)
+	}
+
+	currentAlternates := formatPhones(guest.AlternatePhones)
+	if currentAlternates == "" {
+		currentAlternates = "none"
+	}
+	fmt.Printf("Other numbers they may reply from, comma-separated or \"-\" for none [%s]: ", currentAlternates)
+	if !scanner.Scan() {
+		return
+	}
+	switch input := strings.TrimSpace(scanner.Text()); input {
+	case "":
+	case "-":
+		updated.AlternatePhones = nil
+	default:
+		updated.AlternatePhones = nil
+		for _, alternate := range strings.Split(input, ",") {
+			updated.AlternatePhones = append(updated.AlternatePhones, whatsapp.NormalizePhoneNumber(strings.TrimSpace(alternate)))
+		}
+	}
+
+	fmt.Printf("Side, e.g. bride or groom, or \"-\" for unspecified [%s]: ", guest.GuestSide())
+	if !scanner.Scan() {
+		return
+	}
+	switch input := strings.TrimSpace(scanner.Text()); input {
+	case "":
+	case "-":
+		updated.Side = ""
+	default:
+		updated.Side = handler.NormalizeSide(input)
+	}
+
+	currentTier := guest.Tier
+	if currentTier == "" {
+		currentTier = "standard"
+	}
+	fmt.Printf("Invitation tier, e.g. formal, or \"-\" for the standard invitation [%s]: ", currentTier)
+	if !scanner.Scan() {
+		return
+	}
+	switch input := strings.TrimSpace(scanner.Text()); input {
+	case "":
+	case "-":
+		updated.Tier = ""
+	default:
+		updated.Tier = handler.NormalizeTier(input)
+	}
+
+	currentDeadline := "default"
+	if !guest.RSVPDeadline.IsZero() {
+		currentDeadline = guest.RSVPDeadline.Format(time.RFC3339)
+	}
+	fmt.Printf("RSVP deadline, YYYY-MM-DD or \"-\" for the default [%s]: ", currentDeadline)
+	if !scanner.Scan() {
+		return
+	}
+	switch input := strings.TrimSpace(scanner.Text()); input {
+	case "":
+	case "-":
+		updated.RSVPDeadline = time.Time{}
+	default:
+		deadline, err := config.ParseDeadline(input)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		updated.RSVPDeadline = deadline
+	}
+
+	if err := guestStorage.UpdateGuest(guest.PhoneNumber, updated); err != nil {
+		fmt.Printf("❌ Error updating guest: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Updated %s (%s).\n", updated.Name, updated.DisplayPhone())
+}
+
+func viewGuestDetails(scanner *bufio.Scanner, guestStorage storage.Store) {
+	fmt.Print("Enter phone number or name of the guest: ")
+	if !scanner.Scan() {
+		return
+	}
+	guest, err := resolveGuest(scanner, guestStorage, scanner.Text())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Name: %s\n", guest.Name)
+	fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+	fmt.Printf("Status: %s\n", guest.RSVPStatus)
+	fmt.Printf("Side: %s\n", guest.GuestSide())
+	if guest.Tier != "" {
+		fmt.Printf("Invitation Tier: %s\n", guest.Tier)
+	}
+	fmt.Printf("Invited: %s\n", guest.InvitedDate.Format("2006-01-02 15:04:05"))
+	if guest.PartySize > 0 {
+		fmt.Printf("Party Size: %d\n", guest.PartySize)
+	}
+	if guest.MealPreference != "" {
+		fmt.Printf("Meal: %s\n", guest.MealPreference)
+	}
+	if guest.TableNumber > 0 {
+		fmt.Printf("Table: %d\n", guest.TableNumber)
+	}
+	if guest.Language != "" {
+		fmt.Printf("Language: %s\n", guest.Language)
+	}
+	if guest.ConversationState != models.StateIdle {
+		fmt.Printf("Waiting For: %s\n", guest.ConversationState)
+	}
+	if guest.DeliveryStatus != "" {
+		fmt.Printf("Last Message: %s\n", guest.DeliveryStatus)
+	}
+	if len(guest.AlternatePhones) > 0 {
+		fmt.Printf("Other Numbers: %s\n", formatPhones(guest.AlternatePhones))
+	}
+	if guest.HouseholdID != "" {
+		fmt.Printf("Household: %s\n", guest.HouseholdID)
+	}
+	if !guest.RSVPDeadline.IsZero() {
+		fmt.Printf("RSVP Deadline: %s\n", guest.RSVPDeadline.Format("2006-01-02 15:04:05"))
+	}
+	if guest.ResendCount > 0 {
+		fmt.Printf("Invitation Resent: %d time(s)\n", guest.ResendCount)
+	}
+	if guest.Unreachable {
+		fmt.Printf("Unreachable: %s\n", guest.LastError)
+	}
+	if guest.DoNotContact {
+		fmt.Println("🔕 Do Not Contact: asked not to be messaged")
+	}
+	if guest.CustomMessage != "" {
+		fmt.Printf("Personal Note: %s\n", guest.CustomMessage)
+	}
+	if guest.Notes != "" {
+		fmt.Printf("Notes: %s\n", guest.Notes)
+	}
+
+	history, err := guestStorage.GetHistory(guest.PhoneNumber)
+	if err != nil {
+		fmt.Printf("❌ Error loading RSVP history: %v\n", err)
+		return
+	}
+
+	fmt.Println("\nRSVP History:")
+	if len(history) == 0 {
+		fmt.Println("  No replies yet.")
+	}
+	for _, event := range history {
+		fmt.Printf("  %s  %s → %s  %q\n", event.Timestamp.Format("2006-01-02 15:04:05"), event.OldStatus, event.NewStatus, event.Message)
+	}
+
+	if len(guest.SendAttempts) > 0 {
+		fmt.Println("\nSend Attempts:")
+		for _, attempt := range guest.SendAttempts {
+			if attempt.Success {
+				fmt.Printf("  %s  ✅ sent\n", attempt.Timestamp.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("  %s  ❌ %s\n", attempt.Timestamp.Format("2006-01-02 15:04:05"), attempt.Error)
+			}
+		}
+	}
+	fmt.Println(strings.Repeat("-", 60))
+}
+
+func deleteGuest(scanner *bufio.Scanner, guestStorage storage.Store) {
+	fmt.Print("Enter phone number or name of the guest to delete: ")
+	if !scanner.Scan() {
+		return
+	}
+	guest, err := resolveGuest(scanner, guestStorage, scanner.Text())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("Delete %s (%s)? (y/N): ", guest.Name, guest.DisplayPhone())
+	if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	if err := guestStorage.DeleteGuest(guest.PhoneNumber); err != nil {
+		fmt.Printf("❌ Error deleting guest: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Deleted %s.\n", guest.Name)
+}
+
+func viewStatistics(storage storage.Store) {
+	stats := storage.Stats()
+
+	fmt.Println("\n📊 RSVP Statistics")
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Total guests:   %d\n", stats.Total)
+	fmt.Printf("🏠 Households:  %d\n", stats.Households)
+	fmt.Printf("✅ Accepted:    %d\n", stats.Accepted)
+	fmt.Printf("❌ Declined:    %d\n", stats.Declined)
+	fmt.Printf("🤔 Maybe:       %d\n", stats.Maybe)
+	fmt.Printf("⏳ Pending:     %d\n", stats.Pending)
+	fmt.Printf("📇 Not invited: %d\n", stats.NotInvited)
+	fmt.Printf("🔕 Asked not to be messaged (counted above too): %d\n", stats.DoNotContact)
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("👥 Expected headcount: %d\n", stats.Headcount)
+
+	// Split by side only once someone has said whose guests they are
+	if stats.Sides[models.SideUnspecified].Total < stats.Total {
+		fmt.Println("\nBy side:")
+		for _, side := range slices.Sorted(maps.Keys(stats.Sides)) {
+			s := stats.Sides[side]
+			fmt.Printf("  %-12s %d guests: ✅ %d  ❌ %d  🤔 %d  ⏳ %d  👥 %d\n", side, s.Total, s.Accepted, s.Declined, s.Maybe, s.Pending, s.Headcount)
+		}
+	}
+}
+
+func setHousehold(scanner *bufio.Scanner, storage storage.Store) {
+	fmt.Print("Enter household name (e.g. \"Cohen family\"): ")
+	if !scanner.Scan() {
+		return
+	}
+	id := strings.TrimSpace(scanner.Text())
+	if id == "" {
+		fmt.Println("Household name cannot be empty.")
+		return
+	}
+
+	fmt.Print("Enter the phone numbers of its guests, separated by commas: ")
+	if !scanner.Scan() {
+		return
+	}
+	var phones []string
+	for _, number := range strings.Split(scanner.Text(), ",") {
+		if number = strings.TrimSpace(number); number != "" {
+			phones = append(phones, whatsapp.NormalizePhoneNumber(number))
+		}
+	}
+	if len(phones) == 0 {
+		fmt.Println("No phone numbers entered.")
+		return
+	}
+
+	if err := storage.SetHousehold(phones, id); err != nil {
+		fmt.Printf("❌ Error setting household: %v\n", err)
+		return
+	}
+
+	members := storage.GetHousehold(id)
+	fmt.Printf("✅ Household '%s' now has %d guest(s):\n", id, len(members))
+	for _, guest := range members {
+		fmt.Printf("  %s (%s)\n", guest.Name, guest.DisplayPhone())
+	}
+}
+
+func findDuplicates(storage storage.Store) {
+	groups := storage.FindDuplicates()
+	if len(groups) == 0 {
+		fmt.Println("\n✅ No duplicate guests found.")
+		return
+	}
+
+	fmt.Printf("\n⚠️ Found %d phone number(s) shared by several guests:\n", len(groups))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, group := range groups {
+		for _, guest := range group {
+			fmt.Printf("%s (%s) - %s\n", guest.Name, guest.DisplayPhone(), guest.RSVPStatus)
+		}
+		fmt.Println(strings.Repeat("-", 60))
+	}
+	fmt.Println("Use \"Re-normalize all numbers\" to merge them.")
+}
+
+// guestsPerPage is how many guests viewAllGuests shows before waiting for Enter
+const guestsPerPage = 20
+
+func viewAllGuests(scanner *bufio.Scanner, storage storage.Store) {
+	guests := storage.GetAllGuests()
+	if len(guests) == 0 {
+		fmt.Println("\nNo guests found.")
+		return
+	}
+
+	fmt.Println("\nSort by:")
+	fmt.Println("  1. Order added")
+	fmt.Println("  2. Name")
+	fmt.Println("  3. Status")
+	fmt.Println("  4. RSVP date (most recent first)")
+	fmt.Print("Enter choice (1-4, Enter for order added): ")
+	if !scanner.Scan() {
+		return
+	}
+
+	switch strings.TrimSpace(scanner.Text()) {
+	case "", "1":
+	case "2":
+		slices.SortStableFunc(guests, func(a, b models.Guest) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	case "3":
+		slices.SortStableFunc(guests, func(a, b models.Guest) int {
+			return strings.Compare(string(a.RSVPStatus), string(b.RSVPStatus))
+		})
+	case "4":
+		slices.SortStableFunc(guests, func(a, b models.Guest) int {
+			return b.RSVPDate.Compare(a.RSVPDate)
+		})
+	default:
+		fmt.Println("Invalid choice.")
+		return
+	}
+
+	fmt.Printf("\n📋 All Guests (%d total):\n", len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for i, guest := range guests {
+		// Wait between pages so long lists don't scroll off-screen
+		if i > 0 && i%guestsPerPage == 0 {
+			fmt.Printf("Showing %d of %d. Press Enter for the next page, or q to quit: ", i, len(guests))
+			if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) == "q" {
+				return
+			}
+			fmt.Println(strings.Repeat("-", 60))
+		}
+
+		fmt.Printf("#%d\n", i+1)
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		fmt.Printf("Status: %s\n", guest.RSVPStatus)
+		if guest.DoNotContact {
+			fmt.Println("🔕 Do not contact")
+		}
+		if !guest.RSVPDate.IsZero() {
+			fmt.Printf("RSVP Date: %s\n", guest.RSVPDate.Format("2006-01-02 15:04:05"))
+		}
+		if guest.PartySize > 0 {
+			fmt.Printf("Party Size: %d\n", guest.PartySize)
+		}
+		if guest.MealPreference != "" {
+			fmt.Printf("Meal: %s\n", guest.MealPreference)
+		}
+		if guest.TableNumber > 0 {
+			fmt.Printf("Table: %d\n", guest.TableNumber)
+		}
+		if guest.DeliveryStatus != "" {
+			fmt.Printf("Last Message: %s\n", guest.DeliveryStatus)
+		}
+		fmt.Println(strings.Repeat("-", 60))
+	}
+	fmt.Printf("Showing %d of %d.\n", len(guests), len(guests))
+}
+
+func batchUpdateStatus(scanner *bufio.Scanner, guestStorage storage.Store) {
+	fmt.Print("Enter phone numbers or names, separated by commas: ")
+	if !scanner.Scan() {
+		return
+	}
+	entries := strings.Split(scanner.Text(), ",")
+
+	fmt.Println("Select new status:")
+	fmt.Println("  1. Pending")
+	fmt.Println("  2. Accepted")
+	fmt.Println("  3. Declined")
+	fmt.Println("  4. Maybe")
+	fmt.Print("Enter choice (1-4): ")
+	if !scanner.Scan() {
+		return
+	}
+
+	var status models.RSVPStatus
+	switch strings.TrimSpace(scanner.Text()) {
+	case "1":
+		status = models.RSVPPending
+	case "2":
+		status = models.RSVPAccepted
+	case "3":
+		status = models.RSVPDeclined
+	case "4":
+		status = models.RSVPMaybe
+	default:
+		fmt.Println("Invalid choice.")
+		return
+	}
+
+	updated, total := 0, 0
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		total++
+
+		guest, err := resolveGuest(scanner, guestStorage, entry)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", entry, err)
+			continue
+		}
+
+		if err := guestStorage.UpdateRSVP(guest.PhoneNumber, status, "", "Updated from the CLI"); err != nil {
+			fmt.Printf("❌ %s: %v\n", entry, err)
+			continue
+		}
+
+		// Keep the head count in line with the status, as for replies over WhatsApp
+		partySize := guest.PartySize
+		if status != models.RSVPAccepted {
+			partySize = 0
+		} else if partySize == 0 {
+			partySize = 1
+		}
+		if err := guestStorage.UpdatePartySize(guest.PhoneNumber, partySize); err != nil {
+			fmt.Printf("⚠️ %s: status updated but failed to update party size: %v\n", entry, err)
+		}
+
+		fmt.Printf("✅ %s (%s) is now %s\n", guest.Name, guest.DisplayPhone(), status)
+		updated++
+	}
+
+	fmt.Printf("\nUpdated %d of %d guest(s).\n", updated, total)
+}
+
+// resolveGuest looks a guest up by phone number, in any format, or by their name.
+// A full name picks the guests with exactly that name, otherwise any guest whose name contains it;
+// whenever more than one guest matches, even with identical names, they're listed by phone to pick from.
+func resolveGuest(scanner *bufio.Scanner, guestStorage storage.Store, query string) (*models.Guest, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("no guest entered")
+	}
+	if guest, err := guestStorage.GetGuest(whatsapp.NormalizePhoneNumber(query)); err == nil {
+		return guest, nil
+	}
+
+	matches := guestStorage.GetGuestsByName(query)
+	if len(matches) == 0 {
+		matches = guestStorage.SearchGuests(query)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no guest matches %q", query)
+	case 1:
+		return &matches[0], nil
+	}
+
+	fmt.Printf("%d guests match %q:\n", len(matches), query)
+	for i, guest := range matches {
+		fmt.Printf("  %d. %s (%s) - %s\n", i+1, guest.Name, guest.DisplayPhone(), guest.RSVPStatus)
+	}
+	fmt.Printf("Pick a guest (1-%d, Enter to cancel): ", len(matches))
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("no guest picked")
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || choice < 1 || choice > len(matches) {
+		return nil, fmt.Errorf("no guest picked")
+	}
+	return &matches[choice-1], nil
+}
+
+func filterGuestsByDate(scanner *bufio.Scanner, storage storage.Store) {
+	fmt.Println("\nFilter by:")
+	fmt.Println("  1. Invited more than N days ago")
+	fmt.Println("  2. Replied between two dates")
+	fmt.Print("Enter choice (1-2): ")
+	if !scanner.Scan() {
+		return
+	}
+
+	var guests []models.Guest
+	switch strings.TrimSpace(scanner.Text()) {
+	case "1":
+		fmt.Print("Number of days: ")
+		if !scanner.Scan() {
+			return
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil || days < 0 {
+			fmt.Println("Invalid number of days.")
+			return
+		}
+		guests = storage.GetGuestsInvitedBefore(time.Now().AddDate(0, 0, -days))
+	case "2":
+		fmt.Print("From date (YYYY-MM-DD, empty for no limit): ")
+		if !scanner.Scan() {
+			return
+		}
+		from, err := parseOptionalDate(scanner.Text())
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		fmt.Print("To date, inclusive (YYYY-MM-DD, empty for no limit): ")
+		if !scanner.Scan() {
+			return
+		}
+		to, err := parseOptionalDate(scanner.Text())
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		if !to.IsZero() {
+			to = to.AddDate(0, 0, 1)
+		}
+		guests = storage.GetGuestsByRSVPDateRange(from, to)
+	default:
+		fmt.Println("Invalid choice.")
+		return
+	}
+
+	if len(guests) == 0 {
+		fmt.Println("\nNo matching guests.")
+		return
+	}
+
+	fmt.Printf("\n📋 %d matching guest(s):\n", len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, guest := range guests {
+		fmt.Printf("%s (%s) - %s\n", guest.Name, guest.DisplayPhone(), guest.RSVPStatus)
+		fmt.Printf("  Invited: %s", guest.InvitedDate.Format("2006-01-02"))
+		if !guest.RSVPDate.IsZero() {
+			fmt.Printf(", Replied: %s", guest.RSVPDate.Format("2006-01-02"))
+		}
+		fmt.Println()
+	}
+}
+
+// parseOptionalDate parses a YYYY-MM-DD date in local time, returning zero for empty input
+func parseOptionalDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+func viewReadUnanswered(storage storage.Store) {
+	guests := storage.GetReadUnanswered()
+	if len(guests) == 0 {
+		fmt.Println("\nNo guests have read their invitation without replying.")
+		return
+	}
+
+	fmt.Printf("\n👀 Read but not answered (%d total):\n", len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, guest := range guests {
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		fmt.Printf("Invited: %s\n", guest.InvitedDate.Format("2006-01-02 15:04:05"))
+		if !guest.LastReminderDate.IsZero() {
+			fmt.Printf("Last Reminder: %s\n", guest.LastReminderDate.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func assignTable(scanner *bufio.Scanner, guestStorage storage.Store) {
+	fmt.Print("Enter phone number or name: ")
+	if !scanner.Scan() {
+		return
+	}
+	guest, err := resolveGuest(scanner, guestStorage, scanner.Text())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("Table for %s (currently %d, 0 to unseat): ", guest.Name, guest.TableNumber)
+	if !scanner.Scan() {
+		return
+	}
+	table, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || table < 0 {
+		fmt.Println("Invalid table number.")
+		return
+	}
+
+	if err := guestStorage.AssignTable(guest.PhoneNumber, table); err != nil {
+		fmt.Printf("❌ Error assigning table: %v\n", err)
+		return
+	}
+	if table == 0 {
+		fmt.Printf("✅ %s no longer has a table.\n", guest.Name)
+	} else {
+		fmt.Printf("✅ %s is at table %d.\n", guest.Name, table)
+	}
+}
+
+func viewSeating(storage storage.Store, capacity int) {
+	guests := storage.GetGuestsByStatus(models.RSVPAccepted)
+	if len(guests) == 0 {
+		fmt.Println("\nNo accepted guests to seat.")
+		return
+	}
+
+	tables := make(map[int][]models.Guest)
+	for _, guest := range guests {
+		tables[guest.TableNumber] = append(tables[guest.TableNumber], guest)
+	}
+	numbers := make([]int, 0, len(tables))
+	for table := range tables {
+		if table > 0 {
+			numbers = append(numbers, table)
+		}
+	}
+	slices.Sort(numbers)
+
+	fmt.Printf("\n🪑 Seating (%d tables, capacity %d):\n", len(numbers), capacity)
+	fmt.Println(strings.Repeat("-", 60))
+	for _, table := range numbers {
+		headcount := seatingHeadcount(tables[table])
+		flag := ""
+		if capacity > 0 && headcount > capacity {
+			flag = fmt.Sprintf(" ⚠️ over capacity by %d", headcount-capacity)
+		}
+		fmt.Printf("Table %d: %d people%s\n", table, headcount, flag)
+		printSeatedGuests(tables[table])
+		fmt.Println(strings.Repeat("-", 60))
+	}
+
+	if unseated := tables[0]; len(unseated) > 0 {
+		fmt.Printf("Needs seating: %d people\n", seatingHeadcount(unseated))
+		printSeatedGuests(unseated)
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+// seatingHeadcount counts the people in a group of accepted guests, each at least themselves
+func seatingHeadcount(guests []models.Guest) int {
+	headcount := 0
+	for _, guest := range guests {
+		headcount += max(guest.PartySize, 1)
+	}
+	return headcount
+}
+
+func printSeatedGuests(guests []models.Guest) {
+	for _, guest := range guests {
+		fmt.Printf("  %s (%s), party of %d\n", guest.Name, guest.DisplayPhone(), max(guest.PartySize, 1))
+	}
+}
+
+func viewUnknownContacts(storage storage.Store) {
+	contacts := storage.GetUnknownContacts()
+	if len(contacts) == 0 {
+		fmt.Println("\nNo messages from numbers outside the guest list.")
+		return
+	}
+
+	fmt.Printf("\n❔ Messages from numbers outside the guest list (%d total):\n", len(contacts))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, contact := range contacts {
+		fmt.Printf("Phone: %s\n", phone.Format(contact.PhoneNumber))
+		if guest, err := storage.GetGuest(contact.PhoneNumber); err == nil {
+			fmt.Printf("Invited since as: %s\n", guest.Name)
+		}
+		fmt.Printf("First Message: %s\n", contact.FirstSeen.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Messages: %d (last on %s)\n", contact.MessageCount, contact.LastSeen.Format("2006-01-02 15:04:05"))
+		if contact.LastMessage != "" {
+			fmt.Printf("Last Message: %q\n", contact.LastMessage)
+		}
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func viewUnreachableGuests(storage storage.Store) {
+	var guests []models.Guest
+	for _, guest := range storage.GetAllGuests() {
+		if guest.Unreachable {
+			guests = append(guests, guest)
+		}
+	}
+	if len(guests) == 0 {
+		fmt.Println("\nNo unreachable guests.")
+		return
+	}
+
+	fmt.Printf("\n📵 Unreachable guests (%d total) - follow up by phone:\n", len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, guest := range guests {
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		fmt.Printf("Status: %s\n", guest.RSVPStatus)
+		fmt.Printf("Last Error: %s\n", guest.LastError)
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func viewSendFailures(storage storage.Store) {
+	var guests []models.Guest
+	for _, guest := range storage.GetAllGuests() {
+		if _, failed := guest.LastFailedSend(); failed {
+			guests = append(guests, guest)
+		}
+	}
+	if len(guests) == 0 {
+		fmt.Println("\nNo failed sends recorded.")
+		return
+	}
+
+	// Most recent failure first
+	slices.SortFunc(guests, func(a, b models.Guest) int {
+		lastA, _ := a.LastFailedSend()
+		lastB, _ := b.LastFailedSend()
+		return lastB.Timestamp.Compare(lastA.Timestamp)
+	})
+
+	fmt.Printf("\n⚠️ Guests with failed sends (%d total):\n", len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, guest := range guests {
+		last, _ := guest.LastFailedSend()
+		failures := 0
+		for _, attempt := range guest.SendAttempts {
+			if !attempt.Success {
+				failures++
+			}
+		}
+
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		fmt.Printf("Failed Sends: %d of the last %d\n", failures, len(guest.SendAttempts))
+		fmt.Printf("Last Failure: %s\n", last.Timestamp.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Last Error: %s\n", last.Error)
+		if latest := guest.SendAttempts[len(guest.SendAttempts)-1]; latest.Success {
+			fmt.Printf("Since Then: sent successfully on %s\n", latest.Timestamp.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func viewDoNotContactGuests(storage storage.Store) {
+	var guests []models.Guest
+	for _, guest := range storage.GetAllGuests() {
+		if guest.DoNotContact {
+			guests = append(guests, guest)
+		}
+	}
+	if len(guests) == 0 {
+		fmt.Println("\nNo guests asked not to be messaged.")
+		return
+	}
+
+	fmt.Printf("\n🔕 Guests who asked not to be messaged (%d total) - left out of invitations, reminders and broadcasts:\n", len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, guest := range guests {
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		fmt.Printf("Status: %s\n", guest.RSVPStatus)
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func allowContact(scanner *bufio.Scanner, rsvpHandler *handler.RSVPHandler, guestStorage storage.Store) {
+	fmt.Print("Enter phone number or name of the guest to message again: ")
+	if !scanner.Scan() {
+		return
+	}
+	guest, err := resolveGuest(scanner, guestStorage, scanner.Text())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if !guest.DoNotContact {
+		fmt.Printf("%s hasn't asked not to be messaged.\n", guest.Name)
+		return
+	}
+
+	fmt.Printf("%s asked not to be messaged. Include them in invitations, reminders and broadcasts again? (y/N): ", guest.Name)
+	if !scanner.Scan() || strings.ToLower(strings.TrimSpace(scanner.Text())) != "y" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	if err := rsvpHandler.AllowContact(guest.PhoneNumber); err != nil {
+		fmt.Printf("❌ Error updating guest: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ %s will be messaged again.\n", guest.Name)
+}
+
+func simulateMessage(scanner *bufio.Scanner, whatsappService *whatsapp.Service) {
+	if !whatsappService.IsOffline() {
+		fmt.Println("Guests' messages can only be simulated with FAKE_WHATSAPP=1.")
+		return
+	}
+
+	fmt.Print("Enter the guest's phone number (Enter to list everything the bot sent): ")
+	if !scanner.Scan() {
+		return
+	}
+	phoneNumber := strings.TrimSpace(scanner.Text())
+	if phoneNumber == "" {
+		printSentMessages(whatsappService.SentMessages())
+		return
+	}
+
+	fmt.Print("Enter their message: ")
+	if !scanner.Scan() {
+		return
+	}
+	text := strings.TrimSpace(scanner.Text())
+	if text == "" {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	before := len(whatsappService.SentMessages())
+	if err := whatsappService.InjectMessage(phoneNumber, text); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	replies := whatsappService.SentMessages()[before:]
+	if len(replies) == 0 {
+		fmt.Println("The bot didn't reply.")
+		return
+	}
+	printSentMessages(replies)
+}
+
+// printSentMessages shows messages recorded by the offline WhatsApp service
+func printSentMessages(messages []whatsapp.OutboundMessage) {
+	if len(messages) == 0 {
+		fmt.Println("\nNothing sent yet.")
+		return
+	}
+
+	fmt.Printf("\n📤 Sent by the bot (%d):\n", len(messages))
+	fmt.Println(strings.Repeat("-", 60))
+	for _, msg := range messages {
+		fmt.Printf("To %s at %s:\n%s\n", phone.Format(msg.To), msg.Timestamp.Format("15:04:05"), msg.Text)
+		fmt.Println(strings.Repeat("-", 60))
+	}
+}
+
+func viewGuestsByStatus(scanner *bufio.Scanner, storage storage.Store) {
+	fmt.Println("\nSelect status:")
+	fmt.Println("  1. Pending")
+	fmt.Println("  2. Accepted")
+	fmt.Println("  3. Declined")
+	fmt.Println("  4. Maybe")
+	fmt.Println("  5. Not invited yet")
+	fmt.Println("  6. Unreachable (can't be messaged on WhatsApp)")
+	fmt.Println("  7. Asked not to be messaged")
+	fmt.Print("Enter choice (1-7): ")
+
+	if !scanner.Scan() {
+		return
+	}
+
+	choice := strings.TrimSpace(scanner.Text())
+	var status models.RSVPStatus
+
+	switch choice {
+	case "1":
+		status = models.RSVPPending
+	case "2":
+		status = models.RSVPAccepted
+	case "3":
+		status = models.RSVPDeclined
+	case "4":
+		status = models.RSVPMaybe
+	case "5":
+		status = models.RSVPNotInvited
+	case "6":
+		viewUnreachableGuests(storage)
+		return
+	case "7":
+		viewDoNotContactGuests(storage)
+		return
+	default:
+		fmt.Println("Invalid choice.")
+		return
+	}
+
+	guests := storage.GetGuestsByStatus(status)
+	if len(guests) == 0 {
+		fmt.Printf("\nNo guests with status '%s'.\n", string(status))
+		return
+	}
+
+	fmt.Printf("\n📋 Guests with status '%s' (%d total):\n", string(status), len(guests))
+	fmt.Println(strings.Repeat("-", 60))
+	headcount := 0
+	for _, guest := range guests {
+		fmt.Printf("Name: %s\n", guest.Name)
+		fmt.Printf("Phone: %s\n", guest.DisplayPhone())
+		if guest.PartySize > 0 {
+			fmt.Printf("Party Size: %d\n", guest.PartySize)
+		}
+		if guest.MealPreference != "" {
+			fmt.Printf("Meal: %s\n", guest.MealPreference)
+		}
+		if !guest.RSVPDate.IsZero() {
+			fmt.Printf("RSVP Date: %s\n", guest.RSVPDate.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Println(strings.Repeat("-", 60))
+		headcount += guest.PartySize
+	}
+	if status == models.RSVPAccepted {
+		fmt.Printf("👥 Total headcount: %d\n", headcount)
+	}
+}
+
+// formatPhones lists phone numbers in E.164 form, separated by commas
+func formatPhones(numbers []string) string {
+	formatted := make([]string, len(numbers))
+	for i, number := range numbers {
+		formatted[i] = phone.Format(number)
 	}
+	return strings.Join(formatted, ", ")
 }
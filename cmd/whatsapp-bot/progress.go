@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wedding-whatsapp/internal/handler"
+	"wedding-whatsapp/internal/phone"
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+// sendProgress shows a live counter while a bulk send runs and collects the guests that went wrong
+type sendProgress struct {
+	started  time.Time
+	total    int
+	sent     int
+	failed   int
+	skipped  int
+	problems []handler.Progress
+}
+
+// newSendProgress starts timing a bulk send
+func newSendProgress() *sendProgress {
+	return &sendProgress{started: time.Now()}
+}
+
+// update counts the outcome for one guest and redraws the counter; it's a handler.ProgressFunc
+func (p *sendProgress) update(progress handler.Progress) {
+	p.total = progress.Total
+	switch {
+	case progress.Skipped:
+		p.skipped++
+		p.problems = append(p.problems, progress)
+	case progress.Err != nil:
+		p.failed++
+		p.problems = append(p.problems, progress)
+	default:
+		p.sent++
+	}
+
+	line := fmt.Sprintf("📤 Sent %d/%d, %d failed", p.sent, progress.Total, p.failed)
+	if p.skipped > 0 {
+		line += fmt.Sprintf(", %d skipped", p.skipped)
+	}
+	// Sends are paced by the rate limit, so the pace so far says how long the rest will take
+	if left := progress.Total - progress.Done; left > 0 {
+		remaining := time.Since(p.started) / time.Duration(progress.Done) * time.Duration(left)
+		line += fmt.Sprintf(", about %s left", remaining.Round(time.Second))
+	}
+
+	// Pad over whatever was left of a longer previous line
+	fmt.Printf("\r%-60s", line)
+	if progress.Done == progress.Total {
+		fmt.Println()
+	}
+}
+
+// printSummary prints the totals and a table of the guests that failed or were skipped, with the reason
+func (p *sendProgress) printSummary() {
+	if p.total == 0 {
+		fmt.Println("Nobody to send to.")
+		return
+	}
+
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Sent: %d, Failed: %d, Skipped: %d, in %s\n", p.sent, p.failed, p.skipped, time.Since(p.started).Round(time.Second))
+	if len(p.problems) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("   %-24s %-16s %s\n", "Guest", "Phone", "Reason")
+	for _, problem := range p.problems {
+		icon := "❌"
+		if problem.Skipped {
+			icon = "⏭️ "
+		}
+		fmt.Printf("%s %-24s %-16s %s\n", icon, orDash(problem.Name), orDash(phone.Format(problem.PhoneNumber)), failureReason(problem.Err))
+	}
+	fmt.Println(strings.Repeat("-", 60))
+}
+
+// failureReason explains why a guest wasn't sent a message, in as few words as possible
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, whatsapp.ErrNotOnWhatsApp):
+		return "not on WhatsApp"
+	case errors.Is(err, whatsapp.ErrNotInContacts):
+		return "not in the phone's contacts yet"
+	case errors.Is(err, handler.ErrDoNotContact):
+		return "asked not to be messaged"
+	case errors.Is(err, handler.ErrAlreadyInvited):
+		return "already invited"
+	}
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// orDash returns s, or "-" when it's empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
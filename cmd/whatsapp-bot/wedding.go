@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wedding-whatsapp/internal/config"
+	"wedding-whatsapp/internal/handler"
+	"wedding-whatsapp/internal/metrics"
+	"wedding-whatsapp/internal/phone"
+	"wedding-whatsapp/internal/storage"
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+// wedding is one of the events the process manages, with its own WhatsApp session and guest list
+type wedding struct {
+	cfg             *config.Config
+	storage         storage.Store
+	rsvpHandler     *handler.RSVPHandler
+	whatsappService *whatsapp.Service
+	metricsServer   *metrics.Server
+
+	// stopRetries stops the confirmation retries and the daily digest, retriesDone is closed once the retries have
+	stopRetries chan struct{}
+	retriesDone chan struct{}
+}
+
+// startWedding opens the wedding's guest list, connects its WhatsApp session and starts its background work
+func startWedding(cfg *config.Config) (*wedding, error) {
+	// Initialize storage
+	guestStorage, err := openStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	// Initialize the queue of confirmation replies waiting to be retried
+	replyQueue, err := storage.NewReplyQueue(fmt.Sprintf("%s/confirmation_queue.json", cfg.WhatsAppDataDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize reply queue: %w", err)
+	}
+
+	// Broadcasts scheduled for later, including ones scheduled before a restart
+	broadcastSchedule, err := storage.NewBroadcastSchedule(fmt.Sprintf("%s/broadcast_schedule.json", cfg.WhatsAppDataDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize broadcast schedule: %w", err)
+	}
+
+	// Counters are only kept when they can be scraped
+	var botMetrics *metrics.Metrics
+	if cfg.MetricsAddr != "" {
+		botMetrics = metrics.New()
+	}
+
+	// Initialize WhatsApp service
+	whatsappCfg := &whatsapp.Config{
+		DataDir:        cfg.WhatsAppDataDir,
+		LogLevel:       cfg.LogLevel,
+		AllowedNumbers: cfg.AllowedNumbers,
+		BlockedNumbers: cfg.BlockedNumbers,
+
+		SessionPath: cfg.SessionPath(),
+		FileMode:    cfg.FileMode,
+		DirMode:     cfg.DirMode,
+
+		MinSendInterval: cfg.MinSendInterval,
+		SendJitter:      cfg.SendJitter,
+		MaxSendRetries:  cfg.MaxSendRetries,
+		RequestTimeout:  cfg.RequestTimeout,
+
+		QRTimeout:   cfg.QRTimeout,
+		JIDCacheTTL: cfg.JIDCacheTTL,
+
+		SkipWhatsAppCheck: cfg.SkipWhatsAppCheck,
+
+		QuietStart: cfg.QuietStart,
+		QuietEnd:   cfg.QuietEnd,
+		Timezone:   cfg.Timezone,
+
+		MaxReconnectAttempts: cfg.ReconnectMaxAttempts,
+
+		MessageWorkers:   cfg.MessageWorkers,
+		MessageQueueSize: cfg.MessageQueueSize,
+
+		InvitationImagePath: cfg.InvitationImagePath,
+		InteractiveButtons:  cfg.InteractiveButtons,
+		DryRun:              cfg.DryRun,
+		Metrics:             botMetrics,
+
+		InvitationDocumentPath: cfg.InvitationDocumentPath,
+	}
+	newService := whatsapp.NewService
+	if cfg.FakeWhatsApp {
+		newService = whatsapp.NewOfflineService
+	}
+	whatsappService, err := newService(whatsappCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize WhatsApp service: %w", err)
+	}
+
+	// The digest goes to the organizer's phone and, if asked for, the helpers' group
+	var digestGroup string
+	if cfg.DigestToGroup {
+		digestGroup = cfg.HelpersGroupJID
+	}
+	var digestSchedule *handler.DigestSchedule
+	if (cfg.DigestPhone != "" || digestGroup != "") && cfg.DigestTime != "" {
+		if digestSchedule, err = handler.ParseDigestSchedule(cfg.DigestTime, cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("failed to schedule the daily digest: %w", err)
+		}
+	}
+
+	// Initialize RSVP handler
+	templates, err := handler.LoadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load message templates: %w", err)
+	}
+	if cfg.GiftMessage != "" {
+		if err := templates.Override(handler.TemplateGift, cfg.GiftMessage); err != nil {
+			return nil, fmt.Errorf("invalid GIFT_MESSAGE: %w", err)
+		}
+	}
+
+	keywords, err := handler.LoadKeywords(cfg.KeywordsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load RSVP keywords: %w", err)
+	}
+
+	rsvpHandler := handler.NewRSVPHandler(whatsappService, guestStorage, replyQueue, broadcastSchedule, &handler.Config{
+		WeddingLocation: cfg.WeddingLocation,
+		BrideName:       cfg.BrideName,
+		GroomName:       cfg.GroomName,
+		Templates:       templates,
+		Keywords:        keywords,
+		Metrics:         botMetrics,
+
+		WeddingDate:     cfg.WeddingDate,
+		WeddingDateText: cfg.WeddingDateText,
+		PrimaryLanguage: cfg.PrimaryLanguage,
+
+		RSVPDeadline:     cfg.RSVPDeadline,
+		NotifyWebhookURL: cfg.NotifyWebhookURL,
+		OrganizerPhone:   cfg.OrganizerPhone,
+		VenueLatitude:    cfg.VenueLatitude,
+		VenueLongitude:   cfg.VenueLongitude,
+		RSVPBaseURL:      cfg.RSVPBaseURL,
+
+		UnknownSenderReply: cfg.UnknownSenderReply,
+		RSVPChangeDeadline: cfg.RSVPChangeDeadline,
+		AskPartySize:       cfg.AskPartySize,
+		MarkRead:           cfg.MarkRead,
+
+		TypoTolerance:  cfg.TypoTolerance,
+		ReinviteWindow: cfg.ReinviteWindow,
+
+		GiftLink: cfg.GiftLink,
+
+		EventOver: cfg.EventOver,
+
+		ConfirmationRetryMaxAttempts: cfg.ConfirmationRetryMaxAttempts,
+		ConfirmationRetryBaseDelay:   cfg.ConfirmationRetryBaseDelay,
+	})
+
+	// Set message, receipt, deferred message and JID handlers
+	whatsappService.SetMessageHandler(rsvpHandler.HandleMessage)
+	whatsappService.SetReceiptHandler(rsvpHandler.HandleReceipt)
+	whatsappService.SetSentHandler(rsvpHandler.HandleDeferredSent)
+	whatsappService.SetJIDHandler(rsvpHandler.HandleJIDVerified)
+
+	// Reuse the JIDs guests were verified at before a restart
+	for _, guest := range guestStorage.GetAllGuests() {
+		if guest.JID == "" {
+			continue
+		}
+		if err := whatsappService.RememberJID(guest.PhoneNumber, guest.JID, guest.JIDVerifiedAt); err != nil {
+			fmt.Printf("⚠️ Ignoring the stored JID of %s: %v\n", guest.DisplayPhone(), err)
+		}
+	}
+
+	// Connect to WhatsApp
+	fmt.Printf("Connecting to WhatsApp%s...\n", weddingLabel(cfg))
+	if err := whatsappService.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to WhatsApp: %w", err)
+	}
+
+	fmt.Printf("\n✅ Connected to WhatsApp%s!\n", weddingLabel(cfg))
+	fmt.Print("The bot is now listening for RSVP responses.\n\n")
+	if cfg.FakeWhatsApp {
+		fmt.Print("🧪 FAKE WHATSAPP: nothing is sent, use \"Simulate a guest's message\" to reply as a guest and see what the bot sends (unset FAKE_WHATSAPP to connect for real).\n\n")
+	} else if cfg.DryRun {
+		fmt.Print("🧪 DRY RUN: messages are logged with their recipients but NOT sent (unset DRY_RUN to send for real).\n\n")
+	}
+	for state, count := range rsvpHandler.OpenConversations() {
+		fmt.Printf("💬 Still waiting on %d guest(s) in state %s, their answers will be picked up\n", count, state)
+	}
+
+	// Retry any confirmation replies that failed, including ones queued before a restart
+	stopRetries := make(chan struct{})
+	retriesDone := make(chan struct{})
+	go func() {
+		rsvpHandler.RunConfirmationRetries(15*time.Second, stopRetries)
+		close(retriesDone)
+	}()
+
+	// Scheduled broadcasts also stop along with the retries; one cut short by shutdown isn't resumed
+	if pending := len(rsvpHandler.ScheduledBroadcasts()); pending > 0 {
+		fmt.Printf("📅 %d scheduled broadcast(s) waiting to be sent\n", pending)
+	}
+	go rsvpHandler.RunScheduledBroadcasts(stopRetries)
+
+	// The daily digest stops along with the retries; one cut short by shutdown is simply not sent
+	if digestSchedule != nil {
+		go rsvpHandler.RunDailyDigest(cfg.DigestPhone, digestGroup, digestSchedule, stopRetries)
+		if cfg.DigestPhone != "" {
+			fmt.Printf("📋 Daily digest will be sent to %s at %s\n", phone.Format(cfg.DigestPhone), cfg.DigestTime)
+		}
+		if digestGroup != "" {
+			fmt.Printf("📋 Daily digest will be posted to group %s at %s\n", digestGroup, cfg.DigestTime)
+		}
+	}
+
+	var metricsServer *metrics.Server
+	if cfg.MetricsAddr != "" {
+		metricsServer = metrics.NewServer(cfg.MetricsAddr, botMetrics, guestStorage.Stats)
+		go func() {
+			if err := metricsServer.Start(); err != nil {
+				fmt.Printf("Error serving metrics: %v\n", err)
+			}
+		}()
+		fmt.Printf("📈 Metrics available at http://%s/metrics\n", cfg.MetricsAddr)
+	}
+
+	return &wedding{
+		cfg:             cfg,
+		storage:         guestStorage,
+		rsvpHandler:     rsvpHandler,
+		whatsappService: whatsappService,
+		metricsServer:   metricsServer,
+		stopRetries:     stopRetries,
+		retriesDone:     retriesDone,
+	}, nil
+}
+
+// shutdown stops the wedding's background work and waits for it, and for incoming messages being
+// handled, to finish before disconnecting from WhatsApp
+func (w *wedding) shutdown(ctx context.Context) {
+	label := weddingLabel(w.cfg)
+
+	if w.metricsServer != nil {
+		if err := w.metricsServer.Shutdown(ctx); err != nil {
+			fmt.Printf("⚠️ Error stopping metrics server%s: %v\n", label, err)
+		}
+	}
+
+	close(w.stopRetries)
+	select {
+	case <-w.retriesDone:
+	case <-ctx.Done():
+		fmt.Printf("⚠️ Timed out waiting for confirmation retries%s\n", label)
+	}
+
+	drained, err := w.whatsappService.Shutdown(ctx)
+	if err != nil {
+		fmt.Printf("⚠️ Drained %d incoming message(s)%s before timing out: %v\n", drained, label, err)
+	} else {
+		fmt.Printf("✓ Drained %d incoming message(s)%s\n", drained, label)
+	}
+
+	w.whatsappService.Disconnect()
+}
+
+// name returns the wedding's ID, or "" when it's the only one
+func (w *wedding) name() string {
+	return w.cfg.WeddingID
+}
+
+// weddingLabel returns " for <ID>" to tell the weddings apart in messages, or "" when there's only one
+func weddingLabel(cfg *config.Config) string {
+	if cfg.WeddingID == "" {
+		return ""
+	}
+	return " for " + cfg.WeddingID
+}
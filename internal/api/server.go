@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"wedding-whatsapp/internal/config"
+	"wedding-whatsapp/internal/handler"
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+	"wedding-whatsapp/internal/storage"
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+// Server exposes the guest list over a small JSON HTTP API
+type Server struct {
+	weddings   []Wedding
+	token      string
+	httpServer *http.Server
+}
+
+// Wedding is one of the weddings served by the API. Requests pick one with ?wedding=<ID>,
+// and go to the first when they don't.
+type Wedding struct {
+	ID          string
+	Storage     storage.Store
+	RSVPHandler *handler.RSVPHandler
+	WhatsApp    *whatsapp.Service
+	Config      *config.Config
+}
+
+// invitationRequest is the body of POST /guests
+type invitationRequest struct {
+	Name          string `json:"name"`
+	PhoneNumber   string `json:"phone_number"`
+	CustomMessage string `json:"custom_message,omitempty"` // optional personal note appended to the invitation
+	Side          string `json:"side,omitempty"`           // optional side that invited the guest, e.g. bride or groom
+	Tier          string `json:"tier,omitempty"`           // optional tier picking the invitation, e.g. formal
+	Force         bool   `json:"force,omitempty"`          // send even if the guest was invited recently
+}
+
+// guestResponse is a guest as returned by the API. phone_number stays in the digits-only form
+// guests are looked up by, and display_phone has the same number in E.164 form, e.g. +972501234567.
+type guestResponse struct {
+	models.Guest
+	DisplayPhone string `json:"display_phone"`
+
+	// RSVPToken hides the guest's token, which lets anyone holding it answer for them;
+	// it's never set, and as the shallower field it's encoded (and omitted) instead of the guest's
+	RSVPToken string `json:"rsvp_token,omitempty"`
+}
+
+// newGuestResponse returns the API representation of a guest
+func newGuestResponse(guest models.Guest) guestResponse {
+	return guestResponse{Guest: guest, DisplayPhone: guest.DisplayPhone()}
+}
+
+// healthResponse is the body of GET /healthz
+type healthResponse struct {
+	Healthy  bool            `json:"healthy"`
+	Weddings []weddingHealth `json:"weddings"`
+}
+
+// weddingHealth is the health of one wedding's WhatsApp session and guest storage
+type weddingHealth struct {
+	ID              string `json:"id,omitempty"` // empty with a single wedding
+	Connected       bool   `json:"connected"`
+	StorageWritable bool   `json:"storage_writable"`
+	Error           string `json:"error,omitempty"` // why storage isn't writable
+}
+
+// errorResponse is returned with every non-2xx status
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer creates a new API server listening on addr for the given weddings.
+// Guest data, stats and settings are only served to requests with "Authorization: Bearer <token>".
+func NewServer(addr, token string, weddings []Wedding) *Server {
+	s := &Server{weddings: weddings, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /guests", s.authorized(s.listGuests))
+	mux.HandleFunc("GET /guests/{phone}", s.authorized(s.getGuest))
+	mux.HandleFunc("POST /guests", s.authorized(s.inviteGuest))
+	mux.HandleFunc("GET /stats", s.authorized(s.stats))
+	mux.HandleFunc("GET /config", s.authorized(s.settings))
+	mux.HandleFunc("GET /healthz", s.health)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// NewRSVPServer creates a server for guests' web RSVP links listening on addr. It only serves the links,
+// so it can be exposed publicly while the API, which lists every guest, stays private.
+// Tokens are unique across weddings, so a link works without naming its wedding.
+func NewRSVPServer(addr string, weddings []Wedding) *Server {
+	s := &Server{weddings: weddings}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rsvp/{token}", s.rsvp)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start serves requests until Shutdown is called
+func (s *Server) Start() error {
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve HTTP: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops the server, waiting for in-flight requests to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// authorized wraps a handler so it's only run for requests with the API token, answering 401 otherwise.
+// Without a token nothing is authorized, so the API can't be opened up by leaving it unset.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "missing or invalid API token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// wedding returns the wedding a request is for, writing a 404 if there's no such wedding
+func (s *Server) wedding(w http.ResponseWriter, r *http.Request) (*Wedding, bool) {
+	id := r.URL.Query().Get("wedding")
+	if id == "" {
+		return &s.weddings[0], true
+	}
+	for i := range s.weddings {
+		if s.weddings[i].ID == id {
+			return &s.weddings[i], true
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("wedding %s not found", id))
+	return nil, false
+}
+
+// listGuests handles GET /guests
+func (s *Server) listGuests(w http.ResponseWriter, r *http.Request) {
+	wedding, ok := s.wedding(w, r)
+	if !ok {
+		return
+	}
+	guests := wedding.Storage.GetAllGuests()
+	response := make([]guestResponse, len(guests))
+	for i, guest := range guests {
+		response[i] = newGuestResponse(guest)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// getGuest handles GET /guests/{phone}
+func (s *Server) getGuest(w http.ResponseWriter, r *http.Request) {
+	wedding, ok := s.wedding(w, r)
+	if !ok {
+		return
+	}
+	phoneNumber := whatsapp.NormalizePhoneNumber(r.PathValue("phone"))
+
+	guest, err := wedding.Storage.GetGuest(phoneNumber)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("guest %s not found", phone.Format(phoneNumber)))
+		return
+	}
+	writeJSON(w, http.StatusOK, newGuestResponse(*guest))
+}
+
+// inviteGuest handles POST /guests by sending the guest an invitation
+func (s *Server) inviteGuest(w http.ResponseWriter, r *http.Request) {
+	wedding, ok := s.wedding(w, r)
+	if !ok {
+		return
+	}
+
+	var req invitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.PhoneNumber = whatsapp.NormalizePhoneNumber(req.PhoneNumber)
+	if req.Name == "" || req.PhoneNumber == "" {
+		writeError(w, http.StatusBadRequest, "name and phone_number are required")
+		return
+	}
+
+	if err := wedding.RSVPHandler.SendInvitation(req.PhoneNumber, req.Name, req.CustomMessage, req.Side, req.Tier, req.Force); err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, handler.ErrAlreadyInvited) {
+			status = http.StatusConflict
+		} else if errors.Is(err, storage.ErrInvalidPhone) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, whatsapp.ErrNotOnWhatsApp) || errors.Is(err, whatsapp.ErrNotInContacts) {
+			status = http.StatusUnprocessableEntity
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	guest, err := wedding.Storage.GetGuest(req.PhoneNumber)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, newGuestResponse(*guest))
+}
+
+// stats handles GET /stats
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	wedding, ok := s.wedding(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, wedding.Storage.Stats())
+}
+
+// settings handles GET /config, listing the wedding's effective settings with secrets redacted
+func (s *Server) settings(w http.ResponseWriter, r *http.Request) {
+	wedding, ok := s.wedding(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, wedding.Config.Settings())
+}
+
+// health handles GET /healthz, a probe for container deployments. Every wedding is checked, whatever
+// ?wedding says, since the bot is only healthy when all of them are; it returns 503 when any isn't.
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	response := healthResponse{Healthy: true, Weddings: make([]weddingHealth, 0, len(s.weddings))}
+	for _, wedding := range s.weddings {
+		status := weddingHealth{ID: wedding.ID, Connected: wedding.WhatsApp.IsConnected(), StorageWritable: true}
+		if err := wedding.Storage.CheckWritable(); err != nil {
+			status.StorageWritable = false
+			status.Error = err.Error()
+		}
+		if !status.Connected || !status.StorageWritable {
+			response.Healthy = false
+		}
+		response.Weddings = append(response.Weddings, status)
+	}
+
+	code := http.StatusOK
+	if !response.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, response)
+}
+
+// rsvpAnswers maps the answer parameter of a web RSVP link to a status
+var rsvpAnswers = map[string]models.RSVPStatus{
+	"yes": models.RSVPAccepted,
+	"no":  models.RSVPDeclined,
+}
+
+// rsvp handles GET /rsvp/{token}?answer=yes|no, the web RSVP link sent to guests.
+// It answers in plain text since it's opened by guests in a browser.
+func (s *Server) rsvp(w http.ResponseWriter, r *http.Request) {
+	status, ok := rsvpAnswers[strings.ToLower(r.URL.Query().Get("answer"))]
+	if !ok {
+		writeText(w, http.StatusBadRequest, "Please open this link with ?answer=yes or ?answer=no to RSVP.")
+		return
+	}
+
+	var guest *models.Guest
+	err := handler.ErrInvalidToken
+	for _, wedding := range s.weddings {
+		if guest, err = wedding.RSVPHandler.RespondByToken(r.PathValue("token"), status); !errors.Is(err, handler.ErrInvalidToken) {
+			break
+		}
+	}
+	switch {
+	case errors.Is(err, handler.ErrInvalidToken):
+		writeText(w, http.StatusNotFound, "This RSVP link isn't valid.")
+		return
+	case errors.Is(err, handler.ErrRSVPClosed):
+		writeText(w, http.StatusGone, "RSVPs are closed. Please contact us directly if anything has changed.")
+		return
+	case err != nil:
+		writeText(w, http.StatusInternalServerError, "Sorry, we couldn't record your RSVP. Please try again or reply on WhatsApp.")
+		return
+	}
+
+	if status == models.RSVPAccepted {
+		writeText(w, http.StatusOK, fmt.Sprintf("Thank you, %s! We can't wait to celebrate with you. 💕", guest.Name))
+	} else {
+		writeText(w, http.StatusOK, fmt.Sprintf("Thank you for letting us know, %s. We'll miss you! 💕", guest.Name))
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeText writes a plain text response with the given status code
+func writeText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintln(w, text)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wedding-whatsapp/internal/config"
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/storage"
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+const testToken = "s3cret"
+
+// newTestServer creates a server for one wedding with an empty guest list in a temp dir
+func newTestServer(t *testing.T, token string) *Server {
+	t.Helper()
+	guests, err := storage.NewStorage(filepath.Join(t.TempDir(), "guests.json"))
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	return NewServer("", token, []Wedding{{Storage: guests, Config: &config.Config{}}})
+}
+
+// serve sends a request to the server with the given Authorization header, if any
+func serve(s *Server, method, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIRequiresToken(t *testing.T) {
+	s := newTestServer(t, testToken)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		want          int
+	}{
+		{"no token", "/guests", "", http.StatusUnauthorized},
+		{"wrong token", "/guests", "Bearer nope", http.StatusUnauthorized},
+		{"not a bearer token", "/guests", "Basic " + testToken, http.StatusUnauthorized},
+		{"guests", "/guests", "Bearer " + testToken, http.StatusOK},
+		{"stats without token", "/stats", "", http.StatusUnauthorized},
+		{"stats", "/stats", "Bearer " + testToken, http.StatusOK},
+		{"config without token", "/config", "", http.StatusUnauthorized},
+		{"unknown guest", "/guests/972501234567", "Bearer " + testToken, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(s, http.MethodGet, tt.path, tt.authorization).Code; got != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIWithoutTokenRejectsEverything(t *testing.T) {
+	s := newTestServer(t, "")
+
+	if got := serve(s, http.MethodGet, "/guests", "Bearer ").Code; got != http.StatusUnauthorized {
+		t.Errorf("GET /guests = %d, want %d", got, http.StatusUnauthorized)
+	}
+}
+
+func TestGuestResponseHidesRSVPToken(t *testing.T) {
+	guest := models.Guest{Name: "Dana", PhoneNumber: "972501234567", RSVPToken: "secret-token"}
+
+	data, err := json.Marshal(newGuestResponse(guest))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") || strings.Contains(string(data), "rsvp_token") {
+		t.Errorf("response = %s, want no RSVP token", data)
+	}
+}
+
+func TestGuestResponseDisplayPhone(t *testing.T) {
+	for _, number := range []string{"972501234567", "15551234567"} {
+		data, err := json.Marshal(newGuestResponse(models.Guest{PhoneNumber: number}))
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		var response struct {
+			PhoneNumber  string `json:"phone_number"`
+			DisplayPhone string `json:"display_phone"`
+		}
+		if err := json.Unmarshal(data, &response); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if response.PhoneNumber != number || response.DisplayPhone != "+"+number {
+			t.Errorf("response = %s, want the stored digits and +%s to display", data, number)
+		}
+	}
+}
+
+func TestRSVPLinksServedApartFromAPI(t *testing.T) {
+	guests, err := storage.NewStorage(filepath.Join(t.TempDir(), "guests.json"))
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	weddings := []Wedding{{Storage: guests, Config: &config.Config{}}}
+
+	// The API doesn't serve RSVP links, and the RSVP server serves nothing else
+	if got := serve(NewServer("", testToken, weddings), http.MethodGet, "/rsvp/abc?answer=yes", "").Code; got != http.StatusNotFound {
+		t.Errorf("API GET /rsvp/abc = %d, want %d", got, http.StatusNotFound)
+	}
+	rsvpServer := NewRSVPServer("", weddings)
+	for _, path := range []string{"/guests", "/stats", "/config", "/healthz"} {
+		if got := serve(rsvpServer, http.MethodGet, path, "Bearer "+testToken).Code; got != http.StatusNotFound {
+			t.Errorf("RSVP server GET %s = %d, want %d", path, got, http.StatusNotFound)
+		}
+	}
+	if got := serve(rsvpServer, http.MethodGet, "/rsvp/abc", "").Code; got != http.StatusBadRequest {
+		t.Errorf("RSVP server GET /rsvp/abc without an answer = %d, want %d", got, http.StatusBadRequest)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	dir := t.TempDir()
+	guests, err := storage.NewStorage(filepath.Join(dir, "data", "guests.json"))
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	// An offline service is always connected
+	service, err := whatsapp.NewOfflineService(&whatsapp.Config{DataDir: dir, LogLevel: "error"})
+	if err != nil {
+		t.Fatalf("NewOfflineService: %v", err)
+	}
+	s := NewServer("", testToken, []Wedding{{Storage: guests, WhatsApp: service, Config: &config.Config{}}})
+
+	// The probe doesn't need the token
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	rec := serve(s, http.MethodGet, "/healthz", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /healthz = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	// Storage that can't be written to makes the bot unhealthy
+	if err := os.RemoveAll(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(s, http.MethodGet, "/healthz", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /healthz = %d, want %d: %s", rec.Code, http.StatusServiceUnavailable, rec.Body)
+	}
+	var health healthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if health.Healthy || len(health.Weddings) != 1 || !health.Weddings[0].Connected || health.Weddings[0].StorageWritable {
+		t.Errorf("health = %+v, want connected but not writable", health)
+	}
+}
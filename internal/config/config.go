@@ -1,32 +1,489 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
+	// WeddingID identifies the wedding when one process manages several, empty otherwise
+	WeddingID string
+
 	WhatsAppDataDir string
-	WeddingDate     string
+	StorageBackend  string // "json" or "sqlite"
+
+	// GuestsFile and SessionDB override where the guest list and the WhatsApp session are stored,
+	// empty for their default files in WhatsAppDataDir; see GuestsPath and SessionPath
+	GuestsFile string
+	SessionDB  string
+	// FileMode and DirMode are the permissions data files, and the directories created for them, are given
+	FileMode os.FileMode
+	DirMode  os.FileMode
+
+	DefaultRegion   string // ISO 3166 region for phone numbers entered without a country code
+	APIAddr         string // listen address of the HTTP API, empty to disable it
+	APIToken        string // bearer token the HTTP API requires for guest data and settings
+	RSVPAddr        string // listen address of guests' web RSVP links, empty to disable them
+	LogLevel        string // debug, info, warn or error
 	WeddingLocation string
 	BrideName       string
 	GroomName       string
+
+	// WeddingDate is WEDDING_DATE parsed as YYYY-MM-DD or "YYYY-MM-DD HH:MM", and spelled out
+	// in the language of each message. It's zero when WEDDING_DATE is free text, which is
+	// then used as written from WeddingDateText.
+	WeddingDate     time.Time
+	WeddingDateText string
+
+	// PrimaryLanguage ("en" or "he") is used for guests until they reply in another language
+	PrimaryLanguage string
+
+	// InvitationImagePath is an optional image sent along with the invitation text
+	InvitationImagePath string
+	// InvitationDocumentPath is an optional file, like a PDF invitation, sent after the invitation text
+	InvitationDocumentPath string
+	// TemplatesDir holds <name>.tmpl files overriding the built-in message wording
+	TemplatesDir string
+	// KeywordsFile is a JSON file of RSVP keywords by language, replacing the built-in lists it names
+	KeywordsFile string
+	// InteractiveButtons sends invitations with Accept/Decline buttons where the account supports them
+	InteractiveButtons bool
+	// DryRun logs the messages that would be sent, and to whom, without sending anything
+	DryRun bool
+	// FakeWhatsApp runs without a WhatsApp account: nothing connects, sent messages are kept in memory
+	// and guests' messages are typed in from the CLI
+	FakeWhatsApp bool
+	// MarkRead sends read receipts for messages from guests once they're handled
+	MarkRead bool
+
+	// VenueLatitude and VenueLongitude locate the venue, sent as a pin to guests who accept.
+	// Leaving both at zero disables the pin.
+	VenueLatitude  float64
+	VenueLongitude float64
+
+	// GiftLink is a gift registry link added to the reply to guests who accept, empty to leave it out
+	GiftLink string
+	// GiftMessage replaces the built-in wording around GiftLink, a template that can use {{.GiftLink}}
+	GiftMessage string
+
+	// RSVPDeadline is when replies stop changing guests' RSVP status, zero for no deadline
+	RSVPDeadline time.Time
+	// RSVPChangeDeadline is when guests who already answered can no longer change their answer
+	RSVPChangeDeadline time.Time
+	// RSVPDeadlineText and RSVPChangeDeadlineText are the deadlines as written, so Validate can report typos
+	RSVPDeadlineText       string
+	RSVPChangeDeadlineText string
+
+	// EventOver switches to thanking guests instead of reading their messages as RSVPs.
+	// It also takes effect on its own from the day after WeddingDate.
+	EventOver bool
+
+	// NotifyWebhookURL receives a POST whenever a guest RSVPs or asks a question, empty to disable
+	NotifyWebhookURL string
+
+	// OrganizerPhone is sent the questions guests ask, so they can be answered personally
+	OrganizerPhone string
+
+	// AskPartySize makes the invitation ask how many will attend, following up on a plain "yes" for the number
+	AskPartySize bool
+
+	// TableCapacity is how many people fit at a table; the seating report flags tables over it
+	TableCapacity int
+
+	// UnknownSenderReply is sent once to numbers that message the bot without being invited, empty to stay silent
+	UnknownSenderReply string
+
+	// RSVPBaseURL is the public address RSVPAddr is reached at, used for guests' web RSVP links.
+	// Empty leaves the links out of messages.
+	RSVPBaseURL string
+
+	// MetricsAddr is the listen address of the Prometheus /metrics endpoint, empty to disable it
+	MetricsAddr string
+
+	// TypoTolerance is how many typos a short RSVP reply may have and still be understood, 0 for exact keywords only
+	TypoTolerance int
+
+	// ReinviteWindow is how long after inviting a guest another invitation needs to be forced,
+	// so running a CSV import twice doesn't message everyone again. Zero disables the check.
+	ReinviteWindow time.Duration
+
+	// Confirmation replies that fail to send are retried with exponential backoff
+	ConfirmationRetryMaxAttempts int
+	ConfirmationRetryBaseDelay   time.Duration
+
+	// When AllowedNumbers is non-empty the bot only talks to those numbers.
+	// BlockedNumbers are always excluded, and may also list group chat IDs to ignore.
+	AllowedNumbers []string
+	BlockedNumbers []string
+
+	// Outgoing messages are spaced out to avoid WhatsApp flagging the account
+	MinSendInterval time.Duration
+	SendJitter      time.Duration
+	MaxSendRetries  int
+
+	// RequestTimeout bounds each call to WhatsApp, so a hung connection can't block a send forever
+	RequestTimeout time.Duration
+
+	// QRTimeout is how long to wait for the QR code to be scanned when linking the bot
+	QRTimeout time.Duration
+
+	// JIDCacheTTL is how long a number verified on WhatsApp is trusted before it's looked up again
+	JIDCacheTTL time.Duration
+
+	// SkipWhatsAppCheck sends without first checking the number is on WhatsApp, for regions where
+	// the check wrongly reports numbers as missing
+	SkipWhatsAppCheck bool
+
+	// Invitations and reminders are deferred during quiet hours (HH:MM in Timezone, e.g. Asia/Jerusalem).
+	// Replies to guests are always sent straight away.
+	QuietStart string
+	QuietEnd   string
+	Timezone   string
+
+	// DigestPhone receives a summary of the RSVPs every day at DigestTime (HH:MM in Timezone).
+	// The digest is disabled unless both are set.
+	DigestPhone string
+	DigestTime  string
+
+	// HelpersGroupJID is a group chat of the people helping with the wedding (e.g. 120363012345678901@g.us),
+	// which the digest is also posted to when DigestToGroup is set
+	HelpersGroupJID string
+	DigestToGroup   bool
+
+	// Reconnection after an unexpected disconnect gives up after this many attempts
+	ReconnectMaxAttempts int
+
+	// Incoming messages are processed by a pool of workers
+	MessageWorkers   int
+	MessageQueueSize int
 }
 
+// Defaults of the wedding details, which are placeholders that must never reach guests
+const (
+	defaultWeddingDate     = "Saturday, January 1, 2025"
+	defaultWeddingLocation = "Venue TBD"
+	defaultBrideName       = "Bride"
+	defaultGroomName       = "Groom"
+)
+
 // LoadConfig loads configuration from environment variables or defaults
 func LoadConfig() *Config {
+	return loadConfig(env{})
+}
+
+// loadConfig loads configuration from the environment variables of e
+func loadConfig(e env) *Config {
 	return &Config{
-		WhatsAppDataDir: getEnv("WHATSAPP_DATA_DIR", "data"),
-		WeddingDate:     getEnv("WEDDING_DATE", "Saturday, January 1, 2025"),
-		WeddingLocation: getEnv("WEDDING_LOCATION", "Venue TBD"),
-		BrideName:       getEnv("BRIDE_NAME", "Bride"),
-		GroomName:       getEnv("GROOM_NAME", "Groom"),
+		WhatsAppDataDir: e.getEnv("WHATSAPP_DATA_DIR", "data"),
+		StorageBackend:  e.getEnv("STORAGE_BACKEND", "json"),
+
+		GuestsFile: e.getEnv("GUESTS_FILE", ""),
+		SessionDB:  e.getEnv("WHATSAPP_SESSION_DB", ""),
+		FileMode:   e.getEnvMode("FILE_MODE", 0600),
+		DirMode:    e.getEnvMode("DIR_MODE", 0700),
+
+		DefaultRegion:   e.getEnv("DEFAULT_REGION", "IL"),
+		APIAddr:         e.getEnv("API_ADDR", ""),
+		APIToken:        e.getEnv("API_TOKEN", ""),
+		RSVPAddr:        e.getEnv("RSVP_ADDR", ""),
+		LogLevel:        e.getEnv("LOG_LEVEL", "info"),
+		WeddingLocation: e.getEnv("WEDDING_LOCATION", defaultWeddingLocation),
+		BrideName:       e.getEnv("BRIDE_NAME", defaultBrideName),
+		GroomName:       e.getEnv("GROOM_NAME", defaultGroomName),
+
+		WeddingDate:     e.getEnvDate("WEDDING_DATE"),
+		WeddingDateText: e.getEnv("WEDDING_DATE", defaultWeddingDate),
+		// DATE_LOCALE is the older name, from when only the date was localized
+		PrimaryLanguage: e.getEnv("PRIMARY_LANGUAGE", e.getEnv("DATE_LOCALE", "en")),
+
+		InvitationImagePath: e.getEnv("INVITATION_IMAGE_PATH", ""),
+		TemplatesDir:        e.getEnv("TEMPLATES_DIR", ""),
+		InteractiveButtons:  e.getEnvBool("INTERACTIVE_BUTTONS", false),
+		DryRun:              e.getEnvBool("DRY_RUN", false),
+		FakeWhatsApp:        e.getEnvBool("FAKE_WHATSAPP", false),
+
+		InvitationDocumentPath: e.getEnv("INVITATION_DOCUMENT_PATH", ""),
+		KeywordsFile:           e.getEnv("KEYWORDS_FILE", ""),
+		MarkRead:               e.getEnvBool("MARK_READ", false),
+
+		VenueLatitude:  e.getEnvFloat("VENUE_LAT", 0),
+		VenueLongitude: e.getEnvFloat("VENUE_LNG", 0),
+
+		GiftLink:    e.getEnv("GIFT_LINK", ""),
+		GiftMessage: e.getEnv("GIFT_MESSAGE", ""),
+
+		RSVPDeadline:           e.getEnvDeadline("RSVP_DEADLINE"),
+		RSVPChangeDeadline:     e.getEnvDeadline("RSVP_CHANGE_DEADLINE"),
+		RSVPDeadlineText:       e.getEnv("RSVP_DEADLINE", ""),
+		RSVPChangeDeadlineText: e.getEnv("RSVP_CHANGE_DEADLINE", ""),
+
+		EventOver: e.getEnvBool("EVENT_OVER", false),
+
+		NotifyWebhookURL: e.getEnv("NOTIFY_WEBHOOK_URL", ""),
+
+		OrganizerPhone: e.getEnv("ORGANIZER_PHONE", ""),
+
+		AskPartySize: e.getEnvBool("ASK_PARTY_SIZE", false),
+
+		UnknownSenderReply: e.getEnv("UNKNOWN_SENDER_REPLY", ""),
+
+		TableCapacity: e.getEnvInt("TABLE_CAPACITY", 10),
+
+		RSVPBaseURL: e.getEnv("RSVP_BASE_URL", ""),
+
+		MetricsAddr: e.getEnv("METRICS_ADDR", ""),
+
+		TypoTolerance:  e.getEnvInt("RSVP_TYPO_TOLERANCE", 1),
+		ReinviteWindow: e.getEnvDuration("REINVITE_WINDOW", 7*24*time.Hour),
+
+		ConfirmationRetryMaxAttempts: e.getEnvInt("CONFIRMATION_RETRY_MAX_ATTEMPTS", 5),
+		ConfirmationRetryBaseDelay:   e.getEnvDuration("CONFIRMATION_RETRY_BASE_DELAY", 30*time.Second),
+
+		AllowedNumbers: e.getEnvList("ALLOWED_NUMBERS"),
+		BlockedNumbers: e.getEnvList("BLOCKED_NUMBERS"),
+
+		MinSendInterval: e.getEnvDuration("MIN_SEND_INTERVAL", 3*time.Second),
+		SendJitter:      e.getEnvDuration("SEND_JITTER", 2*time.Second),
+		MaxSendRetries:  e.getEnvInt("MAX_SEND_RETRIES", 3),
+
+		RequestTimeout: e.getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+
+		QRTimeout: e.getEnvDuration("QR_TIMEOUT", 2*time.Minute),
+
+		JIDCacheTTL: e.getEnvDuration("JID_CACHE_TTL", 24*time.Hour),
+
+		SkipWhatsAppCheck: e.getEnvBool("SKIP_WHATSAPP_CHECK", false),
+
+		QuietStart: e.getEnv("QUIET_START", ""),
+		QuietEnd:   e.getEnv("QUIET_END", ""),
+		Timezone:   e.getEnv("TIMEZONE", ""),
+
+		DigestPhone: e.getEnv("DIGEST_PHONE", ""),
+		DigestTime:  e.getEnv("DIGEST_TIME", ""),
+
+		HelpersGroupJID: e.getEnv("HELPERS_GROUP_JID", ""),
+		DigestToGroup:   e.getEnvBool("DIGEST_TO_GROUP", false),
+
+		ReconnectMaxAttempts: e.getEnvInt("RECONNECT_MAX_ATTEMPTS", 10),
+
+		MessageWorkers:   e.getEnvInt("MESSAGE_WORKERS", 4),
+		MessageQueueSize: e.getEnvInt("MESSAGE_QUEUE_SIZE", 100),
+	}
+}
+
+// Validate checks that the wedding details have been configured and the data directory is writable,
+// so invitations are never sent with placeholder text
+func (c *Config) Validate() error {
+	var errs []error
+
+	for _, field := range []struct{ env, value, placeholder string }{
+		{"WEDDING_DATE", c.WeddingDateText, defaultWeddingDate},
+		{"WEDDING_LOCATION", c.WeddingLocation, defaultWeddingLocation},
+		{"BRIDE_NAME", c.BrideName, defaultBrideName},
+		{"GROOM_NAME", c.GroomName, defaultGroomName},
+	} {
+		value := strings.TrimSpace(field.value)
+		if value == "" || value == field.placeholder {
+			errs = append(errs, fmt.Errorf("%s is not set (got %q)", field.env, field.value))
+		}
+	}
+
+	if c.PrimaryLanguage != "en" && c.PrimaryLanguage != "he" {
+		errs = append(errs, fmt.Errorf("PRIMARY_LANGUAGE must be en or he (got %q)", c.PrimaryLanguage))
 	}
+
+	// A mistyped deadline would otherwise leave RSVPs open
+	for _, deadline := range []struct{ env, value string }{
+		{"RSVP_DEADLINE", c.RSVPDeadlineText},
+		{"RSVP_CHANGE_DEADLINE", c.RSVPChangeDeadlineText},
+	} {
+		if _, err := ParseDeadline(deadline.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", deadline.env, err))
+		}
+	}
+
+	// The API sends invitations and lists guests' numbers, so it's never served without a token
+	if c.APIAddr != "" && c.APIToken == "" {
+		errs = append(errs, fmt.Errorf("API_TOKEN is not set, it's required when API_ADDR is"))
+	}
+
+	// Links would be sent to guests without anything to answer them
+	if c.RSVPBaseURL != "" && c.RSVPAddr == "" {
+		errs = append(errs, fmt.Errorf("RSVP_ADDR is not set, it's required for the links of RSVP_BASE_URL to work"))
+	}
+
+	if err := checkWritable(c.WhatsAppDataDir, c.DirMode); err != nil {
+		errs = append(errs, fmt.Errorf("WHATSAPP_DATA_DIR %q is not writable: %w", c.WhatsAppDataDir, err))
+	}
+	if c.GuestsFile != "" {
+		if err := checkWritable(filepath.Dir(c.GuestsFile), c.DirMode); err != nil {
+			errs = append(errs, fmt.Errorf("GUESTS_FILE %q is not writable: %w", c.GuestsFile, err))
+		}
+	}
+	if c.SessionDB != "" {
+		if err := checkWritable(filepath.Dir(c.SessionDB), c.DirMode); err != nil {
+			errs = append(errs, fmt.Errorf("WHATSAPP_SESSION_DB %q is not writable: %w", c.SessionDB, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GuestsPath returns the file the guest list is stored in: GuestsFile, or guests.json
+// (guests.db with the SQLite backend) in WhatsAppDataDir
+func (c *Config) GuestsPath() string {
+	if c.GuestsFile != "" {
+		return c.GuestsFile
+	}
+	if c.StorageBackend == "sqlite" {
+		return filepath.Join(c.WhatsAppDataDir, "guests.db")
+	}
+	return filepath.Join(c.WhatsAppDataDir, "guests.json")
+}
+
+// SessionPath returns the WhatsApp session database: SessionDB, or whatsmeow.db in WhatsAppDataDir
+func (c *Config) SessionPath() string {
+	if c.SessionDB != "" {
+		return c.SessionDB
+	}
+	return filepath.Join(c.WhatsAppDataDir, "whatsmeow.db")
+}
+
+// checkWritable creates dir with the given permissions if needed and verifies a file can be written to it
+func checkWritable(dir string, perm os.FileMode) error {
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// env reads settings from environment variables. With a prefix, as for one of several weddings,
+// <PREFIX>_<KEY> takes precedence over the <KEY> shared by all of them.
+type env struct {
+	prefix string
+}
+
+// lookup returns the value of the setting, or "" if it isn't set
+func (e env) lookup(key string) string {
+	if e.prefix != "" {
+		if value := os.Getenv(e.prefix + "_" + key); value != "" {
+			return value
+		}
+	}
+	return os.Getenv(key)
+}
+
+// source returns the name of the environment variable the setting is read from, or "" if it isn't set
+func (e env) source(key string) string {
+	if e.prefix != "" && os.Getenv(e.prefix+"_"+key) != "" {
+		return e.prefix + "_" + key
+	}
+	if os.Getenv(key) != "" {
+		return key
+	}
+	return ""
+}
+
+func (e env) getEnv(key, defaultValue string) string {
+	if value := e.lookup(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (e env) getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(e.lookup(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func (e env) getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(e.lookup(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+func (e env) getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(e.lookup(key)); err == nil {
 		return value
 	}
 	return defaultValue
 }
+
+func (e env) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(e.lookup(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvMode reads file permissions written in octal, e.g. 0640
+func (e env) getEnvMode(key string, defaultValue os.FileMode) os.FileMode {
+	if value, err := strconv.ParseUint(e.lookup(key), 8, 32); err == nil && value <= 0777 {
+		return os.FileMode(value)
+	}
+	return defaultValue
+}
+
+// weddingDateLayouts are the layouts WEDDING_DATE is parsed with, so day and month can't be mixed up
+var weddingDateLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// getEnvDate reads a date in one of weddingDateLayouts, or zero if unset or free text
+func (e env) getEnvDate(key string) time.Time {
+	value := strings.TrimSpace(e.lookup(key))
+	for _, layout := range weddingDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// getEnvDeadline reads a deadline in a format accepted by ParseDeadline, or zero if unset or invalid.
+// Validate reports an invalid one.
+func (e env) getEnvDeadline(key string) time.Time {
+	deadline, _ := ParseDeadline(e.lookup(key))
+	return deadline
+}
+
+// ParseDeadline parses a deadline given as a date (2006-01-02), which lasts until the end of that day
+// in local time, or as an RFC 3339 timestamp. An empty value is no deadline.
+func ParseDeadline(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date.AddDate(0, 0, 1), nil
+	}
+	deadline, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline %q, expected YYYY-MM-DD or RFC 3339", value)
+	}
+	return deadline, nil
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries
+func (e env) getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(e.lookup(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIDisabledByDefault(t *testing.T) {
+	t.Setenv("API_ADDR", "")
+
+	if addr := LoadConfig().APIAddr; addr != "" {
+		t.Errorf("APIAddr = %q, want the API disabled unless API_ADDR is set", addr)
+	}
+}
+
+func TestValidateRequiresAPIToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		token   string
+		wantErr bool
+	}{
+		{"API disabled", "", "", false},
+		{"API without token", "localhost:8080", "", true},
+		{"API with token", "localhost:8080", "s3cret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_ADDR", tt.addr)
+			t.Setenv("API_TOKEN", tt.token)
+			t.Setenv("WHATSAPP_DATA_DIR", t.TempDir())
+
+			err := LoadConfig().Validate()
+			if got := err != nil && strings.Contains(err.Error(), "API_TOKEN"); got != tt.wantErr {
+				t.Errorf("Validate() = %v, want an API_TOKEN error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRequiresRSVPAddrForLinks(t *testing.T) {
+	t.Setenv("RSVP_BASE_URL", "https://rsvp.example.com")
+	t.Setenv("RSVP_ADDR", "")
+	t.Setenv("WHATSAPP_DATA_DIR", t.TempDir())
+
+	if err := LoadConfig().Validate(); err == nil || !strings.Contains(err.Error(), "RSVP_ADDR") {
+		t.Errorf("Validate() = %v, want an RSVP_ADDR error", err)
+	}
+}
+
+func TestLoadWeddingsRejectsDifferentProcessSettings(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"FILE_MODE", "0640"},
+		{"DIR_MODE", "0750"},
+		{"DEFAULT_REGION", "US"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv("WEDDINGS", "anna-ben,dana-eli")
+			t.Setenv("WHATSAPP_DATA_DIR", t.TempDir())
+			t.Setenv("DANA_ELI_"+tt.key, tt.value)
+
+			_, err := LoadWeddings()
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("LoadWeddings() = %v, want an error about %s", err, tt.key)
+			}
+		})
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		t.Setenv("WEDDINGS", "anna-ben,dana-eli")
+		t.Setenv("WHATSAPP_DATA_DIR", t.TempDir())
+		t.Setenv("DEFAULT_REGION", "US")
+
+		if _, err := LoadWeddings(); err != nil {
+			t.Errorf("LoadWeddings() with shared settings: %v", err)
+		}
+	})
+}
+
+func TestValidateRejectsInvalidDeadlines(t *testing.T) {
+	tests := []struct {
+		env, value string
+		wantErr    bool
+	}{
+		{"RSVP_DEADLINE", "2025-06-01", false},
+		{"RSVP_DEADLINE", "2025-06-01T18:00:00+03:00", false},
+		{"RSVP_DEADLINE", "June 1st", true},
+		{"RSVP_CHANGE_DEADLINE", "2025-13-01", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			t.Setenv("RSVP_DEADLINE", "")
+			t.Setenv("RSVP_CHANGE_DEADLINE", "")
+			t.Setenv(tt.env, tt.value)
+			t.Setenv("WHATSAPP_DATA_DIR", t.TempDir())
+
+			err := LoadConfig().Validate()
+			if got := err != nil && strings.Contains(err.Error(), tt.env); got != tt.wantErr {
+				t.Errorf("Validate() = %v, want a %s error: %v", err, tt.env, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of settings that may contain credentials
+const redacted = "[redacted]"
+
+// Setting is one effective configuration value, as listed by Settings
+type Setting struct {
+	Name   string `json:"name"`   // the environment variable, without a wedding's prefix
+	Value  string `json:"value"`  // empty when unset, or redacted for secrets
+	Source string `json:"source"` // the environment variable it was read from, or "default"
+}
+
+// Settings lists the effective configuration by environment variable, with where each value came from,
+// so it can be checked which variables took effect. Secrets such as the webhook URL and API token are redacted.
+func (c *Config) Settings() []Setting {
+	e := env{}
+	if c.WeddingID != "" {
+		e.prefix = envPrefix(c.WeddingID)
+	}
+
+	weddingDate := c.WeddingDateText
+	if !c.WeddingDate.IsZero() {
+		weddingDate = fmt.Sprintf("%s (parsed as %s)", c.WeddingDateText, c.WeddingDate.Format("Monday 2006-01-02 15:04 MST"))
+	}
+	webhook := c.NotifyWebhookURL
+	if webhook != "" {
+		webhook = redacted
+	}
+	apiToken := c.APIToken
+	if apiToken != "" {
+		apiToken = redacted
+	}
+
+	values := []struct{ name, value string }{
+		{"WHATSAPP_DATA_DIR", absPath(c.WhatsAppDataDir)},
+		{"STORAGE_BACKEND", c.StorageBackend},
+		{"GUESTS_FILE", absPath(c.GuestsPath())},
+		{"WHATSAPP_SESSION_DB", absPath(c.SessionPath())},
+		{"FILE_MODE", fmt.Sprintf("%#o", c.FileMode)},
+		{"DIR_MODE", fmt.Sprintf("%#o", c.DirMode)},
+		{"DEFAULT_REGION", c.DefaultRegion},
+		{"API_ADDR", c.APIAddr},
+		{"API_TOKEN", apiToken},
+		{"RSVP_ADDR", c.RSVPAddr},
+		{"LOG_LEVEL", c.LogLevel},
+		{"WEDDING_DATE", weddingDate},
+		{"WEDDING_LOCATION", c.WeddingLocation},
+		{"BRIDE_NAME", c.BrideName},
+		{"GROOM_NAME", c.GroomName},
+		{"PRIMARY_LANGUAGE", c.PrimaryLanguage},
+		{"INVITATION_IMAGE_PATH", c.InvitationImagePath},
+		{"INVITATION_DOCUMENT_PATH", c.InvitationDocumentPath},
+		{"TEMPLATES_DIR", c.TemplatesDir},
+		{"KEYWORDS_FILE", c.KeywordsFile},
+		{"INTERACTIVE_BUTTONS", strconv.FormatBool(c.InteractiveButtons)},
+		{"DRY_RUN", strconv.FormatBool(c.DryRun)},
+		{"FAKE_WHATSAPP", strconv.FormatBool(c.FakeWhatsApp)},
+		{"MARK_READ", strconv.FormatBool(c.MarkRead)},
+		{"VENUE_LAT", strconv.FormatFloat(c.VenueLatitude, 'f', -1, 64)},
+		{"VENUE_LNG", strconv.FormatFloat(c.VenueLongitude, 'f', -1, 64)},
+		{"GIFT_LINK", c.GiftLink},
+		{"GIFT_MESSAGE", c.GiftMessage},
+		{"RSVP_DEADLINE", formatTime(c.RSVPDeadline)},
+		{"RSVP_CHANGE_DEADLINE", formatTime(c.RSVPChangeDeadline)},
+		{"EVENT_OVER", strconv.FormatBool(c.EventOver)},
+		{"NOTIFY_WEBHOOK_URL", webhook},
+		{"ORGANIZER_PHONE", c.OrganizerPhone},
+		{"ASK_PARTY_SIZE", strconv.FormatBool(c.AskPartySize)},
+		{"UNKNOWN_SENDER_REPLY", c.UnknownSenderReply},
+		{"TABLE_CAPACITY", strconv.Itoa(c.TableCapacity)},
+		{"RSVP_BASE_URL", c.RSVPBaseURL},
+		{"METRICS_ADDR", c.MetricsAddr},
+		{"RSVP_TYPO_TOLERANCE", strconv.Itoa(c.TypoTolerance)},
+		{"REINVITE_WINDOW", c.ReinviteWindow.String()},
+		{"CONFIRMATION_RETRY_MAX_ATTEMPTS", strconv.Itoa(c.ConfirmationRetryMaxAttempts)},
+		{"CONFIRMATION_RETRY_BASE_DELAY", c.ConfirmationRetryBaseDelay.String()},
+		{"ALLOWED_NUMBERS", strings.Join(c.AllowedNumbers, ",")},
+		{"BLOCKED_NUMBERS", strings.Join(c.BlockedNumbers, ",")},
+		{"MIN_SEND_INTERVAL", c.MinSendInterval.String()},
+		{"SEND_JITTER", c.SendJitter.String()},
+		{"MAX_SEND_RETRIES", strconv.Itoa(c.MaxSendRetries)},
+		{"REQUEST_TIMEOUT", c.RequestTimeout.String()},
+		{"QR_TIMEOUT", c.QRTimeout.String()},
+		{"JID_CACHE_TTL", c.JIDCacheTTL.String()},
+		{"SKIP_WHATSAPP_CHECK", strconv.FormatBool(c.SkipWhatsAppCheck)},
+		{"QUIET_START", c.QuietStart},
+		{"QUIET_END", c.QuietEnd},
+		{"TIMEZONE", c.Timezone},
+		{"DIGEST_PHONE", c.DigestPhone},
+		{"DIGEST_TIME", c.DigestTime},
+		{"HELPERS_GROUP_JID", c.HelpersGroupJID},
+		{"DIGEST_TO_GROUP", strconv.FormatBool(c.DigestToGroup)},
+		{"RECONNECT_MAX_ATTEMPTS", strconv.Itoa(c.ReconnectMaxAttempts)},
+		{"MESSAGE_WORKERS", strconv.Itoa(c.MessageWorkers)},
+		{"MESSAGE_QUEUE_SIZE", strconv.Itoa(c.MessageQueueSize)},
+	}
+
+	settings := make([]Setting, 0, len(values))
+	for _, v := range values {
+		source := e.source(v.name)
+		// DATE_LOCALE is the older name of PRIMARY_LANGUAGE
+		if source == "" && v.name == "PRIMARY_LANGUAGE" {
+			source = e.source("DATE_LOCALE")
+		}
+		if source == "" {
+			source = "default"
+		}
+		settings = append(settings, Setting{Name: v.name, Value: v.value, Source: source})
+	}
+	return settings
+}
+
+// absPath resolves a path for Settings, keeping it as it is if it can't be resolved
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// formatTime formats a deadline for Settings, empty when it isn't set
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
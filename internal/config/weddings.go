@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// LoadWeddings loads the configuration of every wedding the process manages.
+// WEDDINGS lists their IDs, e.g. "anna-ben,dana-eli"; when it's unset there is a single wedding
+// configured by LoadConfig. Each wedding reads <ID>_<KEY> in preference to the shared <KEY>, so
+// ANNA_BEN_BRIDE_NAME sets the bride of "anna-ben", and keeps its data (the WhatsApp session and
+// guest list) in its own directory under WHATSAPP_DATA_DIR unless <ID>_WHATSAPP_DATA_DIR is set.
+func LoadWeddings() ([]*Config, error) {
+	ids := env{}.getEnvList("WEDDINGS")
+	if len(ids) == 0 {
+		return []*Config{LoadConfig()}, nil
+	}
+
+	configs := make([]*Config, 0, len(ids))
+	prefixes := make(map[string]string)
+	for _, id := range ids {
+		if strings.ContainsFunc(id, func(r rune) bool { return !validIDRune(r) }) {
+			return nil, fmt.Errorf("invalid wedding ID %q, use only letters, digits, - and _", id)
+		}
+		prefix := envPrefix(id)
+		if other, ok := prefixes[prefix]; ok {
+			return nil, fmt.Errorf("weddings %q and %q would share the settings prefix %s_", other, id, prefix)
+		}
+		prefixes[prefix] = id
+
+		cfg := loadConfig(env{prefix: prefix})
+		cfg.WeddingID = id
+		if os.Getenv(prefix+"_WHATSAPP_DATA_DIR") == "" {
+			cfg.WhatsAppDataDir = filepath.Join(cfg.WhatsAppDataDir, id)
+		}
+		configs = append(configs, cfg)
+	}
+
+	// Settings shared by every wedding can't point them all at the same files
+	guestLists := make(map[string]string)
+	sessions := make(map[string]string)
+	for _, cfg := range configs {
+		if other, ok := guestLists[cfg.GuestsPath()]; ok {
+			return nil, fmt.Errorf("weddings %q and %q would share the guest list %s, set <ID>_GUESTS_FILE for each", other, cfg.WeddingID, cfg.GuestsPath())
+		}
+		guestLists[cfg.GuestsPath()] = cfg.WeddingID
+		if other, ok := sessions[cfg.SessionPath()]; ok {
+			return nil, fmt.Errorf("weddings %q and %q would share the WhatsApp session %s, set <ID>_WHATSAPP_SESSION_DB for each", other, cfg.WeddingID, cfg.SessionPath())
+		}
+		sessions[cfg.SessionPath()] = cfg.WeddingID
+	}
+
+	// Permissions and the default region apply to the whole process, so every wedding must agree on them
+	first := configs[0]
+	for _, cfg := range configs[1:] {
+		switch {
+		case cfg.FileMode != first.FileMode:
+			return nil, fmt.Errorf("weddings %q and %q have different FILE_MODE settings, it must be the same for every wedding", first.WeddingID, cfg.WeddingID)
+		case cfg.DirMode != first.DirMode:
+			return nil, fmt.Errorf("weddings %q and %q have different DIR_MODE settings, it must be the same for every wedding", first.WeddingID, cfg.WeddingID)
+		case cfg.DefaultRegion != first.DefaultRegion:
+			return nil, fmt.Errorf("weddings %q and %q have different DEFAULT_REGION settings, it must be the same for every wedding", first.WeddingID, cfg.WeddingID)
+		}
+	}
+	return configs, nil
+}
+
+// envPrefix returns the prefix of a wedding's settings: its ID in upper case, with anything
+// but letters and digits replaced by underscores
+func envPrefix(id string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, id)
+}
+
+// validIDRune reports whether r may appear in a wedding ID, which is also a directory name
+func validIDRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_'
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// BroadcastResult is the outcome of a broadcast message to a single guest
+type BroadcastResult struct {
+	Name        string
+	PhoneNumber string
+	Skipped     bool  // the guest is unreachable or asked not to be messaged, and nothing was sent
+	Deferred    bool  // it's quiet hours, so the message is held back and sent once they end
+	Err         error // nil when the message was sent
+}
+
+// BroadcastToStatus sends message to every guest with the given RSVP status, e.g. final details
+// for everyone who accepted. The message may use the same fields as the message templates,
+// like {{.GuestName}}. A failure for one guest doesn't stop the rest.
+func (h *RSVPHandler) BroadcastToStatus(status models.RSVPStatus, message string, progress ProgressFunc) ([]BroadcastResult, error) {
+	tmpl, err := parseBroadcast(message)
+	if err != nil {
+		return nil, err
+	}
+
+	guests := h.storage.GetGuestsByStatus(status)
+	var results []BroadcastResult
+	for i, guest := range guests {
+		result := h.broadcastTo(tmpl, guest)
+		results = append(results, result)
+		progress.report(Progress{
+			Done:        i + 1,
+			Total:       len(guests),
+			Name:        result.Name,
+			PhoneNumber: result.PhoneNumber,
+			Skipped:     result.Skipped,
+			Err:         result.Err,
+		})
+	}
+
+	return results, nil
+}
+
+// parseBroadcast parses a broadcast message, which may use the template fields
+func parseBroadcast(message string) (*template.Template, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, errors.New("broadcast message is empty")
+	}
+	tmpl, err := template.New("broadcast").Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse broadcast message: %w", err)
+	}
+	return tmpl, nil
+}
+
+// broadcastTo sends a broadcast message to a single guest.
+// It's sent like a reminder, so it waits for quiet hours to end rather than waking the guest up.
+func (h *RSVPHandler) broadcastTo(tmpl *template.Template, guest models.Guest) BroadcastResult {
+	result := BroadcastResult{Name: guest.Name, PhoneNumber: guest.PhoneNumber}
+	if guest.Unreachable {
+		result.Skipped = true
+		result.Err = fmt.Errorf("unreachable: %s", guest.LastError)
+		return result
+	}
+	if guest.DoNotContact {
+		result.Skipped = true
+		result.Err = ErrDoNotContact
+		return result
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, h.messageData(&guest, guest.PartySize)); err != nil {
+		result.Err = fmt.Errorf("failed to render broadcast message: %w", err)
+		return result
+	}
+
+	messageID, err := h.whatsappService.SendReminder(guest.PhoneNumber, b.String())
+	if err != nil {
+		result.Err = errors.Join(fmt.Errorf("failed to send broadcast message: %w", err), h.recordSendFailure(guest.PhoneNumber, err))
+		return result
+	}
+	// A message held back for quiet hours has no ID until it goes out
+	result.Deferred = messageID == ""
+	return result
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestBroadcastToStatusSkipsOptedOutGuests(t *testing.T) {
+	const otherPhone = "972509876543"
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+	addPendingGuest(t, guests, otherPhone, "Yossi")
+	receive(t, h, testPhone, "yes")
+	receive(t, h, otherPhone, "yes")
+
+	sender.reset()
+	receive(t, h, testPhone, "stop")
+	if reply := sender.last(t, testPhone); !strings.Contains(reply, "we won't send you any more messages") {
+		t.Errorf("reply = %q, want the opt-out confirmation", reply)
+	}
+	if guest, _ := guests.GetGuest(testPhone); !guest.DoNotContact || guest.RSVPStatus != models.RSVPAccepted {
+		t.Fatalf("guest = %+v, want them opted out and still accepted", guest)
+	}
+
+	sender.reset()
+	results, err := h.BroadcastToStatus(models.RSVPAccepted, "Hi {{.GuestName}}, the bus leaves at 6pm", nil)
+	if err != nil {
+		t.Fatalf("BroadcastToStatus: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	for _, result := range results {
+		optedOut := result.PhoneNumber == testPhone
+		if optedOut && (!result.Skipped || !errors.Is(result.Err, ErrDoNotContact)) {
+			t.Errorf("opted-out result = %+v, want it skipped with ErrDoNotContact", result)
+		}
+		if !optedOut && (result.Skipped || result.Err != nil) {
+			t.Errorf("result = %+v, want the message sent", result)
+		}
+	}
+
+	if messages := sender.messages(testPhone); len(messages) != 0 {
+		t.Errorf("sent %+v to a guest who opted out", messages)
+	}
+	if broadcast := sender.last(t, otherPhone); broadcast != "Hi Yossi, the bus leaves at 6pm" {
+		t.Errorf("broadcast = %q, want it rendered for the guest", broadcast)
+	}
+	if stats := guests.Stats(); stats.Accepted != 2 || stats.DoNotContact != 1 {
+		t.Errorf("stats = %+v, want both counted as accepted and one flagged do-not-contact", stats)
+	}
+}
+
+func TestBroadcastToStatusWaitsForQuietHours(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+	receive(t, h, testPhone, "yes")
+
+	sender.reset()
+	sender.quiet = true
+	results, err := h.BroadcastToStatus(models.RSVPAccepted, "The bus leaves at 6pm", nil)
+	if err != nil {
+		t.Fatalf("BroadcastToStatus: %v", err)
+	}
+	if len(results) != 1 || !results[0].Deferred || results[0].Err != nil {
+		t.Fatalf("results = %+v, want the message held back for quiet hours", results)
+	}
+
+	messages := sender.messages(testPhone)
+	if len(messages) != 1 || messages[0].kind != "reminder" {
+		t.Errorf("sent %+v, want the broadcast sent like a reminder so quiet hours apply", messages)
+	}
+}
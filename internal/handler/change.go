@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// statusLabels are how each RSVP answer is described to guests, by language
+var statusLabels = map[string]map[models.RSVPStatus]string{
+	models.LanguageEnglish: {
+		models.RSVPAccepted: "attending",
+		models.RSVPDeclined: "not attending",
+		models.RSVPMaybe:    "not sure yet",
+	},
+	models.LanguageHebrew: {
+		models.RSVPAccepted: "מגיע/ה",
+		models.RSVPDeclined: "לא מגיע/ה",
+		models.RSVPMaybe:    "עוד לא בטוח/ה",
+	},
+}
+
+// statusLabel describes an RSVP answer to a guest in their language
+func statusLabel(status models.RSVPStatus, language string) string {
+	if label, ok := statusLabels[language][status]; ok {
+		return label
+	}
+	if label, ok := statusLabels[models.LanguageEnglish][status]; ok {
+		return label
+	}
+	return string(status)
+}
+
+// isChange reports whether status replaces an answer the guest already gave
+func isChange(guest *models.Guest, status models.RSVPStatus) bool {
+	switch guest.RSVPStatus {
+	case models.RSVPAccepted, models.RSVPDeclined, models.RSVPMaybe:
+		return status != guest.RSVPStatus
+	}
+	return false
+}
+
+// changesLocked reports whether guests who already answered can no longer change their answer
+func (h *RSVPHandler) changesLocked(now time.Time) bool {
+	return !h.config.RSVPChangeDeadline.IsZero() && now.After(h.config.RSVPChangeDeadline)
+}
+
+// changeNotice acknowledges that the guest changed their answer to status, in their language
+func (h *RSVPHandler) changeNotice(guest *models.Guest, status models.RSVPStatus) (string, error) {
+	language := h.language(guest)
+	data := h.messageData(guest, 0)
+	data.PreviousStatus = statusLabel(guest.RSVPStatus, language)
+	data.Status = statusLabel(status, language)
+	return h.config.Templates.Render(TemplateChanged, language, data)
+}
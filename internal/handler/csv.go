@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"wedding-whatsapp/internal/phone"
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+// InvitationResult reports the outcome of sending an invitation for a single CSV row
+type InvitationResult struct {
+	Row         int
+	Name        string
+	PhoneNumber string
+	Side        string
+	Tier        string
+	Skipped     bool  // the row was invalid and no invitation was attempted
+	Err         error // nil when the invitation was sent
+}
+
+// SendInvitationsFromCSV sends invitations to every guest listed in a name,phone CSV file,
+// with an optional third column for the side that invited them (e.g. bride or groom)
+// and a fourth for their tier, which picks the invitation they're sent (e.g. formal).
+// Invalid rows are skipped and a failure on one row does not abort the rest of the batch.
+// The whole file is read before anything is sent, so progress knows how many rows there are.
+func (h *RSVPHandler) SendInvitationsFromCSV(path string, progress ProgressFunc) ([]InvitationResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []csvRow
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, csvRow{row: row, err: err})
+				continue
+			}
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		// Skip the header row if present (Excel exports may also prefix it with a BOM)
+		if row == 1 && len(record) > 0 && strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(record[0]), "\ufeff"), "name") {
+			continue
+		}
+
+		rows = append(rows, csvRow{row: row, record: record})
+	}
+
+	var results []InvitationResult
+	for i, row := range rows {
+		result := InvitationResult{Row: row.row, Skipped: true, Err: row.err}
+		if row.err == nil {
+			result = h.sendCSVInvitation(row.row, row.record)
+		}
+		results = append(results, result)
+		progress.report(Progress{
+			Done:        i + 1,
+			Total:       len(rows),
+			Name:        result.Name,
+			PhoneNumber: result.PhoneNumber,
+			Skipped:     result.Skipped,
+			Err:         result.Err,
+		})
+	}
+
+	return results, nil
+}
+
+// csvRow is a row of a CSV file of guests, or the error it couldn't be parsed with
+type csvRow struct {
+	row    int
+	record []string
+	err    error
+}
+
+// sendCSVInvitation validates a single CSV record and sends its invitation
+func (h *RSVPHandler) sendCSVInvitation(row int, record []string) InvitationResult {
+	result := InvitationResult{Row: row}
+	if len(record) < 2 {
+		result.Skipped = true
+		result.Err = fmt.Errorf("expected name and phone columns, got %d", len(record))
+		return result
+	}
+
+	result.Name = strings.TrimSpace(record[0])
+	result.PhoneNumber = whatsapp.NormalizePhoneNumber(strings.TrimSpace(record[1]))
+	if len(record) > 2 {
+		result.Side = NormalizeSide(record[2])
+	}
+	if len(record) > 3 {
+		result.Tier = NormalizeTier(record[3])
+	}
+
+	if result.Name == "" {
+		result.Skipped = true
+		result.Err = fmt.Errorf("empty name")
+		return result
+	}
+	if !phone.IsValid(result.PhoneNumber) {
+		result.Skipped = true
+		result.Err = fmt.Errorf("malformed phone number %q", record[1])
+		return result
+	}
+	if h.whatsappService.IsOwnNumber(result.PhoneNumber) {
+		result.Skipped = true
+		result.Err = whatsapp.ErrOwnNumber
+		return result
+	}
+
+	// Guests invited recently, e.g. by an earlier run over the same file, are skipped
+	result.Err = h.SendInvitation(result.PhoneNumber, result.Name, "", result.Side, result.Tier, false)
+	result.Skipped = errors.Is(result.Err, ErrAlreadyInvited)
+	return result
+}
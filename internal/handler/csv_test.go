@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+func TestSendInvitationsFromCSVSkipsOwnNumber(t *testing.T) {
+	h, _, sender := newTestHandler(t, nil)
+	sender.ownNumber = "972509876543"
+
+	path := filepath.Join(t.TempDir(), "guests.csv")
+	csv := "name,phone\nMe,050-987-6543\n" + testName + "," + testPhone + "\n"
+	if err := os.WriteFile(path, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := h.SendInvitationsFromCSV(path, nil)
+	if err != nil {
+		t.Fatalf("SendInvitationsFromCSV: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	if own := results[0]; !own.Skipped || !errors.Is(own.Err, whatsapp.ErrOwnNumber) {
+		t.Errorf("own number result = %+v, want it skipped with ErrOwnNumber", own)
+	}
+	if guest := results[1]; guest.Skipped || guest.Err != nil {
+		t.Errorf("guest result = %+v, want the invitation sent", guest)
+	}
+
+	if messages := sender.messages("972509876543"); len(messages) != 0 {
+		t.Errorf("sent %+v to the bot's own number", messages)
+	}
+	if messages := sender.messages(testPhone); len(messages) == 0 || messages[0].kind != "invitation" {
+		t.Errorf("sent %+v to the guest, want the invitation", messages)
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+var hebrewWeekdays = [...]string{"ראשון", "שני", "שלישי", "רביעי", "חמישי", "שישי", "שבת"}
+
+var hebrewMonths = [...]string{
+	"ינואר", "פברואר", "מרץ", "אפריל", "מאי", "יוני",
+	"יולי", "אוגוסט", "ספטמבר", "אוקטובר", "נובמבר", "דצמבר",
+}
+
+// weddingDate returns the wedding date as shown to guests in the given language
+func (h *RSVPHandler) weddingDate(language string) string {
+	if h.config.WeddingDate.IsZero() {
+		return h.config.WeddingDateText
+	}
+	return formatDate(h.config.WeddingDate, language)
+}
+
+// formatDate spells out a date in the given locale, with the time of day if it isn't midnight:
+// "Monday, January 5, 2026 at 19:30" in English, "יום שני, 5 בינואר 2026 בשעה 19:30" in Hebrew.
+// Unknown locales use English.
+func formatDate(t time.Time, locale string) string {
+	hasTime := t.Hour() != 0 || t.Minute() != 0
+
+	if locale == models.LanguageHebrew {
+		date := fmt.Sprintf("יום %s, %d ב%s %d", hebrewWeekdays[t.Weekday()], t.Day(), hebrewMonths[t.Month()-1], t.Year())
+		if hasTime {
+			date += " בשעה " + t.Format("15:04")
+		}
+		return date
+	}
+
+	date := t.Format("Monday, January 2, 2006")
+	if hasTime {
+		date += " at " + t.Format("15:04")
+	}
+	return date
+}
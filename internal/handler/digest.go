@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// digestCheckInterval is how often the daily digest checks whether it's time to send
+const digestCheckInterval = time.Minute
+
+// DigestSchedule is the wall-clock time of day the organizer's digest is sent at
+type DigestSchedule struct {
+	at  time.Duration // offset from midnight
+	loc *time.Location
+}
+
+// ParseDigestSchedule parses a time of day (15:04) in the given IANA timezone, empty for the system timezone
+func ParseDigestSchedule(at, timezone string) (*DigestSchedule, error) {
+	atTime, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest time %q, expected HH:MM", at)
+	}
+
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &DigestSchedule{at: atTime.Sub(midnight), loc: loc}, nil
+}
+
+// next returns the first digest time after t
+func (d *DigestSchedule) next(t time.Time) time.Time {
+	local := t.In(d.loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, d.loc)
+	for {
+		if sendAt := day.Add(d.at); sendAt.After(t) {
+			return sendAt
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// RunDailyDigest sends a summary of the RSVPs once a day on schedule, until stop is closed,
+// to the organizer's phone and to a group chat such as the wedding helpers', whichever are set
+func (h *RSVPHandler) RunDailyDigest(organizerPhone, groupJID string, schedule *DigestSchedule, stop <-chan struct{}) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	sendAt := schedule.next(time.Now())
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if now.Before(sendAt) {
+				continue
+			}
+			// Answers since the previous digest, which is yesterday's unless the bot wasn't running then
+			since := sendAt.AddDate(0, 0, -1)
+			h.sendDigest(organizerPhone, groupJID, h.Digest(since))
+			sendAt = schedule.next(now)
+		}
+	}
+}
+
+// sendDigest sends the digest to the organizer's phone and group, logging the outcome of each
+func (h *RSVPHandler) sendDigest(organizerPhone, groupJID, digest string) {
+	if organizerPhone != "" {
+		if _, err := h.whatsappService.SendMessage(organizerPhone, digest); err != nil {
+			fmt.Printf("⚠️ Failed to send daily digest: %v\n", err)
+		} else {
+			fmt.Println("✓ Daily digest sent")
+		}
+	}
+	if groupJID != "" {
+		if err := h.whatsappService.SendToGroup(groupJID, digest); err != nil {
+			fmt.Printf("⚠️ Failed to post daily digest to the group: %v\n", err)
+		} else {
+			fmt.Println("✓ Daily digest posted to the group")
+		}
+	}
+}
+
+// digestAnswer is an RSVP answer reported in the digest
+type digestAnswer struct {
+	name   string
+	status models.RSVPStatus
+	at     time.Time
+}
+
+// Digest summarizes the RSVPs for the organizer: the answers given since the given time,
+// the current totals and the expected headcount
+func (h *RSVPHandler) Digest(since time.Time) string {
+	var answers []digestAnswer
+	for _, guest := range h.storage.GetAllGuests() {
+		// Only a guest's latest answer counts, so one who changed their mind is listed once
+		for i := len(guest.History) - 1; i >= 0; i-- {
+			event := guest.History[i]
+			if event.Timestamp.After(since) && event.NewStatus != models.RSVPPending {
+				answers = append(answers, digestAnswer{name: guest.Name, status: event.NewStatus, at: event.Timestamp})
+				break
+			}
+		}
+	}
+	slices.SortFunc(answers, func(a, b digestAnswer) int { return a.at.Compare(b.at) })
+
+	var b strings.Builder
+	b.WriteString("📋 *Daily RSVP digest*\n\n")
+	if len(answers) == 0 {
+		b.WriteString("No new RSVPs since yesterday.\n")
+	} else {
+		fmt.Fprintf(&b, "🆕 %d new RSVP(s) since yesterday:\n", len(answers))
+		for _, answer := range answers {
+			fmt.Fprintf(&b, "- %s: %s\n", answer.name, statusLabel(answer.status, models.LanguageEnglish))
+		}
+	}
+
+	stats := h.storage.Stats()
+	fmt.Fprintf(&b, "\n✅ Accepted: %d\n❌ Declined: %d\n🤔 Maybe: %d\n⏳ Pending: %d\n", stats.Accepted, stats.Declined, stats.Maybe, stats.Pending)
+	fmt.Fprintf(&b, "👥 Expected headcount: %d", stats.Headcount)
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// eventOver reports whether the wedding is over: EventOver is set, or it's past the wedding day
+func (h *RSVPHandler) eventOver(now time.Time) bool {
+	if h.config.EventOver {
+		return true
+	}
+	if h.config.WeddingDate.IsZero() {
+		return false
+	}
+	year, month, day := h.config.WeddingDate.Date()
+	dayAfter := time.Date(year, month, day+1, 0, 0, 0, 0, h.config.WeddingDate.Location())
+	return !now.Before(dayAfter)
+}
+
+// handleAfterEvent answers a guest who writes once the wedding is over. Their message is logged
+// but never read as an RSVP, and they're thanked the first time they write.
+func (h *RSVPHandler) handleAfterEvent(phoneNumber string, guest *models.Guest, received string) error {
+	fmt.Printf("💌 %s (%s) wrote after the wedding: %q\n", guest.Name, guest.DisplayPhone(), received)
+	if guest.ConversationState == models.StateThanked {
+		return nil
+	}
+
+	message, err := h.render(TemplateThanks, guest, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := h.whatsappService.SendMessage(phoneNumber, message); err != nil {
+		return fmt.Errorf("failed to send thanks: %w", err)
+	}
+	if err := h.storage.SetConversationState(phoneNumber, models.StateThanked); err != nil {
+		return fmt.Errorf("failed to update conversation state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestHandleMessageAfterEventIgnoresRSVP(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+	}{
+		{"EVENT_OVER set", &Config{EventOver: true}},
+		{"past the wedding day", &Config{WeddingDate: time.Now().AddDate(0, 0, -2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, guests, sender := newTestHandler(t, tt.cfg)
+			addPendingGuest(t, guests, testPhone, testName)
+
+			for _, reply := range []string{"Yes! Great wedding, thank you", "no", "👍"} {
+				receive(t, h, testPhone, reply)
+			}
+
+			guest, err := guests.GetGuest(testPhone)
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+			if guest.RSVPStatus != models.RSVPPending || len(guest.History) != 0 {
+				t.Errorf("guest = %s with history %+v, want the RSVP left alone", guest.RSVPStatus, guest.History)
+			}
+
+			// Thanked once, however many times they write
+			messages := sender.messages(testPhone)
+			if len(messages) != 1 || !strings.Contains(messages[0].text, "Thank you so much for your message, Dana") {
+				t.Errorf("sent %+v, want a single thank-you", messages)
+			}
+		})
+	}
+}
+
+func TestEventOver(t *testing.T) {
+	wedding := time.Date(2026, 6, 10, 19, 0, 0, 0, time.UTC)
+	h, _, _ := newTestHandler(t, &Config{WeddingDate: wedding})
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{wedding.AddDate(0, 0, -1), false},
+		{wedding, false},
+		{time.Date(2026, 6, 10, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2026, 6, 11, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if got := h.eventOver(tt.now); got != tt.want {
+			t.Errorf("eventOver(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"unicode/utf8"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// fuzzyMaxWords limits typo-tolerant matching to short replies like "yse" or "acept!",
+// where a near miss of a keyword is almost certainly meant as an answer
+const fuzzyMaxWords = 3
+
+// fuzzyMinLength keeps short keywords like "no" out of typo-tolerant matching,
+// since almost any two-letter word is one typo away from them
+const fuzzyMinLength = 3
+
+// fuzzyIgnoredWords are real words close enough to a keyword to be mistaken for a typo of it
+var fuzzyIgnoredWords = map[string]bool{
+	"yet": true, "eyes": true, "yen": true, "yas": true, "nose": true, "none": true,
+}
+
+// negationWords stop a reply from being read as an answer by typo-tolerant matching,
+// so "not comming" isn't taken as accepting. "t" is what's left of can't/don't/won't after tokenizing.
+var negationWords = map[string]bool{
+	"no": true, "not": true, "never": true, "t": true, "cant": true, "dont": true, "wont": true, "לא": true,
+}
+
+// fuzzyStatus matches a short reply against the single-word RSVP keywords, allowing up to
+// maxDistance typos (insertions, deletions, substitutions or swapped letters) per word.
+// It returns "" when maxDistance is zero, nothing is close enough, the reply contains a negation,
+// or its words are close to keywords of different statuses.
+func (k *Keywords) fuzzyStatus(text string, maxDistance int) models.RSVPStatus {
+	words := tokenize(text)
+	if maxDistance <= 0 || len(words) == 0 || len(words) > fuzzyMaxWords {
+		return ""
+	}
+
+	var status models.RSVPStatus
+	for _, word := range words {
+		if negationWords[word] {
+			return ""
+		}
+		if fuzzyIgnoredWords[word] || utf8.RuneCountInString(word) < fuzzyMinLength {
+			continue
+		}
+
+		for _, candidate := range k.groups() {
+			if !closeToAny(word, candidate.keywords, maxDistance) {
+				continue
+			}
+			if status != "" && status != candidate.status {
+				// Ambiguous, e.g. one word looks like "yes" and another like "nope"
+				return ""
+			}
+			status = candidate.status
+		}
+	}
+	return status
+}
+
+// closeToAny reports whether word is within maxDistance typos of one of the single-word keywords
+func closeToAny(word string, keywords []string, maxDistance int) bool {
+	for _, keyword := range keywords {
+		if len(tokenize(keyword)) != 1 || utf8.RuneCountInString(keyword) < fuzzyMinLength {
+			continue
+		}
+		if editDistance(word, keyword) <= maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance is the optimal string alignment distance between a and b: the number of single-letter
+// insertions, deletions, substitutions and swaps of adjacent letters needed to turn one into the other
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestFuzzyStatus(t *testing.T) {
+	k := defaultTestKeywords(t)
+	tests := []struct {
+		text string
+		want models.RSVPStatus
+	}{
+		// Common misspellings
+		{"yse", models.RSVPAccepted},
+		{"yess", models.RSVPAccepted},
+		{"acept", models.RSVPAccepted},
+		{"accpet!", models.RSVPAccepted},
+		{"attendng", models.RSVPAccepted},
+		{"declne", models.RSVPDeclined},
+		{"nopee", models.RSVPDeclined},
+		{"mybe", models.RSVPMaybe},
+		{"perhpas", models.RSVPMaybe},
+
+		// Ambiguous or unclear replies stay unmatched
+		{"not comming", ""},
+		{"dont acept", ""},
+		{"yse nopee", ""},
+		{"yet", ""},
+		{"eyes", ""},
+		{"ok", ""},
+		{"hello there", ""},
+		{"we will surely be atending", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := k.fuzzyStatus(tt.text, 1); got != tt.want {
+				t.Errorf("fuzzyStatus(%q, 1) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyStatusDisabled(t *testing.T) {
+	k := defaultTestKeywords(t)
+	if got := k.fuzzyStatus("yse", 0); got != "" {
+		t.Errorf("fuzzyStatus with no tolerance = %q, want no match", got)
+	}
+}
+
+func TestHandleMessageMisspelledReply(t *testing.T) {
+	h, guests, _ := newTestHandler(t, &Config{TypoTolerance: 1})
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "Yse!")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPAccepted {
+		t.Errorf("status = %s, want %s", status, models.RSVPAccepted)
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// KeywordList holds the words and phrases that answer an invitation in one language
+type KeywordList struct {
+	Accept  []string `json:"accept,omitempty"`
+	Decline []string `json:"decline,omitempty"`
+	Maybe   []string `json:"maybe,omitempty"`
+
+	// Stop are the replies asking not to be messaged anymore
+	Stop []string `json:"stop,omitempty"`
+}
+
+// defaultKeywords are the built-in keywords, by language
+var defaultKeywords = map[string]KeywordList{
+	models.LanguageEnglish: {
+		Accept:  []string{"yes", "yep", "yeah", "ya", "yup", "accept", "accepting", "attending", "coming", "will come", "will be there", "be there", "✅"},
+		Decline: []string{"no", "nope", "decline", "declining", "not coming", "can't come", "won't come", "can't make it", "won't be there", "can't be there", "❌"},
+		Maybe:   []string{"maybe", "perhaps", "not sure", "unsure", "undecided", "don't know yet", "will let you know", "🤔"},
+		Stop:    []string{"stop", "unsubscribe", "stop messaging me", "stop sending messages", "don't message me", "remove me from the list"},
+	},
+	models.LanguageHebrew: {
+		Accept:  []string{"כן", "מגיע", "מגיעה", "מגיעים", "נגיע", "בשמחה"},
+		Decline: []string{"לא", "לא מגיע", "לא מגיעה", "לא נוכל", "לא נגיע", "מצטער", "מצטערת", "מצטערים"},
+		Maybe:   []string{"אולי", "לא בטוח", "לא בטוחה", "לא בטוחים", "עוד לא יודע", "עוד לא יודעת", "נעדכן"},
+		Stop:    []string{"תפסיקו", "תפסיק", "הסר", "הסירו", "הסירו אותי", "תפסיקו לשלוח", "די להודעות"},
+	},
+}
+
+// Keywords recognizes RSVP answers in replies, whatever language they're written in
+type Keywords struct {
+	accept  []string
+	decline []string
+	maybe   []string
+	stop    []string
+}
+
+// LoadKeywords reads the RSVP keywords from a JSON file keyed by language, e.g.
+// {"he": {"accept": ["כן", "סבבה", "אין בעיה"]}}. A list in the file replaces the built-in one
+// for that language; lists and languages it leaves out keep the built-in words, as does an empty path.
+// A keyword may not answer two different ways.
+func LoadKeywords(path string) (*Keywords, error) {
+	lists := make(map[string]KeywordList, len(defaultKeywords))
+	for language, list := range defaultKeywords {
+		lists[language] = list
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keywords file: %w", err)
+		}
+		var custom map[string]KeywordList
+		if err := json.Unmarshal(data, &custom); err != nil {
+			return nil, fmt.Errorf("failed to parse keywords file: %w", err)
+		}
+
+		for language, list := range custom {
+			merged := lists[language]
+			if list.Accept != nil {
+				merged.Accept = list.Accept
+			}
+			if list.Decline != nil {
+				merged.Decline = list.Decline
+			}
+			if list.Maybe != nil {
+				merged.Maybe = list.Maybe
+			}
+			if list.Stop != nil {
+				merged.Stop = list.Stop
+			}
+			lists[language] = merged
+		}
+	}
+
+	k := &Keywords{}
+	for _, language := range slices.Sorted(maps.Keys(lists)) {
+		list := lists[language]
+		k.accept = append(k.accept, list.Accept...)
+		k.decline = append(k.decline, list.Decline...)
+		k.maybe = append(k.maybe, list.Maybe...)
+		k.stop = append(k.stop, list.Stop...)
+	}
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// validate checks that no keyword is listed under two different answers, or as both an answer and a stop request
+func (k *Keywords) validate() error {
+	seen := make(map[string]models.RSVPStatus)
+	var errs []error
+	for _, group := range k.groups() {
+		for _, keyword := range group.keywords {
+			key := strings.Join(tokenize(keyword), " ")
+			if key == "" {
+				key = keyword
+			}
+			if status, ok := seen[key]; ok && status != group.status {
+				errs = append(errs, fmt.Errorf("keyword %q is listed as both %s and %s", keyword, status, group.status))
+				continue
+			}
+			seen[key] = group.status
+		}
+	}
+	for _, keyword := range k.stop {
+		if status, ok := seen[strings.Join(tokenize(keyword), " ")]; ok {
+			errs = append(errs, fmt.Errorf("keyword %q is listed as both %s and stop", keyword, status))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// keywordGroup is the keywords of a single answer
+type keywordGroup struct {
+	status   models.RSVPStatus
+	keywords []string
+}
+
+// groups returns the keywords by answer, in the order replies are checked
+func (k *Keywords) groups() []keywordGroup {
+	return []keywordGroup{
+		{models.RSVPMaybe, k.maybe},
+		{models.RSVPDeclined, k.decline},
+		{models.RSVPAccepted, k.accept},
+	}
+}
+
+// status detects an RSVP answer in a text reply, or returns "" if there is none.
+// Tentative answers are checked first since "not sure" / "לא בטוח" contain a negative,
+// then declines since phrases like "not coming" / "לא מגיע" contain an affirmative.
+// A bare "no" / "לא" is checked last and only leading the reply, since "no problem, we'll be there"
+// and "כן, לא נפספס" accept.
+func (k *Keywords) status(text string) models.RSVPStatus {
+	for _, group := range k.groups() {
+		keywords := group.keywords
+		if group.status == models.RSVPDeclined {
+			keywords = slices.DeleteFunc(slices.Clone(keywords), isBareNegation)
+		}
+		if containsAny(text, keywords...) {
+			return group.status
+		}
+	}
+
+	words := tokenize(text)
+	if len(words) > 0 && slices.ContainsFunc(k.decline, func(keyword string) bool {
+		return isBareNegation(keyword) && tokenize(keyword)[0] == words[0]
+	}) {
+		return models.RSVPDeclined
+	}
+	return ""
+}
+
+// isBareNegation reports whether a keyword is a single negation word like "no" or "לא"
+func isBareNegation(keyword string) bool {
+	words := tokenize(keyword)
+	return len(words) == 1 && negationWords[words[0]]
+}
+
+// optOut reports whether a text reply asks not to be messaged anymore. A single-word stop keyword
+// must be the whole reply, so "stop" ends the messages but "can't stop smiling, yes!" is an answer;
+// longer phrases like "stop messaging me" may appear anywhere in it.
+func (k *Keywords) optOut(text string) bool {
+	words := strings.Join(tokenize(text), " ")
+	for _, keyword := range k.stop {
+		stop := tokenize(keyword)
+		if len(stop) == 1 && words == stop[0] || len(stop) > 1 && containsAny(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// defaultTestKeywords loads the built-in keywords, failing the test if they don't
+func defaultTestKeywords(t *testing.T) *Keywords {
+	t.Helper()
+	k, err := LoadKeywords("")
+	if err != nil {
+		t.Fatalf("LoadKeywords: %v", err)
+	}
+	return k
+}
+
+func TestKeywordsHebrew(t *testing.T) {
+	k := defaultTestKeywords(t)
+	tests := []struct {
+		text string
+		want models.RSVPStatus
+	}{
+		{"כן", models.RSVPAccepted},
+		{"כן!", models.RSVPAccepted},
+		{"בשמחה, נגיע", models.RSVPAccepted},
+		{"מגיעה עם בעלי.", models.RSVPAccepted},
+		{"לא", models.RSVPDeclined},
+		{"לא מגיע, מצטער", models.RSVPDeclined},
+		{"לא נוכל להגיע...", models.RSVPDeclined},
+		{"מצטערת", models.RSVPDeclined},
+		{"אולי", models.RSVPMaybe},
+		{"לא בטוחה עדיין", models.RSVPMaybe},
+		{"מזל טוב!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := k.status(tt.text); got != tt.want {
+				t.Errorf("status(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywordsBareNegation(t *testing.T) {
+	k := defaultTestKeywords(t)
+	tests := []struct {
+		text string
+		want models.RSVPStatus
+	}{
+		{"no", models.RSVPDeclined},
+		{"No, sorry", models.RSVPDeclined},
+		{"לא", models.RSVPDeclined},
+		{"לא, תודה", models.RSVPDeclined},
+		{"No problem, we'll be there!", models.RSVPAccepted},
+		{"no doubt, we're coming", models.RSVPAccepted},
+		{"yes of course, no question", models.RSVPAccepted},
+		{"כן, לא נפספס!", models.RSVPAccepted},
+		{"sorry, we won't be there", models.RSVPDeclined},
+		{"we're not coming, no", models.RSVPDeclined},
+		{"not sure yet, no idea", models.RSVPMaybe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := k.status(tt.text); got != tt.want {
+				t.Errorf("status(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywordsMixedLanguagesAndGreetings(t *testing.T) {
+	k := defaultTestKeywords(t)
+	tests := []struct {
+		text string
+		want models.RSVPStatus
+	}{
+		// Mixed Hebrew and English
+		{"yes כן", models.RSVPAccepted},
+		{"OK מגיעים!", models.RSVPAccepted},
+		{"sorry, לא נוכל", models.RSVPDeclined},
+		{"יאללה, coming!", models.RSVPAccepted},
+		{"אולי, not sure yet", models.RSVPMaybe},
+
+		// A greeting before the answer
+		{"Hi! yes", models.RSVPAccepted},
+		{"Hello, yes we're coming", models.RSVPAccepted},
+		{"היי, כן", models.RSVPAccepted},
+		{"שלום, לא נוכל", models.RSVPDeclined},
+		{"Hi, sorry we can't come", models.RSVPDeclined},
+		{"שלום!", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := k.status(tt.text); got != tt.want {
+				t.Errorf("status(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessageHebrewReply(t *testing.T) {
+	h, guests, _ := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "כן, מגיעים!")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPAccepted {
+		t.Errorf("status = %s, want %s", status, models.RSVPAccepted)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// mealKeywords maps each meal preference to the words (English and Hebrew) that select it
+var mealKeywords = []struct {
+	preference string
+	keywords   []string
+}{
+	{"vegan", []string{"vegan", "טבעוני", "טבעונית", "טבעוניים"}},
+	{"vegetarian", []string{"veg", "veggie", "vegetarian", "צמחוני", "צמחונית", "צמחוניים"}},
+	{"fish", []string{"fish", "דג", "דגים"}},
+	{"meat", []string{"meat", "בשר", "בשרי"}},
+}
+
+// hebrewMealNames are the meal preferences as they're written to guests in Hebrew
+var hebrewMealNames = map[string]string{
+	"vegan":      "טבעוני",
+	"vegetarian": "צמחוני",
+	"fish":       "דגים",
+	"meat":       "בשרי",
+}
+
+// parseMealPreference returns the meal preference mentioned in text, or "" if there is none
+func parseMealPreference(text string) string {
+	for _, meal := range mealKeywords {
+		if containsAny(text, meal.keywords...) {
+			return meal.preference
+		}
+	}
+	return ""
+}
+
+// askMealPreference sends the meal question and waits for the guest's answer
+func (h *RSVPHandler) askMealPreference(phoneNumber string, guest *models.Guest) error {
+	question, err := h.render(TemplateMeal, guest, 0)
+	if err != nil {
+		return err
+	}
+	if err := h.storage.SetConversationState(phoneNumber, models.StateAwaitingMeal); err != nil {
+		return fmt.Errorf("failed to update conversation state: %w", err)
+	}
+
+	if _, err := h.whatsappService.SendMessage(phoneNumber, question); err != nil {
+		return fmt.Errorf("failed to send meal question: %w", err)
+	}
+	return nil
+}
+
+// handleMealReply stores the meal preference from a guest we're waiting on.
+// It reports false if the reply doesn't mention a meal so it can be handled as a regular message.
+func (h *RSVPHandler) handleMealReply(phoneNumber string, guest *models.Guest, text string) (bool, error) {
+	preference := parseMealPreference(text)
+	if preference == "" {
+		return false, nil
+	}
+
+	if err := h.storage.UpdateMeal(phoneNumber, preference); err != nil {
+		return true, fmt.Errorf("failed to update meal preference: %w", err)
+	}
+	if err := h.storage.SetConversationState(phoneNumber, models.StateIdle); err != nil {
+		return true, fmt.Errorf("failed to update conversation state: %w", err)
+	}
+
+	language := h.language(guest)
+	data := h.messageData(guest, 0)
+	data.Meal = preference
+	if name, ok := hebrewMealNames[preference]; ok && language == models.LanguageHebrew {
+		data.Meal = name
+	}
+	reply, err := h.config.Templates.Render(TemplateMealNoted, language, data)
+	if err != nil {
+		return true, err
+	}
+	if _, err := h.whatsappService.SendMessage(phoneNumber, reply); err != nil {
+		return true, fmt.Errorf("failed to send meal confirmation: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalizeReply tidies a text reply before it's matched: emoji stuck to words are split off,
+// decorative emoji at the start and end, like "🎉🎉 yes 🎉", are dropped, and line breaks and
+// runs of spaces become single spaces. Emoji that are answers themselves, like ✅ and ❌, are kept.
+func (k *Keywords) normalizeReply(text string) string {
+	words := splitEmoji(text)
+
+	start, end := 0, len(words)
+	for start < end && isEmoji(words[start]) && !k.isEmojiKeyword(words[start]) {
+		start++
+	}
+	for end > start && isEmoji(words[end-1]) && !k.isEmojiKeyword(words[end-1]) {
+		end--
+	}
+	return strings.Join(words[start:end], " ")
+}
+
+// splitEmoji splits text on whitespace and puts each emoji in a word of its own,
+// keeping skin tones, variation selectors and joiners with the emoji they modify
+func splitEmoji(text string) []string {
+	var words []string
+	var word strings.Builder
+	wordIsEmoji := false
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case isEmojiModifier(r):
+			// A modifier continues the emoji before it, and means nothing on its own
+			if wordIsEmoji {
+				word.WriteRune(r)
+			}
+		case isEmojiRune(r):
+			// A joiner before it makes this part of the same emoji, e.g. 👨‍👩‍👧
+			if !strings.HasSuffix(word.String(), "‍") {
+				flush()
+			}
+			word.WriteRune(r)
+			wordIsEmoji = true
+		default:
+			if wordIsEmoji {
+				flush()
+			}
+			word.WriteRune(r)
+			wordIsEmoji = false
+		}
+	}
+	flush()
+	return words
+}
+
+// isEmojiKeyword reports whether an emoji is itself an answer or a stop request, such as ✅ or ❌,
+// whatever its skin tone
+func (k *Keywords) isEmojiKeyword(emoji string) bool {
+	emoji = stripVariation(withoutSkinTone(emoji))
+	lists := [][]string{k.accept, k.decline, k.maybe, k.stop}
+	for _, group := range quotedKeywords {
+		lists = append(lists, group.keywords)
+	}
+	for _, keywords := range lists {
+		for _, keyword := range keywords {
+			if stripVariation(keyword) == emoji {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isEmoji reports whether word is made up of emoji only
+func isEmoji(word string) bool {
+	for _, r := range word {
+		if !isEmojiRune(r) && !isEmojiModifier(r) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// isEmojiRune reports whether r is an emoji or pictographic symbol, like 🎉, ❤ or ✅
+func isEmojiRune(r rune) bool {
+	return unicode.Is(unicode.So, r)
+}
+
+// isEmojiModifier reports whether r changes the emoji before it: a skin tone, a variation selector
+// or a zero-width joiner
+func isEmojiModifier(r rune) bool {
+	return (r >= 0x1F3FB && r <= 0x1F3FF) || r == '︎' || r == '️' || r == '‍'
+}
+
+// stripVariation removes variation selectors, so "❤️" and "❤" compare equal
+func stripVariation(emoji string) string {
+	return strings.NewReplacer("︎", "", "️", "").Replace(emoji)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestNormalizeReply(t *testing.T) {
+	k := defaultTestKeywords(t)
+	tests := []struct {
+		text, want string
+	}{
+		{"🎉🎉 כן בטח ✅\n נתראה", "כן בטח ✅ נתראה"},
+		{"Yes!!! 🎉🎉🎉", "Yes!!!"},
+		{"🥳yes🥳", "yes"},
+		{"no 😢\n\nsorry", "no 😢 sorry"},
+		{"✅", "✅"},
+		{"  \n", ""},
+	}
+	for _, tt := range tests {
+		if got := k.normalizeReply(tt.text); got != tt.want {
+			t.Errorf("normalizeReply(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEmojiDecoratedReplies(t *testing.T) {
+	k := defaultTestKeywords(t)
+	tests := []struct {
+		text string
+		want models.RSVPStatus
+	}{
+		{"🎉🎉 כן בטח ✅\n נתראה", models.RSVPAccepted},
+		{"Yes!!! 🎉🎉🎉", models.RSVPAccepted},
+		{"🥳yes🥳", models.RSVPAccepted},
+		{"💃🕺\nבשמחה\n💃🕺", models.RSVPAccepted},
+		{"✅", models.RSVPAccepted},
+		{"😢 לא נוכל להגיע 😢", models.RSVPDeclined},
+		{"no 😢\n\nsorry", models.RSVPDeclined},
+		{"❌ unfortunately", models.RSVPDeclined},
+		{"❌", models.RSVPDeclined},
+		{"🤔", models.RSVPMaybe},
+		{"❤️❤️❤️", ""},
+		{"🎉 mazal tov 🎉", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := k.status(k.normalizeReply(strings.ToLower(tt.text))); got != tt.want {
+				t.Errorf("status(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessageEmojiReply(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "🎉🎉 כן בטח ✅\n נתראה")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPAccepted {
+		t.Errorf("status = %s, want %s", status, models.RSVPAccepted)
+	}
+	// The guest wrote in Hebrew, so that's the language of the confirmation
+	if confirmation := sender.messages(testPhone)[0].text; !strings.Contains(confirmation, "נפלא") {
+		t.Errorf("confirmation = %q, want the Hebrew accepted confirmation", confirmation)
+	}
+}
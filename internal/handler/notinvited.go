@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// MarkUnsentNotInvited moves pending guests who were never sent anything, such as contacts
+// imported before imports were kept apart, to not_invited. It returns how many were moved.
+func (h *RSVPHandler) MarkUnsentNotInvited() (int, error) {
+	marked := 0
+	var errs []error
+
+	for _, guest := range h.storage.GetGuestsByStatus(models.RSVPPending) {
+		// A message ID, an answer or a failed send all mean we did try to reach the guest
+		if guest.LastMessageID != "" || len(guest.History) > 0 || guest.Unreachable {
+			continue
+		}
+
+		if err := h.storage.MarkNotInvited(guest.PhoneNumber); err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark %s not invited: %w", guest.PhoneNumber, err))
+			continue
+		}
+		marked++
+	}
+
+	return marked, errors.Join(errs...)
+}
+
+// InviteNotInvited sends the invitation to every guest who hasn't been invited yet,
+// which makes them pending. It returns how many invitations were sent.
+func (h *RSVPHandler) InviteNotInvited(progress ProgressFunc) (int, error) {
+	sent := 0
+	var errs []error
+
+	guests := h.storage.GetGuestsByStatus(models.RSVPNotInvited)
+	for i, guest := range guests {
+		err := h.SendInvitation(guest.PhoneNumber, guest.Name, "", "", "", false)
+		progress.report(Progress{
+			Done:        i + 1,
+			Total:       len(guests),
+			Name:        guest.Name,
+			PhoneNumber: guest.PhoneNumber,
+			Skipped:     errors.Is(err, ErrDoNotContact),
+			Err:         err,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to invite %s: %w", guest.PhoneNumber, err))
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// ErrDoNotContact is returned when sending to a guest who asked to stop being messaged
+var ErrDoNotContact = errors.New("guest asked not to be messaged")
+
+// handleOptOut stops messaging a guest who asked us to, confirming it the first time they ask.
+// Their RSVP answer is left as it is, and they can still reply with one later.
+func (h *RSVPHandler) handleOptOut(phoneNumber string, guest *models.Guest) error {
+	if guest.DoNotContact {
+		return nil
+	}
+
+	if err := h.storage.SetDoNotContact(phoneNumber, true); err != nil {
+		return fmt.Errorf("failed to record opt-out: %w", err)
+	}
+	// Any question we asked is dropped along with the reminders
+	if guest.ConversationState != models.StateIdle {
+		if err := h.storage.SetConversationState(phoneNumber, models.StateIdle); err != nil {
+			return fmt.Errorf("failed to update conversation state: %w", err)
+		}
+	}
+	fmt.Printf("🔕 %s (%s) asked not to be messaged anymore\n", guest.Name, guest.DisplayPhone())
+
+	message, err := h.render(TemplateOptOut, guest, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := h.whatsappService.SendMessage(phoneNumber, message); err != nil {
+		return fmt.Errorf("failed to confirm opt-out: %w", err)
+	}
+	return nil
+}
+
+// AllowContact lets a guest who asked not to be messaged be sent invitations, reminders
+// and broadcasts again, e.g. after they asked for that by phone
+func (h *RSVPHandler) AllowContact(phoneNumber string) error {
+	if _, err := h.storage.GetGuest(phoneNumber); err != nil {
+		return err
+	}
+	return h.storage.SetDoNotContact(phoneNumber, false)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// partySizeWords are spelled-out head counts (English and Hebrew) understood in answer to the party size question
+var partySizeWords = []struct {
+	size  int
+	words []string
+}{
+	{1, []string{"one", "just me", "only me", "myself", "alone", "אחד", "אחת", "רק אני"}},
+	{2, []string{"two", "both of us", "the two of us", "שניים", "שתיים", "שנינו"}},
+	{3, []string{"three", "שלושה", "שלוש"}},
+	{4, []string{"four", "ארבעה", "ארבע"}},
+	{5, []string{"five", "חמישה", "חמש"}},
+	{6, []string{"six", "שישה", "שש"}},
+	{7, []string{"seven", "שבעה", "שבע"}},
+	{8, []string{"eight", "שמונה"}},
+	{9, []string{"nine", "תשעה", "תשע"}},
+	{10, []string{"ten", "עשרה", "עשר"}},
+}
+
+// parsePartyCount reads the answer to the party size question, a number written as digits or words, or 0 if there is none
+func parsePartyCount(text string) int {
+	if size := parsePartySize(text); size > 0 {
+		return size
+	}
+	for _, entry := range partySizeWords {
+		if containsAny(text, entry.words...) {
+			return entry.size
+		}
+	}
+	return 0
+}
+
+// askPartySize asks a guest who accepted how many people are coming and waits for the answer.
+// It's sent after a guest accepts without saying how many are coming, when AskPartySize is on.
+func (h *RSVPHandler) askPartySize(phoneNumber string, guest *models.Guest) error {
+	question, err := h.render(TemplatePartySize, guest, 0)
+	if err != nil {
+		return err
+	}
+	if err := h.storage.SetConversationState(phoneNumber, models.StateAwaitingPartySize); err != nil {
+		return fmt.Errorf("failed to update conversation state: %w", err)
+	}
+
+	if _, err := h.whatsappService.SendMessage(phoneNumber, question); err != nil {
+		return fmt.Errorf("failed to send party size question: %w", err)
+	}
+	return nil
+}
+
+// handlePartySizeReply stores the head count from a guest we asked about it.
+// A reply that isn't a number is asked about once more, after which the guest is counted as 1.
+// It reports false for replies that change the RSVP instead, so they're handled as a regular message.
+func (h *RSVPHandler) handlePartySizeReply(phoneNumber string, guest *models.Guest, text string) (bool, error) {
+	size := parsePartyCount(text)
+	if size == 0 && h.config.Keywords.status(text) != "" {
+		return false, nil
+	}
+
+	templateName := TemplatePartySizeNoted
+	switch {
+	case size > 0:
+	case guest.ConversationState == models.StateAwaitingPartySize:
+		reprompt, err := h.render(TemplatePartySizeRetry, guest, 0)
+		if err != nil {
+			return true, err
+		}
+		if err := h.storage.SetConversationState(phoneNumber, models.StateAwaitingPartySizeRetry); err != nil {
+			return true, fmt.Errorf("failed to update conversation state: %w", err)
+		}
+		if _, err := h.whatsappService.SendMessage(phoneNumber, reprompt); err != nil {
+			return true, fmt.Errorf("failed to send party size question: %w", err)
+		}
+		return true, nil
+	default:
+		size = 1
+		templateName = TemplatePartySizeAssumed
+	}
+
+	reply, err := h.render(templateName, guest, size)
+	if err != nil {
+		return true, err
+	}
+	if err := h.storage.UpdatePartySize(phoneNumber, size); err != nil {
+		return true, fmt.Errorf("failed to update party size: %w", err)
+	}
+	if err := h.storage.SetConversationState(phoneNumber, models.StateIdle); err != nil {
+		return true, fmt.Errorf("failed to update conversation state: %w", err)
+	}
+	if _, err := h.whatsappService.SendMessage(phoneNumber, reply); err != nil {
+		return true, fmt.Errorf("failed to send party size confirmation: %w", err)
+	}
+
+	if guest.MealPreference == "" {
+		return true, h.askMealPreference(phoneNumber, guest)
+	}
+	return true, nil
+}
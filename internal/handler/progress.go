@@ -0,0 +1,22 @@
+package handler
+
+// Progress reports the outcome for one guest of a bulk send, and how far the send has got
+type Progress struct {
+	Done        int // guests handled so far, including this one
+	Total       int
+	Name        string
+	PhoneNumber string
+	Skipped     bool  // nothing was sent, e.g. because the guest asked not to be messaged
+	Err         error // why the guest failed or was skipped, nil when the message was sent
+}
+
+// ProgressFunc is called after each guest of a bulk send, e.g. to show a live counter.
+// Sends are paced by the WhatsApp rate limit, so calls arrive at that pace too.
+type ProgressFunc func(Progress)
+
+// report passes progress on to f, which may be nil
+func (f ProgressFunc) report(progress Progress) {
+	if f != nil {
+		f(progress)
+	}
+}
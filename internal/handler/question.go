@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+)
+
+// hebrewQuestionWords start a question, which in Hebrew is often written without a question mark.
+// Only the first word counts, since words like "מה" also turn up in answers.
+var hebrewQuestionWords = []string{"האם", "איפה", "היכן", "מתי", "איך", "כיצד", "למה", "מדוע", "מה", "כמה", "מי", "איזה", "איזו", "אפשר", "מותר"}
+
+// isQuestion reports whether a guest's message looks like a question rather than an answer
+func isQuestion(text string) bool {
+	if strings.Contains(text, "?") {
+		return true
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return false
+	}
+	first := strings.TrimFunc(words[0], unicode.IsPunct)
+	return slices.Contains(hebrewQuestionWords, first)
+}
+
+// forwardQuestion passes a guest's question on to the organizer's phone and the webhook, whichever are set,
+// so it can be answered personally. The guest's RSVP is left as it is.
+func (h *RSVPHandler) forwardQuestion(phoneNumber string, guest *models.Guest, question string) error {
+	fmt.Printf("❓ %s (%s) asked: %s\n", guest.Name, phone.Format(phoneNumber), question)
+
+	h.webhook.notifyQuestion(guest, question)
+
+	if h.config.OrganizerPhone == "" {
+		return nil
+	}
+	forward := fmt.Sprintf("❓ Question from %s (%s):\n\n%s", guest.Name, phone.Format(phoneNumber), question)
+	if _, err := h.whatsappService.SendMessage(h.config.OrganizerPhone, forward); err != nil {
+		return fmt.Errorf("failed to forward question to the organizer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"slices"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/whatsapp"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// quotedKeywords are answers too vague to count on their own, like "ok" or a thumbs up,
+// that are clear enough when the guest quotes our invitation to send them
+var quotedKeywords = []keywordGroup{
+	{models.RSVPAccepted, []string{"ok", "okay", "sure", "of course", "definitely", "👍", "אוקיי", "בטח", "ברור", "בטוח"}},
+	{models.RSVPDeclined, []string{"👎"}},
+}
+
+// quotesInvitation reports whether msg is a reply quoting the last invitation or reminder sent to the guest
+func quotesInvitation(msg *events.Message, guest *models.Guest) bool {
+	quoted := whatsapp.QuotedMessageID(msg)
+	return quoted != "" && quoted == guest.LastMessageID
+}
+
+// quotedStatus detects an RSVP answer in a reply quoting our invitation. It's only consulted when the
+// regular keywords find nothing, and also allows a typo when typo tolerance is otherwise off.
+func (h *RSVPHandler) quotedStatus(text string) models.RSVPStatus {
+	// "Definitely not" is no more an answer than with typo-tolerant matching
+	if slices.ContainsFunc(tokenize(text), func(word string) bool { return negationWords[word] }) {
+		return ""
+	}
+	for _, group := range quotedKeywords {
+		if containsAny(text, group.keywords...) {
+			return group.status
+		}
+	}
+	return h.config.Keywords.fuzzyStatus(text, max(h.config.TypoTolerance, 1))
+}
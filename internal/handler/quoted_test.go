@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// quotedReply is a text reply from a number quoting the message with the given ID
+func quotedReply(from, text, quotedID string) *events.Message {
+	msg := textMessage(from, "")
+	msg.Message = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+		Text:        &text,
+		ContextInfo: &waE2E.ContextInfo{StanzaID: &quotedID},
+	}}
+	return msg
+}
+
+func TestHandleMessageQuotedReply(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		quoteInvite bool
+		want        models.RSVPStatus
+	}{
+		{"ok quoting the invitation", "ok", true, models.RSVPAccepted},
+		{"thumbs up quoting the invitation", "👍", true, models.RSVPAccepted},
+		{"Hebrew quoting the invitation", "בטח", true, models.RSVPAccepted},
+		{"thumbs down quoting the invitation", "👎", true, models.RSVPDeclined},
+		{"ok quoting another message", "ok", false, models.RSVPPending},
+		{"negated quoting the invitation", "definitely not", true, models.RSVPPending},
+		{"clear answer quoting another message", "yes", false, models.RSVPAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, guests, _ := newTestHandler(t, nil)
+			if err := h.SendInvitation(testPhone, testName, "", "", "", false); err != nil {
+				t.Fatalf("SendInvitation: %v", err)
+			}
+			guest, err := guests.GetGuest(testPhone)
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+			if guest.LastMessageID == "" {
+				t.Fatal("the invitation's message ID wasn't stored")
+			}
+
+			quoted := "SOMEOTHERMSG"
+			if tt.quoteInvite {
+				quoted = guest.LastMessageID
+			}
+			if err := h.HandleMessage(quotedReply(testPhone, tt.text, quoted)); err != nil {
+				t.Fatalf("HandleMessage: %v", err)
+			}
+
+			if status := guestStatus(t, guests, testPhone); status != tt.want {
+				t.Errorf("status = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessageUnquotedVagueReply(t *testing.T) {
+	h, guests, _ := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "ok")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPPending {
+		t.Errorf("status = %s, want %s without the invitation quoted", status, models.RSVPPending)
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"strings"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// reactionStatuses are the reactions on our invitation that count as an answer
+var reactionStatuses = map[string]models.RSVPStatus{
+	"👍":  models.RSVPAccepted,
+	"❤️": models.RSVPAccepted,
+	"❤":  models.RSVPAccepted,
+	"😍":  models.RSVPAccepted,
+	"🥰":  models.RSVPAccepted,
+	"👎":  models.RSVPDeclined,
+}
+
+// reactionStatus returns the answer a reaction emoji stands for, whatever its skin tone,
+// or "" if it isn't one
+func reactionStatus(emoji string) models.RSVPStatus {
+	return reactionStatuses[withoutSkinTone(emoji)]
+}
+
+// withoutSkinTone removes skin tone modifiers from an emoji, so 👍🏽 is read as 👍
+func withoutSkinTone(emoji string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 0x1F3FB && r <= 0x1F3FF {
+			return -1
+		}
+		return r
+	}, emoji)
+}
+
+// invitationReaction returns the answer a reaction stands for when it's on the last invitation
+// or reminder sent to the guest, or "" for reactions on anything else
+func invitationReaction(messageID, emoji string, guest *models.Guest) models.RSVPStatus {
+	if messageID == "" || messageID != guest.LastMessageID {
+		return ""
+	}
+	return reactionStatus(emoji)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestReactionStatus(t *testing.T) {
+	tests := []struct {
+		emoji string
+		want  models.RSVPStatus
+	}{
+		{"👍", models.RSVPAccepted},
+		{"👍🏽", models.RSVPAccepted},
+		{"❤️", models.RSVPAccepted},
+		{"❤", models.RSVPAccepted},
+		{"😍", models.RSVPAccepted},
+		{"👎", models.RSVPDeclined},
+		{"👎🏿", models.RSVPDeclined},
+		{"😂", ""},
+		{"🙏", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := reactionStatus(tt.emoji); got != tt.want {
+			t.Errorf("reactionStatus(%q) = %q, want %q", tt.emoji, got, tt.want)
+		}
+	}
+}
+
+// reactionMessage is a reaction from a number on the message with the given ID
+func reactionMessage(from, emoji, messageID string) *events.Message {
+	msg := textMessage(from, "")
+	msg.Message = &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{
+		Key:  &waCommon.MessageKey{ID: &messageID},
+		Text: &emoji,
+	}}
+	return msg
+}
+
+func TestHandleMessageReaction(t *testing.T) {
+	tests := []struct {
+		name         string
+		emoji        string
+		onInvitation bool
+		want         models.RSVPStatus
+	}{
+		{"thumbs up on the invitation", "👍", true, models.RSVPAccepted},
+		{"heart on the invitation", "❤️", true, models.RSVPAccepted},
+		{"thumbs down on the invitation", "👎", true, models.RSVPDeclined},
+		{"other emoji on the invitation", "😂", true, models.RSVPPending},
+		{"thumbs up on another message", "👍", false, models.RSVPPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, guests, _ := newTestHandler(t, nil)
+			if err := h.SendInvitation(testPhone, testName, "", "", "", false); err != nil {
+				t.Fatalf("SendInvitation: %v", err)
+			}
+			guest, err := guests.GetGuest(testPhone)
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+
+			messageID := "SOMEOTHERMSG"
+			if tt.onInvitation {
+				messageID = guest.LastMessageID
+			}
+			if err := h.HandleMessage(reactionMessage(testPhone, tt.emoji, messageID)); err != nil {
+				t.Fatalf("HandleMessage: %v", err)
+			}
+
+			if status := guestStatus(t, guests, testPhone); status != tt.want {
+				t.Errorf("status = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessageReactionFromStranger(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+
+	if err := h.HandleMessage(reactionMessage(testPhone, "👍", "MSG1")); err != nil {
+		t.Fatalf("HandleMessage: %v", err)
+	}
+	if contacts := guests.GetUnknownContacts(); len(contacts) != 0 {
+		t.Errorf("unknown contacts = %+v, want a stranger's reaction ignored", contacts)
+	}
+	if len(sender.messages(testPhone)) != 0 {
+		t.Error("replied to a stranger's reaction")
+	}
+}
@@ -1,34 +1,164 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
+	"wedding-whatsapp/internal/metrics"
 	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
 	"wedding-whatsapp/internal/storage"
 	"wedding-whatsapp/internal/whatsapp"
 
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
+// buttonStatuses maps the IDs of the invitation's interactive buttons to the RSVP status they select
+var buttonStatuses = map[string]models.RSVPStatus{
+	whatsapp.ButtonAccept:  models.RSVPAccepted,
+	whatsapp.ButtonDecline: models.RSVPDeclined,
+}
+
+// partySizePattern matches a head count in replies like "yes, 3 people" or "coming with 2".
+// A number introduced by "with"/"plus"/"+" counts companions, so the guest is added on top.
+var partySizePattern = regexp.MustCompile(`(with|plus|\+)?\s*(\d+)`)
+
+// maxPartySize guards against treating unrelated numbers (dates, times) as a head count
+const maxPartySize = 20
+
+// maxConfirmationRetryDelay caps the exponential backoff between confirmation retries
+const maxConfirmationRetryDelay = time.Hour
+
+// ErrAlreadyInvited is returned by SendInvitation when the guest was already sent an invitation
+// within the reinvite window. Bulk imports can ignore it, which makes running them twice safe.
+var ErrAlreadyInvited = errors.New("guest was already invited")
+
+// MessageSender sends messages to guests. It is implemented by *whatsapp.Service,
+// and lets the handler run against a fake that records messages instead of sending them.
+type MessageSender interface {
+	SendMessage(phoneNumber, message string) (string, error)
+	SendInvitation(phoneNumber, message string) (string, error)
+	SendReminder(phoneNumber, message string) (string, error)
+	SendLocation(phoneNumber string, lat, lng float64, name string) error
+	SendToGroup(groupJID, message string) error
+	IsOwnNumber(phoneNumber string) bool
+	MarkRead(msg *events.Message) error
+}
+
+var _ MessageSender = (*whatsapp.Service)(nil)
+
 type RSVPHandler struct {
-	whatsappService *whatsapp.Service
-	storage         *storage.Storage
+	whatsappService MessageSender
+	storage         storage.Store
+	replyQueue      *storage.ReplyQueue
+	schedule        *storage.BroadcastSchedule
+	webhook         *webhook
 	config          *Config
 }
 
 type Config struct {
-	WeddingDate     string
 	WeddingLocation string
 	BrideName       string
 	GroomName       string
+
+	// WeddingDate is spelled out in the language of each message.
+	// When it's zero WeddingDateText is used as written.
+	WeddingDate     time.Time
+	WeddingDateText string
+
+	// PrimaryLanguage ("en" or "he") is used for guests until they reply in another language
+	PrimaryLanguage string
+
+	// RSVPDeadline is when replies stop changing the RSVP status, zero for no deadline.
+	// A guest's own RSVPDeadline takes precedence.
+	RSVPDeadline time.Time
+
+	// RSVPChangeDeadline is when guests who already answered can no longer change their answer,
+	// though those who haven't answered still can until RSVPDeadline. Zero for no limit.
+	RSVPChangeDeadline time.Time
+
+	// NotifyWebhookURL receives a POST whenever a guest's RSVP changes or they ask a question, empty to disable
+	NotifyWebhookURL string
+
+	// OrganizerPhone is sent the questions guests ask, empty to only print them and post them to the webhook
+	OrganizerPhone string
+
+	// AskPartySize has the invitation ask how many are coming, and guests who accept without
+	// saying are asked for the number before anything else
+	AskPartySize bool
+
+	// UnknownSenderReply is sent the first time a number that isn't on the guest list messages us,
+	// empty to only record the contact
+	UnknownSenderReply string
+
+	// RSVPBaseURL is where the API is reachable by guests, used to build their web RSVP links.
+	// Messages have no link when it's empty.
+	RSVPBaseURL string
+
+	// VenueLatitude and VenueLongitude are sent as a location pin after a guest accepts,
+	// unless both are zero
+	VenueLatitude  float64
+	VenueLongitude float64
+
+	// GiftLink is a gift registry link added to the reply to guests who accept, empty to leave it out
+	GiftLink string
+
+	// Templates renders the messages sent to guests, nil for the built-in wording
+	Templates *Templates
+
+	// Keywords recognizes RSVP answers in text replies, nil for the built-in keywords
+	Keywords *Keywords
+
+	// MarkRead sends read receipts for guests' messages, so they can see their answer was read
+	MarkRead bool
+
+	// TypoTolerance is how many typos a short reply may have and still count as a keyword,
+	// so "yse" or "acept" are understood. Zero only accepts exact keywords.
+	TypoTolerance int
+
+	// ReinviteWindow is how long after an invitation SendInvitation refuses to send another one
+	// unless forced, zero to always send
+	ReinviteWindow time.Duration
+
+	// EventOver stops reading messages as RSVPs: guests are thanked instead, once each.
+	// This also happens on its own from the day after WeddingDate.
+	EventOver bool
+
+	// Metrics counts RSVP answers, nil to disable
+	Metrics *metrics.Metrics
+
+	// ConfirmationRetryMaxAttempts is how many times a failed confirmation reply is retried before giving up,
+	// 0 to drop it after its first retry fails
+	ConfirmationRetryMaxAttempts int
+
+	// ConfirmationRetryBaseDelay is the wait before the first retry, doubled on each further attempt,
+	// 0 to retry on the next RetryConfirmations run
+	ConfirmationRetryBaseDelay time.Duration
 }
 
 // NewRSVPHandler creates a new RSVP handler
-func NewRSVPHandler(whatsappService *whatsapp.Service, storage *storage.Storage, cfg *Config) *RSVPHandler {
+func NewRSVPHandler(whatsappService MessageSender, storage storage.Store, replyQueue *storage.ReplyQueue, schedule *storage.BroadcastSchedule, cfg *Config) *RSVPHandler {
+	if cfg.Templates == nil {
+		// The built-in templates always parse
+		cfg.Templates, _ = LoadTemplates("")
+	}
+	if cfg.Keywords == nil {
+		// The built-in keywords never conflict
+		cfg.Keywords, _ = LoadKeywords("")
+	}
+
 	return &RSVPHandler{
-		whatsappService: whatsappService,
+		whatsappService: &attemptRecorder{MessageSender: whatsappService, storage: storage},
 		storage:         storage,
+		replyQueue:      replyQueue,
+		schedule:        schedule,
+		webhook:         newWebhook(cfg.NotifyWebhookURL),
 		config:          cfg,
 	}
 }
@@ -39,8 +169,10 @@ func (h *RSVPHandler) HandleMessage(msg *events.Message) error {
 		return nil
 	}
 
-	text := msg.Message.GetConversation()
-	if text == "" {
+	text := whatsapp.MessageText(msg)
+	button := whatsapp.SelectedButton(msg)
+	reactedTo, reaction := whatsapp.Reaction(msg)
+	if text == "" && button == "" && reaction == "" {
 		return nil
 	}
 
@@ -53,88 +185,728 @@ func (h *RSVPHandler) HandleMessage(msg *events.Message) error {
 	phoneNumber = strings.ReplaceAll(phoneNumber, " ", "")
 
 	// Get guest - only process RSVP if guest was previously invited
-	_, err := h.storage.GetGuest(phoneNumber)
+	guest, err := h.storage.GetGuest(phoneNumber)
 	if err != nil {
-		// Guest not found, might be a new conversation - ignore
+		// The guest may be writing from a variant of their number
+		var matched bool
+		if guest, matched = h.softMatchGuest(phoneNumber); !matched {
+			// Reactions from strangers can't be on anything we sent them
+			if reaction != "" {
+				return nil
+			}
+			// Not a guest - record who it was so they can be invited if they should be
+			return h.handleUnknownContact(msg, phoneNumber, text)
+		}
+	}
+
+	// A reaction is only an answer when it's on our invitation
+	reactionAnswer := invitationReaction(reactedTo, reaction, guest)
+	if reaction != "" && reactionAnswer == "" {
 		return nil
 	}
 
-	// Check if this is an RSVP response
-	text = strings.ToLower(strings.TrimSpace(text))
-
-	var newStatus models.RSVPStatus
-	var responseMessage string
-
-	if containsAny(text, "yes", "yep", "yeah", "accept", "accepting", "attending", "coming", "will come", "will be there", "✅") {
-		newStatus = models.RSVPAccepted
-		responseMessage = fmt.Sprintf(
-			"🎉 Wonderful! We're so excited to celebrate with you!\n\n"+
-				"We've confirmed your attendance for the wedding of %s & %s on %s.\n\n"+
-				"See you there! 💕",
-			h.config.BrideName, h.config.GroomName, h.config.WeddingDate,
-		)
-	} else if containsAny(text, "no", "nope", "decline", "declining", "not coming", "can't come", "won't come", "can't make it", "❌") {
-		newStatus = models.RSVPDeclined
-		responseMessage = fmt.Sprintf(
-			"Thank you for letting us know. We're sorry you won't be able to join us for the wedding of %s & %s.\n\n"+
-				"We'll miss you! 💕",
-			h.config.BrideName, h.config.GroomName,
-		)
-	} else {
+	// Only guests' messages are marked as read, once they've been handled
+	defer h.markRead(msg)
+
+	// Keep the reply as received for the guest's RSVP history; button taps are recorded by their ID
+	// and reactions by their emoji
+	received := strings.TrimSpace(text)
+	if received == "" {
+		received = button
+	}
+	if received == "" {
+		received = reaction
+	}
+
+	// Answer in the language the guest writes in from now on
+	if language := detectLanguage(text); language != "" && language != guest.Language {
+		if err := h.storage.SetLanguage(phoneNumber, language); err != nil {
+			fmt.Printf("Failed to save language for %s: %v\n", phone.Format(phoneNumber), err)
+		}
+		guest.Language = language
+	}
+
+	// Line breaks and decorative emoji don't change what a reply says
+	text = h.config.Keywords.normalizeReply(strings.ToLower(text))
+
+	// A guest asking us to stop is never taken as an answer
+	if reactionAnswer == "" && button == "" && h.config.Keywords.optOut(text) {
+		return h.handleOptOut(phoneNumber, guest)
+	}
+
+	// Once the wedding is over, "thanks, great wedding!" isn't an answer to anything
+	if h.eventOver(time.Now()) {
+		return h.handleAfterEvent(phoneNumber, guest, received)
+	}
+
+	// A question, like "is there parking?", is for the organizer to answer and is never read as an answer itself
+	if reactionAnswer == "" && button == "" && isQuestion(text) {
+		return h.forwardQuestion(phoneNumber, guest, received)
+	}
+
+	// If we asked for a meal choice or head count, treat the reply as the answer before looking for an RSVP.
+	// A reaction on the invitation can only be an RSVP.
+	switch {
+	case reactionAnswer != "":
+	case guest.ConversationState == models.StateAwaitingMeal:
+		if handled, err := h.handleMealReply(phoneNumber, guest, text); handled {
+			return err
+		}
+	case guest.ConversationState == models.StateAwaitingPartySize, guest.ConversationState == models.StateAwaitingPartySizeRetry:
+		if handled, err := h.handlePartySizeReply(phoneNumber, guest, text); handled {
+			return err
+		}
+	}
+
+	// Check if this is an RSVP response, either a tapped button, a reaction or a text reply
+	newStatus := buttonStatuses[button]
+	if newStatus == "" {
+		newStatus = reactionAnswer
+	}
+	if newStatus == "" {
+		newStatus = h.config.Keywords.status(text)
+	}
+	if newStatus == "" {
+		newStatus = h.config.Keywords.fuzzyStatus(text, h.config.TypoTolerance)
+	}
+	if newStatus == "" && quotesInvitation(msg, guest) {
+		newStatus = h.quotedStatus(text)
+	}
+
+	if newStatus != "" && h.rsvpClosed(guest, time.Now()) {
+		return h.replyRSVPClosed(phoneNumber, guest, received, newStatus)
+	}
+	changed := newStatus != "" && isChange(guest, newStatus)
+	if changed && h.changesLocked(time.Now()) {
+		return h.replyRSVPClosed(phoneNumber, guest, received, newStatus)
+	}
+
+	partySize := 0
+	askPartySize := false
+	templateName := TemplateDeclined
+
+	if newStatus == models.RSVPAccepted {
+		partySize = parsePartySize(text)
+		if partySize == 0 {
+			// Count the guest alone until they tell us otherwise
+			partySize = 1
+			askPartySize = h.config.AskPartySize
+		}
+		templateName = TemplateAccepted
+	} else if newStatus == models.RSVPMaybe {
+		templateName = TemplateMaybe
+	} else if newStatus != models.RSVPDeclined {
 		// Not a clear RSVP response, ignore
 		return nil
 	}
 
+	responseMessage, err := h.render(templateName, guest, partySize)
+	if err != nil {
+		return err
+	}
+	// Only guests who are coming are pointed at the registry
+	if newStatus == models.RSVPAccepted && h.config.GiftLink != "" {
+		gift, err := h.render(TemplateGift, guest, partySize)
+		if err != nil {
+			return err
+		}
+		responseMessage += "\n\n" + gift
+	}
+	// Acknowledge a change of plans specifically, so the guest knows their earlier answer was replaced
+	if changed {
+		notice, err := h.changeNotice(guest, newStatus)
+		if err != nil {
+			return err
+		}
+		responseMessage = notice + "\n\n" + responseMessage
+	}
+
 	// Update RSVP status
-	if err := h.storage.UpdateRSVP(phoneNumber, newStatus, ""); err != nil {
+	if err := h.storage.UpdateRSVP(phoneNumber, newStatus, "", received); err != nil {
 		return fmt.Errorf("failed to update RSVP: %w", err)
 	}
+	h.webhook.notifyRSVP(guest, newStatus)
+	h.config.Metrics.RSVP(newStatus)
 
-	// Send confirmation message
-	if err := h.whatsappService.SendMessage(phoneNumber, responseMessage); err != nil {
-		return fmt.Errorf("failed to send confirmation: %w", err)
+	// Declined and undecided guests don't count towards the head count
+	if err := h.storage.UpdatePartySize(phoneNumber, partySize); err != nil {
+		return fmt.Errorf("failed to update party size: %w", err)
+	}
+
+	// Send confirmation message, queueing it for retry if it fails so the guest still hears back
+	if _, err := h.whatsappService.SendMessage(phoneNumber, responseMessage); err != nil {
+		if qErr := h.queueConfirmation(phoneNumber, responseMessage, err); qErr != nil {
+			return fmt.Errorf("failed to send confirmation: %w (and failed to queue retry: %v)", err, qErr)
+		}
+		return fmt.Errorf("failed to send confirmation, queued for retry: %w", err)
+	}
+
+	// A confirmation still queued from an earlier answer is stale now that this one got through
+	if err := h.replyQueue.Remove(phoneNumber); err != nil {
+		return fmt.Errorf("failed to clear queued confirmation: %w", err)
+	}
+	if err := h.storage.SetConfirmationDelivered(phoneNumber, true); err != nil {
+		return fmt.Errorf("failed to mark confirmation delivered: %w", err)
+	}
+
+	// Accepted guests get the venue's location and are asked for their head count and meal choice as a follow-up
+	if newStatus == models.RSVPAccepted {
+		if err := h.sendVenueLocation(phoneNumber); err != nil {
+			fmt.Printf("⚠️ Failed to send venue location to %s: %v\n", phone.Format(phoneNumber), err)
+		}
+	}
+	if askPartySize {
+		return h.askPartySize(phoneNumber, guest)
+	}
+	if newStatus == models.RSVPAccepted && guest.MealPreference == "" {
+		return h.askMealPreference(phoneNumber, guest)
+	}
+	if newStatus == models.RSVPDeclined && guest.ConversationState != models.StateIdle {
+		if err := h.storage.SetConversationState(phoneNumber, models.StateIdle); err != nil {
+			return fmt.Errorf("failed to update conversation state: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// SendInvitation sends a wedding invitation to a guest
-func (h *RSVPHandler) SendInvitation(phoneNumber, name string) error {
+// OpenConversations counts the guests we're waiting on for an answer, by what we asked them.
+// The state is stored with each guest, so questions asked before a restart are still answered afterwards.
+func (h *RSVPHandler) OpenConversations() map[models.ConversationState]int {
+	open := make(map[models.ConversationState]int)
+	for _, guest := range h.storage.GetAllGuests() {
+		if guest.ConversationState != models.StateIdle {
+			open[guest.ConversationState]++
+		}
+	}
+	return open
+}
+
+// handleUnknownContact records a message from a number that isn't on the guest list,
+// answering with UnknownSenderReply the first time the number writes
+func (h *RSVPHandler) handleUnknownContact(msg *events.Message, phoneNumber, text string) error {
+	// Group chats and status updates aren't addressed to the bot
+	if msg.Info.IsGroup || msg.Info.Chat.Server == types.BroadcastServer {
+		return nil
+	}
+
+	first, err := h.storage.RecordUnknownContact(phoneNumber, strings.TrimSpace(text))
+	if err != nil {
+		return fmt.Errorf("failed to record unknown contact: %w", err)
+	}
+	fmt.Printf("❔ Message from %s, who isn't on the guest list: %q\n", phone.Format(phoneNumber), text)
+
+	if !first || h.config.UnknownSenderReply == "" {
+		return nil
+	}
+	if _, err := h.whatsappService.SendMessage(phoneNumber, h.config.UnknownSenderReply); err != nil {
+		return fmt.Errorf("failed to reply to unknown contact: %w", err)
+	}
+	return nil
+}
+
+// markRead marks a guest's message as read if read receipts are enabled, logging any failure
+func (h *RSVPHandler) markRead(msg *events.Message) {
+	if !h.config.MarkRead {
+		return
+	}
+	if err := h.whatsappService.MarkRead(msg); err != nil {
+		fmt.Printf("⚠️ %v (from %s)\n", err, phone.Format(msg.Info.Sender.User))
+	}
+}
+
+// sendVenueLocation sends the venue's location pin, if one is configured
+func (h *RSVPHandler) sendVenueLocation(phoneNumber string) error {
+	if h.config.VenueLatitude == 0 && h.config.VenueLongitude == 0 {
+		return nil
+	}
+	return h.whatsappService.SendLocation(phoneNumber, h.config.VenueLatitude, h.config.VenueLongitude, h.config.WeddingLocation)
+}
+
+// rsvpClosed reports whether the guest's RSVP deadline has passed
+func (h *RSVPHandler) rsvpClosed(guest *models.Guest, now time.Time) bool {
+	if h.eventOver(now) {
+		return true
+	}
+	deadline := h.config.RSVPDeadline
+	if !guest.RSVPDeadline.IsZero() {
+		deadline = guest.RSVPDeadline
+	}
+	return !deadline.IsZero() && now.After(deadline)
+}
+
+// replyRSVPClosed tells a guest who answered after the deadline that their RSVP can no longer change.
+// The attempt is logged but their status is left as it was.
+func (h *RSVPHandler) replyRSVPClosed(phoneNumber string, guest *models.Guest, received string, status models.RSVPStatus) error {
+	fmt.Printf("⏰ %s (%s) replied %q (%s) after the RSVP deadline, status left as %s\n",
+		guest.Name, phone.Format(phoneNumber), received, status, guest.RSVPStatus)
+
+	message, err := h.render(TemplateClosed, guest, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := h.whatsappService.SendMessage(phoneNumber, message); err != nil {
+		return fmt.Errorf("failed to send RSVP closed reply: %w", err)
+	}
+	return nil
+}
+
+// HandleReceipt records delivery and read receipts for invitations and reminders
+func (h *RSVPHandler) HandleReceipt(receipt *events.Receipt) error {
+	var status models.DeliveryStatus
+	switch receipt.Type {
+	case types.ReceiptTypeDelivered:
+		status = models.DeliveryDelivered
+	case types.ReceiptTypeRead, types.ReceiptTypeReadSelf:
+		status = models.DeliveryRead
+	default:
+		return nil
+	}
+
+	var errs []error
+	for _, messageID := range receipt.MessageIDs {
+		if err := h.storage.UpdateDeliveryStatus(messageID, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update delivery status of %s: %w", messageID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// queueConfirmation stores a failed confirmation reply so it can be retried later
+func (h *RSVPHandler) queueConfirmation(phoneNumber, message string, sendErr error) error {
+	if err := h.storage.SetConfirmationDelivered(phoneNumber, false); err != nil {
+		return err
+	}
+
+	return h.replyQueue.Enqueue(models.PendingReply{
+		PhoneNumber: phoneNumber,
+		Message:     message,
+		Attempts:    1,
+		NextAttempt: time.Now().Add(h.retryDelay(1)),
+		LastError:   sendErr.Error(),
+	})
+}
+
+// RetryConfirmations resends queued confirmation replies that are due.
+// Replies that still fail are rescheduled with backoff until the attempt limit is reached.
+func (h *RSVPHandler) RetryConfirmations() (int, error) {
+	delivered := 0
+	var errs []error
+
+	for _, reply := range h.replyQueue.Due(time.Now()) {
+		_, err := h.whatsappService.SendMessage(reply.PhoneNumber, reply.Message)
+		if err == nil {
+			if err := h.replyQueue.Remove(reply.PhoneNumber); err != nil {
+				errs = append(errs, err)
+			}
+			if err := h.storage.SetConfirmationDelivered(reply.PhoneNumber, true); err != nil {
+				errs = append(errs, err)
+			}
+			delivered++
+			continue
+		}
+
+		reply.Attempts++
+		if reply.Attempts > h.config.ConfirmationRetryMaxAttempts {
+			errs = append(errs, fmt.Errorf("giving up on confirmation to %s after %d attempts: %w", reply.PhoneNumber, reply.Attempts-1, err))
+			if err := h.replyQueue.Remove(reply.PhoneNumber); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		reply.NextAttempt = time.Now().Add(h.retryDelay(reply.Attempts))
+		reply.LastError = err.Error()
+		if err := h.replyQueue.Enqueue(reply); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+// RunConfirmationRetries periodically retries queued confirmation replies until stop is closed
+func (h *RSVPHandler) RunConfirmationRetries(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			delivered, err := h.RetryConfirmations()
+			if delivered > 0 {
+				fmt.Printf("✓ Delivered %d queued confirmation(s)\n", delivered)
+			}
+			if err != nil {
+				fmt.Printf("⚠️ Confirmation retry: %v\n", err)
+			}
+		}
+	}
+}
+
+// retryDelay returns the backoff delay before the given attempt number
+func (h *RSVPHandler) retryDelay(attempt int) time.Duration {
+	delay := h.config.ConfirmationRetryBaseDelay
+	for i := 1; i < attempt && delay < maxConfirmationRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxConfirmationRetryDelay)
+}
+
+// SendInvitation sends a wedding invitation to a guest, ending with customMessage when it isn't empty.
+// The note, side (whose guest they are, e.g. "bride") and tier (which invitation they get, e.g. "formal")
+// are stored on the guest, and a guest invited again without them keeps their previous ones. A guest invited again also keeps the answer they already gave.
+// A guest already sent an invitation within the reinvite window gets ErrAlreadyInvited unless force is set.
+func (h *RSVPHandler) SendInvitation(phoneNumber, name, customMessage, side, tier string, force bool) error {
 	// Normalize phone number before storing (so it matches WhatsApp format)
 	normalizedNumber := whatsapp.NormalizePhoneNumber(phoneNumber)
+	if !phone.IsValid(normalizedNumber) {
+		return fmt.Errorf("%q: %w", phoneNumber, storage.ErrInvalidPhone)
+	}
 
-	// Add or update guest in storage with normalized phone number
-	guest := models.Guest{
-		PhoneNumber: normalizedNumber,
-		Name:        name,
-		RSVPStatus:  models.RSVPPending,
+	if existing, err := h.storage.GetGuest(normalizedNumber); err == nil && !force && h.recentlyInvited(existing) {
+		return fmt.Errorf("%s on %s: %w", normalizedNumber, existing.InvitedDate.Format("2006-01-02 15:04"), ErrAlreadyInvited)
 	}
 
-	if err := h.storage.AddGuest(guest); err != nil {
+	// A guest invited again keeps their answer, RSVP link, language and, unless given new ones, their note, side and tier
+	err := h.storage.UpsertGuest(models.Guest{
+		PhoneNumber:   normalizedNumber,
+		Name:          name,
+		RSVPStatus:    models.RSVPPending,
+		CustomMessage: strings.TrimSpace(customMessage),
+		Side:          NormalizeSide(side),
+		Tier:          NormalizeTier(tier),
+	})
+	if err != nil {
 		return fmt.Errorf("failed to add guest: %w", err)
 	}
 
-	// Send invitation via WhatsApp (it will normalize again, but that's fine)
-	if err := h.whatsappService.SendInvitation(
-		phoneNumber,
-		name,
-		h.config.WeddingDate,
-		h.config.WeddingLocation,
-		h.config.BrideName,
-		h.config.GroomName,
-	); err != nil {
-		return fmt.Errorf("failed to send invitation: %w", err)
+	guest, err := h.storage.GetGuest(normalizedNumber)
+	if err != nil {
+		return err
+	}
+	return h.deliverInvitation(*guest)
+}
+
+// recentlyInvited reports whether the guest's invitation went out within the reinvite window.
+// Guests whose invitation was never sent, e.g. because it failed, can always be invited.
+func (h *RSVPHandler) recentlyInvited(guest *models.Guest) bool {
+	return h.config.ReinviteWindow > 0 &&
+		guest.LastMessageID != "" &&
+		time.Since(guest.InvitedDate) < h.config.ReinviteWindow
+}
+
+// NormalizeSide tidies up the side a guest was invited by, so "Bride " and "bride" are counted together
+func NormalizeSide(side string) string {
+	return strings.ToLower(strings.TrimSpace(side))
+}
+
+// NormalizeTier tidies up a guest's tier to match the name of its invitation template
+func NormalizeTier(tier string) string {
+	return strings.ToLower(strings.TrimSpace(tier))
+}
+
+// ResendInvitation sends the invitation again to a guest who is already stored,
+// keeping their record (and personal note) as it is apart from the resend count
+func (h *RSVPHandler) ResendInvitation(phoneNumber string) error {
+	guest, err := h.storage.GetGuest(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	if err := h.deliverInvitation(*guest); err != nil {
+		return err
 	}
 
+	if err := h.storage.IncrementResendCount(guest.PhoneNumber); err != nil {
+		return fmt.Errorf("failed to record resend: %w", err)
+	}
 	return nil
 }
 
-// containsAny checks if the text contains any of the given keywords
+// ResendUnreached resends the invitation to pending guests whose last message was never delivered
+func (h *RSVPHandler) ResendUnreached(progress ProgressFunc) (int, error) {
+	var unreached []models.Guest
+	for _, guest := range h.storage.GetGuestsByStatus(models.RSVPPending) {
+		if !guest.Unreachable && !guest.DoNotContact && !guest.DeliveryStatus.After(models.DeliverySent) {
+			unreached = append(unreached, guest)
+		}
+	}
+
+	sent := 0
+	var errs []error
+	for i, guest := range unreached {
+		err := h.ResendInvitation(guest.PhoneNumber)
+		progress.report(Progress{Done: i + 1, Total: len(unreached), Name: guest.Name, PhoneNumber: guest.PhoneNumber, Err: err})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resend invitation to %s: %w", guest.PhoneNumber, err))
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// deliverInvitation sends the invitation to a stored guest and starts tracking its delivery.
+// Guests who asked not to be messaged get ErrDoNotContact instead.
+func (h *RSVPHandler) deliverInvitation(guest models.Guest) error {
+	if guest.DoNotContact {
+		return fmt.Errorf("%s: %w", guest.PhoneNumber, ErrDoNotContact)
+	}
+	if err := h.ensureRSVPToken(&guest); err != nil {
+		return err
+	}
+
+	message, err := h.renderInvitation(&guest)
+	if err != nil {
+		return err
+	}
+	if guest.CustomMessage != "" {
+		message += "\n\n" + guest.CustomMessage
+	}
+
+	messageID, err := h.whatsappService.SendInvitation(guest.PhoneNumber, message)
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to send invitation: %w", err), h.recordSendFailure(guest.PhoneNumber, err))
+	}
+
+	if err := h.recordMessageSent(guest.PhoneNumber, messageID); err != nil {
+		return fmt.Errorf("failed to record invitation: %w", err)
+	}
+	return nil
+}
+
+// HandleDeferredSent starts tracking delivery of an invitation or reminder held back during quiet hours.
+// If it couldn't be sent, the failure is added to the guest's send attempts, since holding it back
+// was recorded as a success, and a guest who can't be reached is marked unreachable.
+// Numbers that aren't on the guest list, like the recipient of a test invitation, are ignored.
+func (h *RSVPHandler) HandleDeferredSent(phoneNumber, messageID string, sendErr error) error {
+	if _, err := h.storage.GetGuest(phoneNumber); err != nil {
+		return nil
+	}
+	if sendErr != nil {
+		attempt := models.SendAttempt{Timestamp: time.Now(), Error: sendErr.Error()}
+		recordErr := h.storage.RecordSendAttempt(phoneNumber, attempt)
+		if recordErr != nil {
+			recordErr = fmt.Errorf("failed to record the send attempt: %w", recordErr)
+		}
+		return errors.Join(recordErr, h.recordSendFailure(phoneNumber, sendErr))
+	}
+	return h.recordMessageSent(phoneNumber, messageID)
+}
+
+// HandleJIDVerified stores the JID a guest's number was verified at, or forgets it when jid is empty,
+// so it's reused after a restart. Numbers that aren't on the guest list are ignored.
+func (h *RSVPHandler) HandleJIDVerified(phoneNumber, jid string, verifiedAt time.Time) {
+	if _, err := h.storage.GetGuest(phoneNumber); err != nil {
+		return
+	}
+	if err := h.storage.SetJID(phoneNumber, jid, verifiedAt); err != nil {
+		fmt.Printf("⚠️ Failed to store the JID of %s: %v\n", phone.Format(phoneNumber), err)
+	}
+}
+
+// recordMessageSent starts tracking delivery of a message.
+// Messages deferred for quiet hours have no ID yet and are recorded once they go out.
+func (h *RSVPHandler) recordMessageSent(phoneNumber, messageID string) error {
+	if messageID == "" {
+		return nil
+	}
+	return h.storage.RecordMessageSent(phoneNumber, messageID)
+}
+
+// recordSendFailure marks the guest unreachable when a send failed because of the recipient,
+// so they can be followed up by phone instead of being retried
+func (h *RSVPHandler) recordSendFailure(phoneNumber string, sendErr error) error {
+	if !errors.Is(sendErr, whatsapp.ErrUnreachable) {
+		return nil
+	}
+	if err := h.storage.MarkUnreachable(phoneNumber, sendErr.Error()); err != nil {
+		return fmt.Errorf("failed to mark %s unreachable: %w", phoneNumber, err)
+	}
+	return nil
+}
+
+// SendReminders sends a follow-up to pending guests who were invited more than olderThan ago.
+// Guests already reminded within the same window are skipped so nobody is pinged twice.
+func (h *RSVPHandler) SendReminders(olderThan time.Duration, progress ProgressFunc) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	var due []models.Guest
+	for _, guest := range h.storage.GetGuestsInvitedBefore(cutoff) {
+		if guest.RSVPStatus == models.RSVPPending && !guest.Unreachable && !guest.DoNotContact && !guest.LastReminderDate.After(cutoff) {
+			due = append(due, guest)
+		}
+	}
+
+	sent := 0
+	var errs []error
+	for i, guest := range due {
+		message, err := h.render(TemplateReminder, &guest, 0)
+		if err != nil {
+			return sent, err
+		}
+
+		messageID, err := h.whatsappService.SendReminder(guest.PhoneNumber, message)
+		if err != nil {
+			err = errors.Join(fmt.Errorf("failed to send reminder to %s: %w", guest.PhoneNumber, err), h.recordSendFailure(guest.PhoneNumber, err))
+			errs = append(errs, err)
+			progress.report(Progress{Done: i + 1, Total: len(due), Name: guest.Name, PhoneNumber: guest.PhoneNumber, Err: err})
+			continue
+		}
+		sent++
+		progress.report(Progress{Done: i + 1, Total: len(due), Name: guest.Name, PhoneNumber: guest.PhoneNumber})
+
+		if err := h.storage.SetLastReminderDate(guest.PhoneNumber, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record reminder for %s: %w", guest.PhoneNumber, err))
+		}
+		if err := h.recordMessageSent(guest.PhoneNumber, messageID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record reminder for %s: %w", guest.PhoneNumber, err))
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// language returns the language to write to a guest in: the one they reply in, or the primary language
+func (h *RSVPHandler) language(guest *models.Guest) string {
+	if guest.Language != "" {
+		return guest.Language
+	}
+	return h.config.PrimaryLanguage
+}
+
+// detectLanguage returns the language text is written in, or "" if it has no letters to tell by
+func detectLanguage(text string) string {
+	latin := false
+	for _, r := range text {
+		if unicode.Is(unicode.Hebrew, r) && unicode.IsLetter(r) {
+			return models.LanguageHebrew
+		}
+		if unicode.Is(unicode.Latin, r) {
+			latin = true
+		}
+	}
+	if latin {
+		return models.LanguageEnglish
+	}
+	return ""
+}
+
+// InvitationTiers returns the guest tiers that have their own invitation
+func (h *RSVPHandler) InvitationTiers() []string {
+	return h.config.Templates.Tiers()
+}
+
+// renderInvitation fills in the invitation for the guest's tier. A tier without its own
+// invitation gets the standard one, with a warning so a misspelled tier is noticed.
+func (h *RSVPHandler) renderInvitation(guest *models.Guest) (string, error) {
+	name := h.config.Templates.invitation(guest.Tier, h.language(guest))
+	if guest.Tier != "" && name == TemplateInvitation {
+		fmt.Printf("⚠️ No invitation for tier %q (%s.tmpl), sending %s the standard invitation\n", guest.Tier, tierInvitation(guest.Tier), guest.Name)
+	}
+	return h.render(name, guest, 0)
+}
+
+// render fills in the named message template for a guest, in the guest's language
+func (h *RSVPHandler) render(name string, guest *models.Guest, partySize int) (string, error) {
+	return h.config.Templates.Render(name, h.language(guest), h.messageData(guest, partySize))
+}
+
+// messageData returns the template data for a message to the guest
+func (h *RSVPHandler) messageData(guest *models.Guest, partySize int) MessageData {
+	return MessageData{
+		GuestName:       guest.Name,
+		BrideName:       h.config.BrideName,
+		GroomName:       h.config.GroomName,
+		WeddingDate:     h.weddingDate(h.language(guest)),
+		RSVPLink:        h.rsvpLink(guest),
+		WeddingLocation: h.config.WeddingLocation,
+		PartySize:       partySize,
+		AskPartySize:    h.config.AskPartySize,
+		GiftLink:        h.config.GiftLink,
+	}
+}
+
+// SendDayOfReminders sends a personalized day-of reminder to every accepted guest who didn't ask not to be messaged.
+// Guests with an assigned table get their table number included in the message.
+func (h *RSVPHandler) SendDayOfReminders(progress ProgressFunc) (int, error) {
+	var attending []models.Guest
+	for _, guest := range h.storage.GetGuestsByStatus(models.RSVPAccepted) {
+		if !guest.DoNotContact {
+			attending = append(attending, guest)
+		}
+	}
+
+	sent := 0
+	var errs []error
+	for i, guest := range attending {
+		_, err := h.whatsappService.SendReminder(guest.PhoneNumber, h.dayOfReminderMessage(guest))
+		if err != nil {
+			err = errors.Join(fmt.Errorf("failed to send day-of reminder to %s: %w", guest.PhoneNumber, err), h.recordSendFailure(guest.PhoneNumber, err))
+			errs = append(errs, err)
+		} else {
+			sent++
+		}
+		progress.report(Progress{Done: i + 1, Total: len(attending), Name: guest.Name, PhoneNumber: guest.PhoneNumber, Err: err})
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// dayOfReminderMessage builds the day-of reminder for a single guest
+func (h *RSVPHandler) dayOfReminderMessage(guest models.Guest) string {
+	message := fmt.Sprintf(
+		"💍 Today's the day, %s!\n\n"+
+			"We can't wait to celebrate the wedding of %s & %s with you.\n\n"+
+			"📍 Location: %s\n",
+		guest.Name, h.config.BrideName, h.config.GroomName, h.config.WeddingLocation,
+	)
+
+	// Skip the table line for guests who haven't been seated yet
+	if guest.TableNumber > 0 {
+		message += fmt.Sprintf("🪑 You're at Table %d\n", guest.TableNumber)
+	}
+
+	return message + "\nSee you soon! 💕"
+}
+
+// parsePartySize extracts the number of attending people from a reply, or 0 if none is given
+func parsePartySize(text string) int {
+	match := partySizePattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+
+	size, err := strconv.Atoi(match[2])
+	if err != nil || size <= 0 || size > maxPartySize {
+		return 0
+	}
+
+	// "with 2" / "+2" counts companions, so include the guest themselves
+	if match[1] != "" {
+		size++
+	}
+	return size
+}
+
+// containsAny checks if the text contains any of the given keywords as whole words.
+// Both sides are split on whitespace and punctuation, so "Yes!" matches "yes" but "know" doesn't match "no".
 func containsAny(text string, keywords ...string) bool {
+	padded := " " + strings.Join(tokenize(text), " ") + " "
 	for _, keyword := range keywords {
-		if strings.Contains(text, keyword) {
+		if strings.Contains(padded, " "+strings.Join(tokenize(keyword), " ")+" ") {
 			return true
 		}
 	}
 	return false
 }
+
+// tokenize lowercases text and splits it into words, dropping punctuation
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+}
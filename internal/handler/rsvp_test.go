@@ -0,0 +1,692 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/storage"
+	"wedding-whatsapp/internal/whatsapp"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// sentMessage is a message recorded by fakeSender
+type sentMessage struct {
+	kind string // "message", "invitation", "reminder", "location" or "group"
+	to   string
+	text string
+}
+
+// fakeSender is a MessageSender that records messages instead of sending them
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []sentMessage
+	next int
+
+	// ownNumber is the number IsOwnNumber reports as the bot's own
+	ownNumber string
+	// failures makes sends to a number fail with the given error
+	failures map[string]error
+	// quiet holds invitations and reminders back like quiet hours do, returning no message ID
+	quiet bool
+}
+
+var _ MessageSender = (*fakeSender)(nil)
+
+func newFakeSender() *fakeSender {
+	return &fakeSender{failures: make(map[string]error)}
+}
+
+// record stores a sent message and returns its ID, or the failure set for the number
+func (f *fakeSender) record(kind, to, text string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.failures[to]; err != nil {
+		return "", err
+	}
+	f.sent = append(f.sent, sentMessage{kind: kind, to: to, text: text})
+	f.next++
+	return fmt.Sprintf("MSG%d", f.next), nil
+}
+
+func (f *fakeSender) SendMessage(phoneNumber, message string) (string, error) {
+	return f.record("message", phoneNumber, message)
+}
+
+func (f *fakeSender) SendInvitation(phoneNumber, message string) (string, error) {
+	return f.hold(f.record("invitation", phoneNumber, message))
+}
+
+func (f *fakeSender) SendReminder(phoneNumber, message string) (string, error) {
+	return f.hold(f.record("reminder", phoneNumber, message))
+}
+
+// hold drops the message ID of a send during quiet hours, as the real sender has none until it goes out
+func (f *fakeSender) hold(messageID string, err error) (string, error) {
+	if f.quiet && err == nil {
+		return "", nil
+	}
+	return messageID, err
+}
+
+func (f *fakeSender) SendLocation(phoneNumber string, lat, lng float64, name string) error {
+	_, err := f.record("location", phoneNumber, name)
+	return err
+}
+
+func (f *fakeSender) SendToGroup(groupJID, message string) error {
+	_, err := f.record("group", groupJID, message)
+	return err
+}
+
+func (f *fakeSender) IsOwnNumber(phoneNumber string) bool {
+	return f.ownNumber != "" && phoneNumber == f.ownNumber
+}
+
+func (f *fakeSender) MarkRead(msg *events.Message) error {
+	return nil
+}
+
+// messages returns what was sent to a number, oldest first
+func (f *fakeSender) messages(to string) []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []sentMessage
+	for _, m := range f.sent {
+		if m.to == to {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// last returns the text of the last message sent to a number, failing the test if there's none
+func (f *fakeSender) last(t *testing.T, to string) string {
+	t.Helper()
+	messages := f.messages(to)
+	if len(messages) == 0 {
+		t.Fatalf("nothing was sent to %s", to)
+	}
+	return messages[len(messages)-1].text
+}
+
+// reset forgets the messages sent so far
+func (f *fakeSender) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+}
+
+const (
+	testPhone = "972501234567"
+	testName  = "Dana"
+)
+
+// newTestStorage opens a JSON guest list in a temp dir
+func newTestStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+	guests, err := storage.NewStorage(filepath.Join(t.TempDir(), "guests.json"))
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	return guests
+}
+
+// newTestHandler creates a handler that sends through a fake and stores guests in a temp dir.
+// A nil cfg uses the defaults.
+func newTestHandler(t *testing.T, cfg *Config) (*RSVPHandler, *storage.Storage, *fakeSender) {
+	t.Helper()
+	guests := newTestStorage(t)
+	sender := newFakeSender()
+	return newTestHandlerWith(t, sender, guests, cfg), guests, sender
+}
+
+// newTestHandlerWith creates a handler on an existing sender and store, so a test can restart the bot on the same data
+func newTestHandlerWith(t *testing.T, sender MessageSender, guests storage.Store, cfg *Config) *RSVPHandler {
+	t.Helper()
+	dir := t.TempDir()
+	replyQueue, err := storage.NewReplyQueue(filepath.Join(dir, "confirmation_queue.json"))
+	if err != nil {
+		t.Fatalf("NewReplyQueue: %v", err)
+	}
+	schedule, err := storage.NewBroadcastSchedule(filepath.Join(dir, "broadcast_schedule.json"))
+	if err != nil {
+		t.Fatalf("NewBroadcastSchedule: %v", err)
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.BrideName == "" {
+		cfg.BrideName, cfg.GroomName, cfg.WeddingLocation = "Noa", "Yoni", "Tel Aviv"
+	}
+	return NewRSVPHandler(sender, guests, replyQueue, schedule, cfg)
+}
+
+// addPendingGuest stores a guest who was invited and hasn't answered yet
+func addPendingGuest(t *testing.T, guests storage.Store, phoneNumber, name string) {
+	t.Helper()
+	err := guests.AddGuest(models.Guest{PhoneNumber: phoneNumber, Name: name, RSVPStatus: models.RSVPPending})
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+}
+
+// textMessage is a text message from a number, as it arrives from WhatsApp
+func textMessage(from, text string) *events.Message {
+	sender := types.NewJID(from, types.DefaultUserServer)
+	return &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: sender, Sender: sender},
+			ID:            "IN1",
+		},
+		Message: &waE2E.Message{Conversation: &text},
+	}
+}
+
+// receive handles a text message from a number, failing the test if the handler returns an error
+func receive(t *testing.T, h *RSVPHandler, from, text string) {
+	t.Helper()
+	if err := h.HandleMessage(textMessage(from, text)); err != nil {
+		t.Fatalf("HandleMessage(%q): %v", text, err)
+	}
+}
+
+// guestStatus returns a stored guest's RSVP status
+func guestStatus(t *testing.T, guests storage.Store, phoneNumber string) models.RSVPStatus {
+	t.Helper()
+	guest, err := guests.GetGuest(phoneNumber)
+	if err != nil {
+		t.Fatalf("GetGuest(%s): %v", phoneNumber, err)
+	}
+	return guest.RSVPStatus
+}
+
+func TestHandleMessageAccept(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "Yes, we'll be there!")
+
+	guest, err := guests.GetGuest(testPhone)
+	if err != nil {
+		t.Fatalf("GetGuest: %v", err)
+	}
+	if guest.RSVPStatus != models.RSVPAccepted {
+		t.Errorf("status = %s, want %s", guest.RSVPStatus, models.RSVPAccepted)
+	}
+	if guest.PartySize != 1 {
+		t.Errorf("party size = %d, want 1", guest.PartySize)
+	}
+	if guest.RSVPDate.IsZero() {
+		t.Error("RSVP date wasn't set")
+	}
+	if !guest.ConfirmationDelivered {
+		t.Error("confirmation wasn't marked delivered")
+	}
+	if len(guest.History) != 1 || guest.History[0].NewStatus != models.RSVPAccepted {
+		t.Errorf("history = %+v, want one change to accepted", guest.History)
+	}
+
+	messages := sender.messages(testPhone)
+	if len(messages) == 0 || !strings.Contains(messages[0].text, "Wonderful") {
+		t.Fatalf("messages = %+v, want the accepted confirmation first", messages)
+	}
+	// Accepted guests are asked for their meal choice next
+	if !strings.Contains(sender.last(t, testPhone), "what would you like to eat") {
+		t.Errorf("last message = %q, want the meal question", sender.last(t, testPhone))
+	}
+	if guest.ConversationState != models.StateAwaitingMeal {
+		t.Errorf("conversation state = %s, want %s", guest.ConversationState, models.StateAwaitingMeal)
+	}
+}
+
+func TestHandleMessageDecline(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "Sorry, we can't come")
+
+	guest, err := guests.GetGuest(testPhone)
+	if err != nil {
+		t.Fatalf("GetGuest: %v", err)
+	}
+	if guest.RSVPStatus != models.RSVPDeclined {
+		t.Errorf("status = %s, want %s", guest.RSVPStatus, models.RSVPDeclined)
+	}
+	if guest.PartySize != 0 {
+		t.Errorf("party size = %d, want 0", guest.PartySize)
+	}
+
+	messages := sender.messages(testPhone)
+	if len(messages) != 1 {
+		t.Fatalf("sent %d messages, want just the confirmation: %+v", len(messages), messages)
+	}
+	if !strings.Contains(messages[0].text, "We'll miss you") {
+		t.Errorf("confirmation = %q, want the declined confirmation", messages[0].text)
+	}
+	if guest.ConversationState != models.StateIdle {
+		t.Errorf("conversation state = %s, want %s", guest.ConversationState, models.StateIdle)
+	}
+}
+
+func TestHandleMessageChangeOfPlans(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "yes")
+	sender.reset()
+	receive(t, h, testPhone, "no")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPDeclined {
+		t.Errorf("status = %s, want %s", status, models.RSVPDeclined)
+	}
+	if confirmation := sender.last(t, testPhone); !strings.Contains(confirmation, "Change of plans") {
+		t.Errorf("confirmation = %q, want it to acknowledge the change", confirmation)
+	}
+	history, err := guests.GetHistory(testPhone)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].NewStatus != models.RSVPAccepted || history[1].OldStatus != models.RSVPAccepted {
+		t.Errorf("history = %+v, want accepted then declined", history)
+	}
+}
+
+func TestHandleMessageIgnoresUnclearReply(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "Mazal tov to the happy couple")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPPending {
+		t.Errorf("status = %s, want %s", status, models.RSVPPending)
+	}
+	if messages := sender.messages(testPhone); len(messages) != 0 {
+		t.Errorf("sent %+v, want nothing", messages)
+	}
+}
+
+func TestHandleMessageQueuesFailedConfirmation(t *testing.T) {
+	// Without a base delay the retry is due straight away
+	h, guests, sender := newTestHandler(t, &Config{ConfirmationRetryMaxAttempts: 3})
+	addPendingGuest(t, guests, testPhone, testName)
+	sender.failures[testPhone] = fmt.Errorf("connection lost")
+
+	if err := h.HandleMessage(textMessage(testPhone, "no")); err == nil {
+		t.Fatal("HandleMessage succeeded, want the send failure reported")
+	}
+
+	guest, err := guests.GetGuest(testPhone)
+	if err != nil {
+		t.Fatalf("GetGuest: %v", err)
+	}
+	// The answer is kept even though the guest hasn't heard back yet
+	if guest.RSVPStatus != models.RSVPDeclined {
+		t.Errorf("status = %s, want %s", guest.RSVPStatus, models.RSVPDeclined)
+	}
+	if guest.ConfirmationDelivered {
+		t.Error("confirmation marked delivered, want it waiting for a retry")
+	}
+
+	delete(sender.failures, testPhone)
+	delivered, err := h.RetryConfirmations()
+	if err != nil {
+		t.Fatalf("RetryConfirmations: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("delivered %d confirmations, want 1", delivered)
+	}
+	if confirmation := sender.last(t, testPhone); !strings.Contains(confirmation, "We'll miss you") {
+		t.Errorf("retried confirmation = %q, want the declined confirmation", confirmation)
+	}
+	if guest, _ := guests.GetGuest(testPhone); !guest.ConfirmationDelivered {
+		t.Error("confirmation wasn't marked delivered after the retry")
+	}
+}
+
+func TestHandleMessageDropsStaleQueuedConfirmation(t *testing.T) {
+	h, guests, sender := newTestHandler(t, &Config{ConfirmationRetryMaxAttempts: 3})
+	addPendingGuest(t, guests, testPhone, testName)
+	sender.failures[testPhone] = fmt.Errorf("connection lost")
+
+	if err := h.HandleMessage(textMessage(testPhone, "no")); err == nil {
+		t.Fatal("HandleMessage succeeded, want the send failure reported")
+	}
+
+	// The guest changes their mind once sending works again
+	delete(sender.failures, testPhone)
+	receive(t, h, testPhone, "yes")
+	sender.reset()
+
+	delivered, err := h.RetryConfirmations()
+	if err != nil {
+		t.Fatalf("RetryConfirmations: %v", err)
+	}
+	if delivered != 0 {
+		t.Errorf("delivered %d confirmations, want 0", delivered)
+	}
+	if messages := sender.messages(testPhone); len(messages) != 0 {
+		t.Errorf("sent %+v, want the stale declined confirmation dropped", messages)
+	}
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPAccepted {
+		t.Errorf("status = %s, want %s", status, models.RSVPAccepted)
+	}
+}
+
+func TestHandleMessageConcurrentGuests(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	const count = 20
+	phoneNumber := func(i int) string { return fmt.Sprintf("9725012345%02d", i) }
+	for i := range count {
+		addPendingGuest(t, guests, phoneNumber(i), fmt.Sprintf("Guest %d", i))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, count)
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply := "yes"
+			if i%2 == 1 {
+				reply = "no"
+			}
+			if err := h.HandleMessage(textMessage(phoneNumber(i), reply)); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("HandleMessage: %v", err)
+	}
+
+	// Every answer is kept, none overwritten by another guest's
+	for i := range count {
+		want := models.RSVPAccepted
+		if i%2 == 1 {
+			want = models.RSVPDeclined
+		}
+		if status := guestStatus(t, guests, phoneNumber(i)); status != want {
+			t.Errorf("guest %d status = %s, want %s", i, status, want)
+		}
+		if len(sender.messages(phoneNumber(i))) == 0 {
+			t.Errorf("guest %d got no confirmation", i)
+		}
+	}
+	if stats := guests.Stats(); stats.Accepted != count/2 || stats.Declined != count/2 {
+		t.Errorf("stats = %+v, want %d accepted and %d declined", stats, count/2, count/2)
+	}
+}
+
+func TestHandleMessageAfterDeadline(t *testing.T) {
+	h, guests, sender := newTestHandler(t, &Config{RSVPDeadline: time.Now().Add(-time.Hour)})
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "yes")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPPending {
+		t.Errorf("status = %s, want it left as %s", status, models.RSVPPending)
+	}
+	if reply := sender.last(t, testPhone); !strings.Contains(reply, "RSVPs for the wedding of Noa & Yoni are now closed") {
+		t.Errorf("reply = %q, want the closed message", reply)
+	}
+}
+
+func TestHandleMessageGuestDeadlineOverridesConfig(t *testing.T) {
+	h, guests, _ := newTestHandler(t, &Config{RSVPDeadline: time.Now().Add(-time.Hour)})
+	err := guests.AddGuest(models.Guest{
+		PhoneNumber:  testPhone,
+		Name:         testName,
+		RSVPStatus:   models.RSVPPending,
+		RSVPDeadline: time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+
+	receive(t, h, testPhone, "yes")
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPAccepted {
+		t.Errorf("status = %s, want %s before the guest's own deadline", status, models.RSVPAccepted)
+	}
+}
+
+func TestHandleMessageExtendedText(t *testing.T) {
+	h, guests, _ := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+
+	// Replies quoting a message, or with a link preview, arrive as extended text
+	msg := textMessage(testPhone, "")
+	text := "Sorry, we can't come"
+	msg.Message = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: &text}}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage: %v", err)
+	}
+
+	if status := guestStatus(t, guests, testPhone); status != models.RSVPDeclined {
+		t.Errorf("status = %s, want %s", status, models.RSVPDeclined)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Yes, we'll be there", models.LanguageEnglish},
+		{"כן, נגיע", models.LanguageHebrew},
+		{"OK אנחנו מגיעים", models.LanguageHebrew},
+		{"👍", ""},
+		{"2", ""},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHandleMessageRepliesInGuestLanguage(t *testing.T) {
+	tests := []struct {
+		reply, language, confirmation string
+	}{
+		{"no", models.LanguageEnglish, "We'll miss you"},
+		{"לא", models.LanguageHebrew, "נתגעגע"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			h, guests, sender := newTestHandler(t, nil)
+			addPendingGuest(t, guests, testPhone, testName)
+
+			receive(t, h, testPhone, tt.reply)
+
+			if confirmation := sender.last(t, testPhone); !strings.Contains(confirmation, tt.confirmation) {
+				t.Errorf("confirmation = %q, want it in %s", confirmation, tt.language)
+			}
+			guest, err := guests.GetGuest(testPhone)
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+			if guest.Language != tt.language {
+				t.Errorf("language = %q, want %q", guest.Language, tt.language)
+			}
+		})
+	}
+}
+
+func TestFollowUpQuestionsInGuestLanguage(t *testing.T) {
+	h, guests, sender := newTestHandler(t, &Config{AskPartySize: true})
+	addPendingGuest(t, guests, testPhone, testName)
+
+	steps := []struct {
+		reply string
+		want  []string
+	}{
+		{"כן", []string{"כמה תהיו"}},
+		{"עוד לא יודעים", []string{"סליחה, לא הבנו"}},
+		{"3", []string{"רשמנו אתכם ל-3 אורחים", "מה תרצו לאכול"}},
+		{"דגים", []string{"רשמנו לך *דגים*"}},
+	}
+	for _, step := range steps {
+		sender.reset()
+		receive(t, h, testPhone, step.reply)
+
+		var texts []string
+		for _, m := range sender.messages(testPhone) {
+			texts = append(texts, m.text)
+		}
+		for _, want := range step.want {
+			if !strings.Contains(strings.Join(texts, "\n"), want) {
+				t.Errorf("replies to %q = %q, want one with %q", step.reply, texts, want)
+			}
+		}
+	}
+}
+
+func TestInvitationSwitchesToGuestLanguage(t *testing.T) {
+	h, _, sender := newTestHandler(t, &Config{PrimaryLanguage: models.LanguageEnglish})
+
+	if err := h.SendInvitation(testPhone, testName, "", "", "", false); err != nil {
+		t.Fatalf("SendInvitation: %v", err)
+	}
+	if invitation := sender.last(t, testPhone); !strings.Contains(invitation, "Wedding Invitation") {
+		t.Errorf("first invitation = %q, want it in the primary language", invitation)
+	}
+
+	receive(t, h, testPhone, "אולי")
+	if err := h.ResendInvitation(testPhone); err != nil {
+		t.Fatalf("ResendInvitation: %v", err)
+	}
+	if invitation := sender.last(t, testPhone); !strings.Contains(invitation, "הזמנה לחתונה") {
+		t.Errorf("resent invitation = %q, want it in Hebrew", invitation)
+	}
+}
+
+func TestConversationResumesAfterRestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "guests.json")
+	guests, err := storage.NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	sender := newFakeSender()
+	h := newTestHandlerWith(t, sender, guests, &Config{AskPartySize: true})
+	addPendingGuest(t, guests, testPhone, testName)
+
+	receive(t, h, testPhone, "yes")
+	if !strings.Contains(sender.last(t, testPhone), "How many of you will attend") {
+		t.Fatalf("last message = %q, want the party size question", sender.last(t, testPhone))
+	}
+
+	// Restart the bot on the same guest list
+	reloaded, err := storage.NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage after restart: %v", err)
+	}
+	h = newTestHandlerWith(t, sender, reloaded, &Config{AskPartySize: true})
+
+	open := h.OpenConversations()
+	if open[models.StateAwaitingPartySize] != 1 {
+		t.Errorf("open conversations after restart = %v, want the party size question pending", open)
+	}
+
+	receive(t, h, testPhone, "3")
+	guest, err := reloaded.GetGuest(testPhone)
+	if err != nil {
+		t.Fatalf("GetGuest: %v", err)
+	}
+	if guest.PartySize != 3 {
+		t.Errorf("party size = %d, want the answer given after the restart", guest.PartySize)
+	}
+	if guest.ConversationState == models.StateAwaitingPartySize {
+		t.Error("still waiting for the party size after it was answered")
+	}
+}
+
+func TestSendInvitationRejectsInvalidPhone(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+
+	for _, number := range []string{"", "abc", "123", "12345678901234567890"} {
+		if err := h.SendInvitation(number, testName, "", "", "", false); !errors.Is(err, storage.ErrInvalidPhone) {
+			t.Errorf("SendInvitation(%q) = %v, want ErrInvalidPhone", number, err)
+		}
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("sent %+v, want nothing", sender.sent)
+	}
+	if got := len(guests.GetAllGuests()); got != 0 {
+		t.Errorf("stored %d guests, want none", got)
+	}
+}
+
+func TestHandleMessageGiftLink(t *testing.T) {
+	const link = "https://registry.example.com/noa-yoni"
+	tests := []struct {
+		reply    string
+		wantLink bool
+	}{
+		{"yes", true},
+		{"no", false},
+		{"maybe", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			h, guests, sender := newTestHandler(t, &Config{GiftLink: link})
+			addPendingGuest(t, guests, testPhone, testName)
+
+			receive(t, h, testPhone, tt.reply)
+
+			confirmation := sender.messages(testPhone)[0].text
+			if strings.Contains(confirmation, link) != tt.wantLink {
+				t.Errorf("confirmation = %q, want the gift link: %v", confirmation, tt.wantLink)
+			}
+		})
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		h, guests, sender := newTestHandler(t, nil)
+		addPendingGuest(t, guests, testPhone, testName)
+
+		receive(t, h, testPhone, "yes")
+
+		if confirmation := sender.messages(testPhone)[0].text; strings.Contains(confirmation, "registry") {
+			t.Errorf("confirmation = %q, want no gift message without a link", confirmation)
+		}
+	})
+}
+
+func TestHandleDeferredSentRecordsFailure(t *testing.T) {
+	h, guests, sender := newTestHandler(t, nil)
+	addPendingGuest(t, guests, testPhone, testName)
+	sender.quiet = true
+	if _, err := h.whatsappService.SendReminder(testPhone, "Don't forget to RSVP"); err != nil {
+		t.Fatalf("SendReminder: %v", err)
+	}
+
+	// Quiet hours end and the held back reminder can't be delivered
+	sendErr := fmt.Errorf("%w: not on WhatsApp", whatsapp.ErrUnreachable)
+	if err := h.HandleDeferredSent(testPhone, "", sendErr); err != nil {
+		t.Fatalf("HandleDeferredSent: %v", err)
+	}
+
+	guest, err := guests.GetGuest(testPhone)
+	if err != nil {
+		t.Fatalf("GetGuest: %v", err)
+	}
+	if !guest.Unreachable {
+		t.Error("guest not marked unreachable")
+	}
+	if failed, ok := guest.LastFailedSend(); !ok || !strings.Contains(failed.Error, "not on WhatsApp") {
+		t.Errorf("send attempts = %+v, want the deferred failure recorded", guest.SendAttempts)
+	}
+}
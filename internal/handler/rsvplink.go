@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// rsvpTokenBytes is the amount of randomness in a web RSVP token, enough that it can't be guessed
+const rsvpTokenBytes = 16
+
+var (
+	// ErrInvalidToken is returned for a web RSVP token that doesn't belong to any guest
+	ErrInvalidToken = errors.New("invalid RSVP link")
+	// ErrRSVPClosed is returned for a web RSVP after the guest's deadline
+	ErrRSVPClosed = errors.New("RSVPs are closed")
+)
+
+// newRSVPToken returns a random URL-safe token for a guest's web RSVP link
+func newRSVPToken() (string, error) {
+	b := make([]byte, rsvpTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate RSVP token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ensureRSVPToken gives the guest a web RSVP token if they don't have one yet
+func (h *RSVPHandler) ensureRSVPToken(guest *models.Guest) error {
+	if guest.RSVPToken != "" {
+		return nil
+	}
+
+	token, err := newRSVPToken()
+	if err != nil {
+		return err
+	}
+	if err := h.storage.SetRSVPToken(guest.PhoneNumber, token); err != nil {
+		return fmt.Errorf("failed to save RSVP token: %w", err)
+	}
+	guest.RSVPToken = token
+	return nil
+}
+
+// rsvpLink returns the guest's web RSVP link, or "" when RSVPBaseURL isn't configured
+func (h *RSVPHandler) rsvpLink(guest *models.Guest) string {
+	if h.config.RSVPBaseURL == "" || guest.RSVPToken == "" {
+		return ""
+	}
+	return strings.TrimRight(h.config.RSVPBaseURL, "/") + "/rsvp/" + guest.RSVPToken
+}
+
+// RespondByToken records an RSVP made through the guest's web link.
+// Tokens stop working once the guest's RSVP deadline has passed.
+func (h *RSVPHandler) RespondByToken(token string, status models.RSVPStatus) (*models.Guest, error) {
+	guest, err := h.storage.GetGuestByToken(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if h.rsvpClosed(guest, time.Now()) || (isChange(guest, status) && h.changesLocked(time.Now())) {
+		return nil, ErrRSVPClosed
+	}
+
+	if err := h.storage.UpdateRSVP(guest.PhoneNumber, status, "", "web link: "+string(status)); err != nil {
+		return nil, fmt.Errorf("failed to update RSVP: %w", err)
+	}
+	h.webhook.notifyRSVP(guest, status)
+	h.config.Metrics.RSVP(status)
+
+	// Keep a head count given earlier, otherwise an accepted guest counts as one
+	partySize := 0
+	if status == models.RSVPAccepted {
+		partySize = max(guest.PartySize, 1)
+	}
+	if err := h.storage.UpdatePartySize(guest.PhoneNumber, partySize); err != nil {
+		return nil, fmt.Errorf("failed to update party size: %w", err)
+	}
+
+	return h.storage.GetGuest(guest.PhoneNumber)
+}
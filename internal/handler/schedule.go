@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// scheduleCheckInterval is how often scheduled broadcasts are checked for being due
+const scheduleCheckInterval = 30 * time.Second
+
+// ScheduleBroadcast schedules message to be broadcast to every guest with the given status at the given time,
+// as BroadcastToStatus would. The guests are picked when it's sent, not now.
+func (h *RSVPHandler) ScheduleBroadcast(at time.Time, status models.RSVPStatus, message string) (models.ScheduledBroadcast, error) {
+	if _, err := parseBroadcast(message); err != nil {
+		return models.ScheduledBroadcast{}, err
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return models.ScheduledBroadcast{}, fmt.Errorf("failed to generate broadcast ID: %w", err)
+	}
+	broadcast := models.ScheduledBroadcast{
+		ID:        hex.EncodeToString(id),
+		SendAt:    at,
+		Status:    status,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+	if err := h.schedule.Add(broadcast); err != nil {
+		return models.ScheduledBroadcast{}, fmt.Errorf("failed to schedule broadcast: %w", err)
+	}
+	return broadcast, nil
+}
+
+// ScheduledBroadcasts returns the broadcasts waiting to be sent, earliest first
+func (h *RSVPHandler) ScheduledBroadcasts() []models.ScheduledBroadcast {
+	return h.schedule.Pending()
+}
+
+// CancelScheduledBroadcast drops a scheduled broadcast before it's sent
+func (h *RSVPHandler) CancelScheduledBroadcast(id string) error {
+	removed, err := h.schedule.Remove(id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel broadcast: %w", err)
+	}
+	if !removed {
+		return fmt.Errorf("scheduled broadcast %s not found", id)
+	}
+	return nil
+}
+
+// RunScheduledBroadcasts sends scheduled broadcasts as they fall due, until stop is closed.
+// Broadcasts that came due while the bot wasn't running are sent straight away, with a warning.
+func (h *RSVPHandler) RunScheduledBroadcasts(stop <-chan struct{}) {
+	for _, broadcast := range h.schedule.Due(time.Now()) {
+		fmt.Printf("⚠️ Scheduled broadcast %s to %s guests was due at %s while the bot wasn't running, sending it now\n",
+			broadcast.ID, broadcast.Status, broadcast.SendAt.Format("2006-01-02 15:04"))
+		h.sendScheduledBroadcast(broadcast)
+	}
+
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			for _, broadcast := range h.schedule.Due(now) {
+				h.sendScheduledBroadcast(broadcast)
+			}
+		}
+	}
+}
+
+// sendScheduledBroadcast sends a due broadcast and logs the outcome. It's taken off the schedule first,
+// so a restart part way through doesn't send it to the same guests twice.
+func (h *RSVPHandler) sendScheduledBroadcast(broadcast models.ScheduledBroadcast) {
+	if _, err := h.schedule.Remove(broadcast.ID); err != nil {
+		fmt.Printf("⚠️ Not sending scheduled broadcast %s, it couldn't be taken off the schedule: %v\n", broadcast.ID, err)
+		return
+	}
+
+	results, err := h.BroadcastToStatus(broadcast.Status, broadcast.Message, nil)
+	if err != nil {
+		fmt.Printf("❌ Scheduled broadcast %s failed: %v\n", broadcast.ID, err)
+		return
+	}
+
+	sent, deferred, failed, skipped := 0, 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			skipped++
+		case result.Deferred:
+			deferred++
+		case result.Err != nil:
+			failed++
+			fmt.Printf("⚠️ Scheduled broadcast %s to %s failed: %v\n", broadcast.ID, result.Name, result.Err)
+		default:
+			sent++
+		}
+	}
+	fmt.Printf("📣 Scheduled broadcast %s to %s guests: %d sent, %d held back for quiet hours, %d failed, %d skipped\n",
+		broadcast.ID, broadcast.Status, sent, deferred, failed, skipped)
+}
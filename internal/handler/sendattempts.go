@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+	"wedding-whatsapp/internal/storage"
+)
+
+// attemptRecorder is a MessageSender that records every text message sent to a guest,
+// and whether it got through, in the guest's send attempts
+type attemptRecorder struct {
+	MessageSender
+	storage storage.Store
+}
+
+var _ MessageSender = (*attemptRecorder)(nil)
+
+// SendMessage sends a message and records the attempt
+func (r *attemptRecorder) SendMessage(phoneNumber, message string) (string, error) {
+	messageID, err := r.MessageSender.SendMessage(phoneNumber, message)
+	r.record(phoneNumber, err)
+	return messageID, err
+}
+
+// SendInvitation sends an invitation and records the attempt
+func (r *attemptRecorder) SendInvitation(phoneNumber, message string) (string, error) {
+	messageID, err := r.MessageSender.SendInvitation(phoneNumber, message)
+	r.record(phoneNumber, err)
+	return messageID, err
+}
+
+// SendReminder sends a reminder and records the attempt
+func (r *attemptRecorder) SendReminder(phoneNumber, message string) (string, error) {
+	messageID, err := r.MessageSender.SendReminder(phoneNumber, message)
+	r.record(phoneNumber, err)
+	return messageID, err
+}
+
+// record adds a send attempt to the guest's trail. Numbers that aren't on the guest list,
+// like the organizer's daily digest, aren't recorded.
+// A message held back for quiet hours counts as sent once it's been queued.
+func (r *attemptRecorder) record(phoneNumber string, sendErr error) {
+	if _, err := r.storage.GetGuest(phoneNumber); err != nil {
+		return
+	}
+
+	attempt := models.SendAttempt{Timestamp: time.Now(), Success: sendErr == nil}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := r.storage.RecordSendAttempt(phoneNumber, attempt); err != nil {
+		fmt.Printf("⚠️ Failed to record the send attempt to %s: %v\n", phone.Format(phoneNumber), err)
+	}
+}
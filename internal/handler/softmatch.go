@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+)
+
+// A reply from an unknown number is matched to a guest whose number ends with the same digits,
+// trying the longest suffix first. Shorter suffixes would match unrelated numbers too easily.
+const (
+	softMatchMaxDigits = 9
+	softMatchMinDigits = 7
+)
+
+// softMatchGuest looks for the one guest whose number ends like phoneNumber, for guests replying from
+// a number written with a different country code or a second SIM. The number is added to the guest's
+// alternate numbers so their replies are recorded. Nothing is matched when more than one guest could be meant.
+func (h *RSVPHandler) softMatchGuest(phoneNumber string) (*models.Guest, bool) {
+	for digits := min(softMatchMaxDigits, len(phoneNumber)-1); digits >= softMatchMinDigits; digits-- {
+		candidates := h.storage.FindGuestsByPhoneSuffix(phoneNumber[len(phoneNumber)-digits:])
+		if len(candidates) > 1 {
+			fmt.Printf("⚠️ %s matches %d guests by its last %d digits, not guessing which one\n", phone.Format(phoneNumber), len(candidates), digits)
+			return nil, false
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		guest := candidates[0]
+		if !slices.Contains(guest.AlternatePhones, phoneNumber) {
+			guest.AlternatePhones = append(guest.AlternatePhones, phoneNumber)
+			if err := h.storage.UpdateGuest(guest.PhoneNumber, guest); err != nil {
+				fmt.Printf("Failed to add %s to %s's numbers: %v\n", phone.Format(phoneNumber), guest.Name, err)
+				return nil, false
+			}
+		}
+		fmt.Printf("🔗 Matched %s to %s (%s) by the last %d digits and added it to their numbers, please verify\n",
+			phone.Format(phoneNumber), guest.Name, guest.DisplayPhone(), digits)
+		return &guest, true
+	}
+	return nil, false
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// Names of the message templates that can be overridden from the templates directory
+const (
+	TemplateInvitation = "invitation"
+	TemplateAccepted   = "accepted"
+	TemplateDeclined   = "declined"
+	TemplateMaybe      = "maybe"
+	TemplateClosed     = "closed"
+	TemplateReminder   = "reminder"
+	TemplateChanged    = "changed"
+	TemplateGift       = "gift"
+	TemplateOptOut     = "optout"
+	TemplateThanks     = "thanks"
+
+	TemplatePartySize        = "partysize"
+	TemplatePartySizeRetry   = "partysize-retry"
+	TemplatePartySizeNoted   = "partysize-noted"
+	TemplatePartySizeAssumed = "partysize-assumed"
+	TemplateMeal             = "meal"
+	TemplateMealNoted        = "meal-noted"
+)
+
+// defaultTemplates is the built-in wording used when the templates directory has no file for a message
+var defaultTemplates = map[string]string{
+	TemplateInvitation: "🎉 *Wedding Invitation*\n\n" +
+		"Dear {{.GuestName}},\n\n" +
+		"You are cordially invited to celebrate the wedding of\n\n" +
+		"*{{.BrideName}}* & *{{.GroomName}}*\n\n" +
+		"📅 Date: {{.WeddingDate}}\n" +
+		"📍 Location: {{.WeddingLocation}}\n\n" +
+		"Please confirm your attendance by selecting one of the options below." +
+		"{{if .AskPartySize}} How many of you will attend?{{end}}" +
+		"{{if .RSVPLink}}\n\nOr RSVP online:\n✅ {{.RSVPLink}}?answer=yes\n❌ {{.RSVPLink}}?answer=no{{end}}",
+	TemplateAccepted: "🎉 Wonderful! We're so excited to celebrate with you!\n\n" +
+		"We've confirmed your attendance for the wedding of {{.BrideName}} & {{.GroomName}} on {{.WeddingDate}} (party of {{.PartySize}}).\n\n" +
+		"See you there! 💕",
+	TemplateDeclined: "Thank you for letting us know. We're sorry you won't be able to join us for the wedding of {{.BrideName}} & {{.GroomName}}.\n\n" +
+		"We'll miss you! 💕",
+	TemplateMaybe: "Thanks for letting us know! We understand you're not sure yet.\n\n" +
+		"Please confirm closer to the wedding of {{.BrideName}} & {{.GroomName}} on {{.WeddingDate}} by replying *YES* or *NO*. 💕",
+	TemplateClosed: "Thank you for your reply! RSVPs for the wedding of {{.BrideName}} & {{.GroomName}} are now closed, " +
+		"so we couldn't update your response.\n\n" +
+		"Please contact us directly if anything has changed. 💕",
+	TemplateReminder: "👋 Hi {{.GuestName}},\n\n" +
+		"Just a gentle reminder about the wedding of *{{.BrideName}}* & *{{.GroomName}}* on {{.WeddingDate}}.\n\n" +
+		"We'd love to know if you can make it!\n\n" +
+		"Reply with:\n✅ *YES* to accept\n❌ *NO* to decline" +
+		"{{if .RSVPLink}}\n\nOr RSVP online:\n✅ {{.RSVPLink}}?answer=yes\n❌ {{.RSVPLink}}?answer=no{{end}}",
+	TemplateChanged: "🔄 Change of plans noted! We've updated your RSVP from *{{.PreviousStatus}}* to *{{.Status}}*.",
+	TemplateGift:    "🎁 Your presence is the best gift, but if you'd like to give something more, you'll find our registry here: {{.GiftLink}}",
+	TemplateOptOut: "👍 Got it, we won't send you any more messages about the wedding of {{.BrideName}} & {{.GroomName}}.\n\n" +
+		"If you'd still like to RSVP, you can reply *YES* or *NO* here at any time.",
+	TemplateThanks: "💕 Thank you so much for your message, {{.GuestName}}!\n\n" +
+		"The wedding of {{.BrideName}} & {{.GroomName}} is behind us, and we're so grateful for all the love. " +
+		"We'll read every message ourselves.",
+	TemplatePartySize:        "👥 How many of you will attend, including yourself? Reply with a number, e.g. *2*.",
+	TemplatePartySizeRetry:   "Sorry, I didn't catch that. How many people are coming in total? Please reply with a number, e.g. *2*.",
+	TemplatePartySizeNoted:   "👍 Got it - we've put you down as a party of {{.PartySize}}. Thank you!",
+	TemplatePartySizeAssumed: "No problem - we've put you down as 1 guest. If more of you are coming, reply e.g. *yes, 3*.",
+	TemplateMeal: "🍽️ One more thing - what would you like to eat?\n\n" +
+		"Reply with *MEAT*, *FISH*, *VEGETARIAN* or *VEGAN*.",
+	TemplateMealNoted: "👍 Got it - we've noted *{{.Meal}}* for you. Thank you!",
+}
+
+// defaultHebrewTemplates is the built-in Hebrew wording, used for guests who write in Hebrew
+var defaultHebrewTemplates = map[string]string{
+	TemplateInvitation: "🎉 *הזמנה לחתונה*\n\n" +
+		"{{.GuestName}} היקר/ה,\n\n" +
+		"נשמח לראותך בחתונתם של\n\n" +
+		"*{{.BrideName}}* ו*{{.GroomName}}*\n\n" +
+		"📅 תאריך: {{.WeddingDate}}\n" +
+		"📍 מקום: {{.WeddingLocation}}\n\n" +
+		"נא אשרו את הגעתכם בבחירת אחת מהאפשרויות למטה." +
+		"{{if .AskPartySize}} כמה תהיו?{{end}}" +
+		"{{if .RSVPLink}}\n\nאפשר גם לאשר באתר:\n✅ {{.RSVPLink}}?answer=yes\n❌ {{.RSVPLink}}?answer=no{{end}}",
+	TemplateAccepted: "🎉 נפלא! אנחנו כל כך שמחים לחגוג איתך!\n\n" +
+		"אישרנו את הגעתך לחתונה של {{.BrideName}} ו{{.GroomName}} ב{{.WeddingDate}} ({{.PartySize}} אורחים).\n\n" +
+		"נתראה שם! 💕",
+	TemplateDeclined: "תודה שעדכנת אותנו. חבל שלא תוכל/י להגיע לחתונה של {{.BrideName}} ו{{.GroomName}}.\n\n" +
+		"נתגעגע! 💕",
+	TemplateMaybe: "תודה על העדכון! אנחנו מבינים שעוד לא בטוח.\n\n" +
+		"נא אשרו לקראת החתונה של {{.BrideName}} ו{{.GroomName}} (📅 {{.WeddingDate}}) בתשובה *כן* או *לא*. 💕",
+	TemplateClosed: "תודה על התשובה! אישורי ההגעה לחתונה של {{.BrideName}} ו{{.GroomName}} כבר נסגרו, " +
+		"ולכן לא יכולנו לעדכן את תשובתך.\n\n" +
+		"אם משהו השתנה, נא צרו איתנו קשר ישירות. 💕",
+	TemplateReminder: "👋 היי {{.GuestName}},\n\n" +
+		"רק תזכורת קטנה לגבי החתונה של *{{.BrideName}}* ו*{{.GroomName}}* (📅 {{.WeddingDate}}).\n\n" +
+		"נשמח לדעת אם תגיעו!\n\n" +
+		"השיבו:\n✅ *כן* לאישור\n❌ *לא* אם לא תוכלו להגיע" +
+		"{{if .RSVPLink}}\n\nאפשר גם לאשר באתר:\n✅ {{.RSVPLink}}?answer=yes\n❌ {{.RSVPLink}}?answer=no{{end}}",
+	TemplateChanged: "🔄 קיבלנו את השינוי! עדכנו את אישור ההגעה שלך מ*{{.PreviousStatus}}* ל*{{.Status}}*.",
+	TemplateGift:    "🎁 הנוכחות שלכם היא המתנה הכי טובה, אבל אם תרצו לפנק אותנו, הנה הקישור: {{.GiftLink}}",
+	TemplateOptOut: "👍 הבנו, לא נשלח לך יותר הודעות לגבי החתונה של {{.BrideName}} ו{{.GroomName}}.\n\n" +
+		"אם תרצו בכל זאת לאשר הגעה, אפשר להשיב כאן *כן* או *לא* בכל זמן.",
+	TemplateThanks: "💕 תודה רבה על ההודעה, {{.GuestName}}!\n\n" +
+		"החתונה של {{.BrideName}} ו{{.GroomName}} כבר מאחורינו, ואנחנו אסירי תודה על כל האהבה. " +
+		"נקרא כל הודעה בעצמנו.",
+	TemplatePartySize:        "👥 כמה תהיו, כולל אותך? השיבו במספר, למשל *2*.",
+	TemplatePartySizeRetry:   "סליחה, לא הבנו. כמה תהיו בסך הכול? נא להשיב במספר, למשל *2*.",
+	TemplatePartySizeNoted:   "👍 קיבלנו - רשמנו אתכם ל-{{.PartySize}} אורחים. תודה!",
+	TemplatePartySizeAssumed: "אין בעיה - רשמנו אורח/ת אחד/ת. אם תגיעו יותר, השיבו למשל *כן, 3*.",
+	TemplateMeal: "🍽️ עוד דבר אחד - מה תרצו לאכול?\n\n" +
+		"השיבו *בשר*, *דגים*, *צמחוני* או *טבעוני*.",
+	TemplateMealNoted: "👍 קיבלנו - רשמנו לך *{{.Meal}}*. תודה!",
+}
+
+// MessageData is the data available to message templates
+type MessageData struct {
+	GuestName       string
+	BrideName       string
+	GroomName       string
+	WeddingDate     string
+	WeddingLocation string
+	PartySize       int
+	RSVPLink        string // the guest's web RSVP link, empty when not configured
+	AskPartySize    bool   // the invitation should ask how many people are coming
+	GiftLink        string // the gift registry link, empty when not configured
+
+	// PreviousStatus and Status describe a guest's old and new answer in changed.tmpl
+	PreviousStatus string
+	Status         string
+
+	// Meal is the guest's meal choice in meal-noted.tmpl, in the guest's language
+	Meal string
+}
+
+// Templates renders the messages sent to guests
+type Templates struct {
+	tmpl *template.Template
+}
+
+// LoadTemplates parses the message templates, reading <name>.tmpl from dir where present.
+// Hebrew wording is read from <name>.he.tmpl. Messages without a file, or every message
+// when dir is empty, use the built-in wording. Invitations for guest tiers are read from
+// invitation-<tier>.tmpl and invitation-<tier>.he.tmpl.
+func LoadTemplates(dir string) (*Templates, error) {
+	root := template.New("messages")
+
+	for name, text := range defaultTemplates {
+		if err := parseTemplate(root, dir, name, text); err != nil {
+			return nil, err
+		}
+	}
+	for name, text := range defaultHebrewTemplates {
+		if err := parseTemplate(root, dir, name+".he", text); err != nil {
+			return nil, err
+		}
+	}
+	if err := parseTierTemplates(root, dir); err != nil {
+		return nil, err
+	}
+
+	return &Templates{tmpl: root}, nil
+}
+
+// parseTierTemplates adds the tier invitations found in dir to root
+func parseTierTemplates(root *template.Template, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, tierInvitation("*")+".tmpl"))
+	if err != nil {
+		return fmt.Errorf("failed to list tier invitations: %w", err)
+	}
+	for _, path := range paths {
+		if err := parseTemplate(root, dir, strings.TrimSuffix(filepath.Base(path), ".tmpl"), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tierInvitation returns the name of the invitation template for a guest tier
+func tierInvitation(tier string) string {
+	return TemplateInvitation + "-" + tier
+}
+
+// Tiers returns the guest tiers that have their own invitation, sorted by name
+func (t *Templates) Tiers() []string {
+	var tiers []string
+	for _, tmpl := range t.tmpl.Templates() {
+		name, _, _ := strings.Cut(tmpl.Name(), ".")
+		if tier, ok := strings.CutPrefix(name, tierInvitation("")); ok && !slices.Contains(tiers, tier) {
+			tiers = append(tiers, tier)
+		}
+	}
+	slices.Sort(tiers)
+	return tiers
+}
+
+// invitation returns the name of the invitation template for a guest tier: the tier's own
+// invitation in language or English when there is one, or the standard invitation otherwise
+func (t *Templates) invitation(tier, language string) string {
+	if tier == "" {
+		return TemplateInvitation
+	}
+	name := tierInvitation(tier)
+	if t.tmpl.Lookup(name) != nil || t.tmpl.Lookup(name+"."+language) != nil {
+		return name
+	}
+	return TemplateInvitation
+}
+
+// parseTemplate adds the named template to root, from <name>.tmpl in dir if it exists or from text otherwise
+func parseTemplate(root *template.Template, dir, name, text string) error {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+		if err == nil {
+			// Editors usually end files with a newline that isn't part of the message
+			text = strings.TrimRight(string(data), "\r\n")
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read %s template: %w", name, err)
+		}
+	}
+
+	if _, err := root.New(name).Parse(text); err != nil {
+		return fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return nil
+}
+
+// Override replaces the wording of the named template, in every language, with text
+func (t *Templates) Override(name, text string) error {
+	for _, tmpl := range t.tmpl.Templates() {
+		if tmpl.Name() != name && !strings.HasPrefix(tmpl.Name(), name+".") {
+			continue
+		}
+		if _, err := t.tmpl.New(tmpl.Name()).Parse(text); err != nil {
+			return fmt.Errorf("failed to parse %s template: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Render executes the named template with data, using its wording in language when there is one
+func (t *Templates) Render(name, language string, data MessageData) (string, error) {
+	if language != "" && language != models.LanguageEnglish && t.tmpl.Lookup(name+"."+language) != nil {
+		name += "." + language
+	}
+
+	var b strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s message: %w", name, err)
+	}
+	return b.String(), nil
+}
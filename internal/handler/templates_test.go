@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// tierTemplates loads templates from a temp dir with formal and casual invitations
+func tierTemplates(t *testing.T) *Templates {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"invitation-formal.tmpl":    "Dear {{.GuestName}}, you are formally invited.\n",
+		"invitation-formal.he.tmpl": "{{.GuestName}} היקר/ה, הנך מוזמן/ת באופן רשמי.\n",
+		"invitation-casual.tmpl":    "Hey {{.GuestName}}, come party with us!\n",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	templates, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	return templates
+}
+
+func TestTemplatesTiers(t *testing.T) {
+	if tiers := tierTemplates(t).Tiers(); !slices.Equal(tiers, []string{"casual", "formal"}) {
+		t.Errorf("Tiers() = %q, want casual and formal", tiers)
+	}
+}
+
+func TestSendInvitationRendersTierTemplate(t *testing.T) {
+	tests := []struct {
+		tier, want string
+	}{
+		{"formal", "Dear Dana, you are formally invited."},
+		{" Formal ", "Dear Dana, you are formally invited."},
+		{"casual", "Hey Dana, come party with us!"},
+		{"", "Wedding Invitation"},
+		{"unknown", "Wedding Invitation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tier, func(t *testing.T) {
+			h, _, sender := newTestHandler(t, &Config{Templates: tierTemplates(t)})
+
+			if err := h.SendInvitation(testPhone, testName, "", "", tt.tier, false); err != nil {
+				t.Fatalf("SendInvitation: %v", err)
+			}
+			if invitation := sender.last(t, testPhone); !strings.Contains(invitation, tt.want) {
+				t.Errorf("invitation = %q, want %q", invitation, tt.want)
+			}
+		})
+	}
+}
+
+func TestTierInvitationInGuestLanguage(t *testing.T) {
+	h, guests, sender := newTestHandler(t, &Config{Templates: tierTemplates(t)})
+	if err := h.SendInvitation(testPhone, testName, "", "", "formal", false); err != nil {
+		t.Fatalf("SendInvitation: %v", err)
+	}
+	if err := guests.SetLanguage(testPhone, models.LanguageHebrew); err != nil {
+		t.Fatalf("SetLanguage: %v", err)
+	}
+
+	if err := h.ResendInvitation(testPhone); err != nil {
+		t.Fatalf("ResendInvitation: %v", err)
+	}
+	if invitation := sender.last(t, testPhone); !strings.Contains(invitation, "הנך מוזמן/ת באופן רשמי") {
+		t.Errorf("invitation = %q, want the formal invitation in Hebrew", invitation)
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+	"wedding-whatsapp/internal/storage"
+	"wedding-whatsapp/internal/whatsapp"
+)
+
+// testGuestName is the guest name filled into a test invitation
+const testGuestName = "Test Guest"
+
+// SendTestInvitation sends the invitation for a guest tier, empty for the standard one, rendered
+// exactly as guests will get it, to phoneNumber so it can be checked before inviting anyone.
+// No guest is stored, and when RSVPBaseURL is set the RSVP link in it has a random token that
+// doesn't record an answer.
+// It returns the message ID, which is empty when the invitation is held back for quiet hours.
+func (h *RSVPHandler) SendTestInvitation(phoneNumber, tier string) (string, error) {
+	normalizedNumber := whatsapp.NormalizePhoneNumber(phoneNumber)
+	if !phone.IsValid(normalizedNumber) {
+		return "", fmt.Errorf("%q: %w", phoneNumber, storage.ErrInvalidPhone)
+	}
+
+	guest := models.Guest{PhoneNumber: normalizedNumber, Name: testGuestName, RSVPStatus: models.RSVPPending, Tier: NormalizeTier(tier)}
+	if h.config.RSVPBaseURL != "" {
+		token, err := newRSVPToken()
+		if err != nil {
+			return "", err
+		}
+		guest.RSVPToken = token
+	}
+
+	message, err := h.renderInvitation(&guest)
+	if err != nil {
+		return "", err
+	}
+
+	messageID, err := h.whatsappService.SendInvitation(normalizedNumber, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to send test invitation: %w", err)
+	}
+	return messageID, nil
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// webhookTimeout bounds how long a notification may take so slow endpoints don't pile up goroutines
+const webhookTimeout = 10 * time.Second
+
+// rsvpNotification is the JSON body posted to the webhook when a guest's RSVP changes.
+// Text and Content repeat the summary so Slack and Discord webhooks display it as is.
+type rsvpNotification struct {
+	GuestName   string            `json:"guest_name"`
+	PhoneNumber string            `json:"phone_number"`
+	Status      models.RSVPStatus `json:"status"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Text        string            `json:"text"`
+	Content     string            `json:"content"`
+}
+
+// questionNotification is the JSON body posted to the webhook when a guest asks a question
+type questionNotification struct {
+	GuestName   string    `json:"guest_name"`
+	PhoneNumber string    `json:"phone_number"`
+	Question    string    `json:"question"`
+	Timestamp   time.Time `json:"timestamp"`
+	Text        string    `json:"text"`
+	Content     string    `json:"content"`
+}
+
+// webhook posts RSVP notifications to an HTTP endpoint
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+// newWebhook creates a webhook for url, or returns nil if url is empty
+func newWebhook(url string) *webhook {
+	if url == "" {
+		return nil
+	}
+	return &webhook{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// notifyRSVP posts the guest's new status in the background.
+// Failures are logged rather than returned so they never hold up the RSVP flow.
+func (w *webhook) notifyRSVP(guest *models.Guest, status models.RSVPStatus) {
+	if w == nil {
+		return
+	}
+
+	summary := fmt.Sprintf("%s (%s) RSVP'd %s", guest.Name, guest.PhoneNumber, status)
+	notification := rsvpNotification{
+		GuestName:   guest.Name,
+		PhoneNumber: guest.PhoneNumber,
+		Status:      status,
+		Timestamp:   time.Now(),
+		Text:        summary,
+		Content:     summary,
+	}
+
+	go func() {
+		if err := w.post(notification); err != nil {
+			fmt.Printf("⚠️ Failed to send RSVP notification for %s: %v\n", guest.DisplayPhone(), err)
+		}
+	}()
+}
+
+// notifyQuestion posts a question the guest asked in the background, so the organizer can answer it.
+// Like notifyRSVP, failures are only logged.
+func (w *webhook) notifyQuestion(guest *models.Guest, question string) {
+	if w == nil {
+		return
+	}
+
+	summary := fmt.Sprintf("%s (%s) asked: %s", guest.Name, guest.PhoneNumber, question)
+	notification := questionNotification{
+		GuestName:   guest.Name,
+		PhoneNumber: guest.PhoneNumber,
+		Question:    question,
+		Timestamp:   time.Now(),
+		Text:        summary,
+		Content:     summary,
+	}
+
+	go func() {
+		if err := w.post(notification); err != nil {
+			fmt.Printf("⚠️ Failed to send question notification for %s: %v\n", guest.DisplayPhone(), err)
+		}
+	}()
+}
+
+// post sends a single notification
+func (w *webhook) post(notification any) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
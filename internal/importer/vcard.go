@@ -0,0 +1,185 @@
+// Package importer reads guest lists exported from other applications
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+)
+
+// vcardProperty is a single "NAME;PARAMS:value" line of a vCard
+type vcardProperty struct {
+	name   string
+	params []string
+	value  string
+}
+
+// vcardContact collects the properties of one BEGIN:VCARD ... END:VCARD block that we care about
+type vcardContact struct {
+	fullName   string
+	structured string
+	phones     []vcardProperty
+}
+
+// ParseVCF extracts guests from a vCard (.vcf) contacts export.
+// The name comes from FN (or N when FN is missing) and the phone from TEL, preferring a number
+// labeled as mobile when a contact has several. Contacts without a name or phone are skipped.
+// Guests are returned as not_invited, since nothing has been sent to them yet, with normalized phone numbers.
+func ParseVCF(r io.Reader) ([]models.Guest, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vCard: %w", err)
+	}
+
+	guests := make([]models.Guest, 0)
+	var contact *vcardContact
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			if strings.EqualFold(prop.value, "VCARD") {
+				contact = &vcardContact{}
+			}
+		case "END":
+			if contact != nil && strings.EqualFold(prop.value, "VCARD") {
+				if guest, ok := contact.guest(); ok {
+					guests = append(guests, guest)
+				}
+				contact = nil
+			}
+		case "FN":
+			if contact != nil {
+				contact.fullName = prop.value
+			}
+		case "N":
+			if contact != nil {
+				contact.structured = prop.value
+			}
+		case "TEL":
+			if contact != nil {
+				contact.phones = append(contact.phones, prop)
+			}
+		}
+	}
+
+	return guests, nil
+}
+
+// guest converts the contact to a guest who hasn't been invited yet
+func (c *vcardContact) guest() (models.Guest, bool) {
+	name := strings.TrimSpace(unescapeValue(c.fullName))
+	if name == "" {
+		name = structuredName(c.structured)
+	}
+
+	number := ""
+	for _, tel := range c.phones {
+		if number == "" || isMobile(tel.params) {
+			number = tel.value
+		}
+		if isMobile(tel.params) {
+			break
+		}
+	}
+	number = phone.Normalize(strings.TrimPrefix(strings.TrimSpace(number), "tel:"))
+
+	if name == "" || number == "" {
+		return models.Guest{}, false
+	}
+	return models.Guest{PhoneNumber: number, Name: name, RSVPStatus: models.RSVPNotInvited}, true
+}
+
+// unfoldLines reads the file, joining folded lines (continued with leading whitespace)
+// and quoted-printable soft line breaks (a trailing "=")
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		last := len(lines) - 1
+		switch {
+		case last >= 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			lines[last] += line[1:]
+		case last >= 0 && isQuotedPrintable(lines[last]) && strings.HasSuffix(lines[last], "="):
+			lines[last] = strings.TrimSuffix(lines[last], "=") + line
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and decoded value
+func parseProperty(line string) (vcardProperty, bool) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return vcardProperty{}, false
+	}
+
+	parts := strings.Split(key, ";")
+	name := strings.ToUpper(parts[0])
+	// Grouped properties like "item1.TEL" belong to the same contact
+	if _, after, grouped := strings.Cut(name, "."); grouped {
+		name = after
+	}
+
+	prop := vcardProperty{name: name, params: parts[1:], value: value}
+	if isQuotedPrintable(line) {
+		if decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(value))); err == nil {
+			prop.value = string(decoded)
+		}
+	}
+	return prop, true
+}
+
+// isQuotedPrintable reports whether a content line is quoted-printable encoded (vCard 2.1)
+func isQuotedPrintable(line string) bool {
+	key, _, _ := strings.Cut(line, ":")
+	return strings.Contains(strings.ToUpper(key), "QUOTED-PRINTABLE")
+}
+
+// isMobile reports whether TEL parameters label the number as a mobile one,
+// e.g. "TYPE=CELL", "type=mobile,voice" or the vCard 2.1 form "CELL"
+func isMobile(params []string) bool {
+	for _, param := range params {
+		param = strings.ToUpper(param)
+		if strings.Contains(param, "CELL") || strings.Contains(param, "MOBILE") {
+			return true
+		}
+	}
+	return false
+}
+
+// structuredName builds "Given Family" from an N value (Family;Given;Additional;Prefix;Suffix)
+func structuredName(value string) string {
+	parts := strings.Split(value, ";")
+	var names []string
+	for _, i := range []int{3, 1, 2, 0, 4} {
+		if i < len(parts) {
+			if part := strings.TrimSpace(unescapeValue(parts[i])); part != "" {
+				names = append(names, part)
+			}
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// unescapeValue undoes vCard text escaping
+func unescapeValue(value string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(value)
+}
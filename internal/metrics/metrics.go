@@ -0,0 +1,125 @@
+// Package metrics counts what the bot does and serves the counts in the Prometheus text format
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// Metrics holds the bot's counters. A nil *Metrics is valid and counts nothing,
+// so callers don't need to check whether metrics are enabled.
+type Metrics struct {
+	invitationsSent  atomic.Int64
+	messagesReceived atomic.Int64
+	sendErrors       atomic.Int64
+	rsvpsAccepted    atomic.Int64
+	rsvpsDeclined    atomic.Int64
+	rsvpsMaybe       atomic.Int64
+}
+
+// New creates a set of counters starting at zero
+func New() *Metrics {
+	return &Metrics{}
+}
+
+// InvitationSent counts an invitation that went out
+func (m *Metrics) InvitationSent() {
+	if m != nil {
+		m.invitationsSent.Add(1)
+	}
+}
+
+// MessageReceived counts an incoming message from a guest
+func (m *Metrics) MessageReceived() {
+	if m != nil {
+		m.messagesReceived.Add(1)
+	}
+}
+
+// SendError counts a message that couldn't be sent
+func (m *Metrics) SendError() {
+	if m != nil {
+		m.sendErrors.Add(1)
+	}
+}
+
+// RSVP counts a guest's answer
+func (m *Metrics) RSVP(status models.RSVPStatus) {
+	if m == nil {
+		return
+	}
+	switch status {
+	case models.RSVPAccepted:
+		m.rsvpsAccepted.Add(1)
+	case models.RSVPDeclined:
+		m.rsvpsDeclined.Add(1)
+	case models.RSVPMaybe:
+		m.rsvpsMaybe.Add(1)
+	}
+}
+
+// Server serves the counters at /metrics, along with a gauge of pending guests
+type Server struct {
+	metrics    *Metrics
+	stats      func() models.RSVPStats
+	httpServer *http.Server
+}
+
+// NewServer creates a metrics server listening on addr.
+// stats is called on every scrape for the guest counts, which the storage already computes.
+func NewServer(addr string, m *Metrics, stats func() models.RSVPStats) *Server {
+	s := &Server{metrics: m, stats: stats}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", s.serveMetrics)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start serves requests until Shutdown is called
+func (s *Server) Start() error {
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops the server, waiting for in-flight requests to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// serveMetrics handles GET /metrics
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	m := s.metrics
+	stats := s.stats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetric(w, "wedding_invitations_sent_total", "counter", "Invitations sent.", m.invitationsSent.Load())
+	writeMetric(w, "wedding_messages_received_total", "counter", "Messages received from guests.", m.messagesReceived.Load())
+	writeMetric(w, "wedding_send_errors_total", "counter", "Messages that failed to send.", m.sendErrors.Load())
+
+	fmt.Fprintln(w, "# HELP wedding_rsvps_total RSVP answers received, by status.")
+	fmt.Fprintln(w, "# TYPE wedding_rsvps_total counter")
+	fmt.Fprintf(w, "wedding_rsvps_total{status=%q} %d\n", models.RSVPAccepted, m.rsvpsAccepted.Load())
+	fmt.Fprintf(w, "wedding_rsvps_total{status=%q} %d\n", models.RSVPDeclined, m.rsvpsDeclined.Load())
+	fmt.Fprintf(w, "wedding_rsvps_total{status=%q} %d\n", models.RSVPMaybe, m.rsvpsMaybe.Load())
+
+	writeMetric(w, "wedding_guests_pending", "gauge", "Guests who haven't answered yet.", int64(stats.Pending))
+}
+
+// writeMetric writes a single unlabeled metric with its help and type lines
+func writeMetric(w http.ResponseWriter, name, kind, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"wedding-whatsapp/internal/phone"
+)
 
 // Guest represents a wedding guest
 type Guest struct {
@@ -10,6 +14,94 @@ type Guest struct {
 	RSVPDate    time.Time  `json:"rsvp_date,omitempty"`
 	InvitedDate time.Time  `json:"invited_date"`
 	Notes       string     `json:"notes,omitempty"`
+	TableNumber int        `json:"table_number,omitempty"`
+	PartySize   int        `json:"party_size,omitempty"`
+
+	// RSVPDeadline overrides the configured RSVP deadline for this guest when set
+	RSVPDeadline time.Time `json:"rsvp_deadline,omitempty"`
+
+	// HouseholdID groups guests invited together, e.g. a couple or family, even if only one is messaged
+	HouseholdID string `json:"household_id,omitempty"`
+
+	// Side is whose guest this is, e.g. "bride" or "groom", so the guest count can be balanced.
+	// Empty when it wasn't given; see GuestSide.
+	Side string `json:"side,omitempty"`
+
+	// Tier picks the invitation the guest is sent, e.g. "formal" for invitation-formal.tmpl.
+	// Empty, or a tier without its own template, gets the standard invitation.
+	Tier string `json:"tier,omitempty"`
+
+	// CustomMessage is a personal note appended to the guest's invitation
+	CustomMessage string `json:"custom_message,omitempty"`
+
+	// RSVPToken is the unguessable part of the guest's web RSVP link, set when they're first invited
+	RSVPToken string `json:"rsvp_token,omitempty"`
+
+	MealPreference    string            `json:"meal_preference,omitempty"`
+	ConversationState ConversationState `json:"conversation_state,omitempty"`
+
+	// Language is the language the guest replies in, and the one we write to them in.
+	// It's empty until they reply, and messages use the primary language until then.
+	Language string `json:"language,omitempty"`
+
+	LastReminderDate      time.Time `json:"last_reminder_date,omitempty"`
+	ConfirmationDelivered bool      `json:"confirmation_delivered"`
+
+	// LastMessageID is the WhatsApp ID of the last invitation or reminder sent to the guest,
+	// whose delivery is tracked in DeliveryStatus
+	LastMessageID  string         `json:"last_message_id,omitempty"`
+	DeliveryStatus DeliveryStatus `json:"delivery_status,omitempty"`
+	ResendCount    int            `json:"resend_count,omitempty"` // times the invitation was sent again
+
+	// JID is the WhatsApp address PhoneNumber was last verified at, reused after a restart
+	// instead of looking the number up again until it's older than the cache TTL
+	JID           string    `json:"jid,omitempty"`
+	JIDVerifiedAt time.Time `json:"jid_verified_at,omitempty"`
+
+	// AlternatePhones are other numbers the guest may reply from, like a work phone.
+	// Messages are sent to PhoneNumber, but a reply from any of them counts as the guest's.
+	AlternatePhones []string `json:"alternate_phones,omitempty"`
+
+	// Unreachable is set when the number isn't on WhatsApp or a send to it failed permanently,
+	// with the reason in LastError. It's cleared once a message gets through again.
+	Unreachable bool   `json:"unreachable,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+
+	// SendAttempts is the trail of messages sent to the guest and whether they got through, oldest first.
+	// Only the most recent attempts are kept.
+	SendAttempts []SendAttempt `json:"send_attempts,omitempty"`
+
+	// DoNotContact is set when the guest asked to stop being messaged. They're left out of
+	// invitations, reminders and broadcasts, though their own messages are still answered.
+	DoNotContact bool `json:"do_not_contact,omitempty"`
+
+	// History is the append-only trail of the guest's RSVP answers, oldest first
+	History []RSVPEvent `json:"history,omitempty"`
+}
+
+// RSVPEvent records a single RSVP answer, so earlier answers survive a change of mind
+type RSVPEvent struct {
+	Timestamp time.Time  `json:"timestamp"`
+	OldStatus RSVPStatus `json:"old_status"`
+	NewStatus RSVPStatus `json:"new_status"`
+	Message   string     `json:"message,omitempty"` // the guest's reply as received
+}
+
+// SendAttempt records one attempt to send the guest a message
+type SendAttempt struct {
+	Timestamp time.Time `json:"timestamp"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"` // why the send failed
+}
+
+// LastFailedSend returns the guest's most recent send attempt that failed, if any
+func (g Guest) LastFailedSend() (SendAttempt, bool) {
+	for i := len(g.SendAttempts) - 1; i >= 0; i-- {
+		if !g.SendAttempts[i].Success {
+			return g.SendAttempts[i], true
+		}
+	}
+	return SendAttempt{}, false
 }
 
 // RSVPStatus represents the attendance confirmation status
@@ -19,12 +111,131 @@ const (
 	RSVPPending    RSVPStatus = "pending"
 	RSVPAccepted   RSVPStatus = "accepted"
 	RSVPDeclined   RSVPStatus = "declined"
+	RSVPMaybe      RSVPStatus = "maybe"
 	RSVPNotInvited RSVPStatus = "not_invited"
 )
 
+// RSVPStats summarizes the guest list's RSVP status
+type RSVPStats struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Accepted  int `json:"accepted"`
+	Declined  int `json:"declined"`
+	Maybe     int `json:"maybe"`
+	Headcount int `json:"headcount"` // summed party size of accepted guests
+
+	// NotInvited counts guests on the list who haven't been sent an invitation yet
+	NotInvited int `json:"not_invited"`
+
+	// DoNotContact counts guests who asked to stop being messaged; they're also counted by status
+	DoNotContact int `json:"do_not_contact"`
+
+	// Households counts invitations: guests sharing a household ID count once, others individually
+	Households int `json:"households"`
+
+	// Sides breaks the answers down by the side guests were invited by, see GuestSide
+	Sides map[string]SideStats `json:"sides"`
+}
+
+// SideStats summarizes the RSVP status of the guests invited by one side
+type SideStats struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Accepted  int `json:"accepted"`
+	Declined  int `json:"declined"`
+	Maybe     int `json:"maybe"`
+	Headcount int `json:"headcount"` // summed party size of accepted guests
+}
+
+// SideUnspecified is the side of guests who were invited without saying by whom
+const SideUnspecified = "unspecified"
+
+// GuestSide returns the side the guest was invited by, or SideUnspecified
+func (g Guest) GuestSide() string {
+	if g.Side == "" {
+		return SideUnspecified
+	}
+	return g.Side
+}
+
+// DisplayPhone returns the guest's number in E.164 form with its leading +, e.g. +972501234567.
+// PhoneNumber itself stays digits only, since guests are stored and looked up by it.
+func (g Guest) DisplayPhone() string {
+	return phone.Format(g.PhoneNumber)
+}
+
+// Languages guests can be written to in
+const (
+	LanguageEnglish = "en"
+	LanguageHebrew  = "he"
+)
+
+// ConversationState tracks what the bot is waiting for from a guest
+type ConversationState string
+
+const (
+	StateIdle         ConversationState = ""
+	StateAwaitingMeal ConversationState = "awaiting_meal"
+
+	// The guest accepted and was asked how many are coming; after one unclear answer they're asked again
+	StateAwaitingPartySize      ConversationState = "awaiting_party_size"
+	StateAwaitingPartySizeRetry ConversationState = "awaiting_party_size_retry"
+
+	// The guest wrote after the wedding and was thanked, so later messages aren't answered
+	StateThanked ConversationState = "thanked"
+)
+
+// DeliveryStatus tracks how far the last message sent to a guest got
+type DeliveryStatus string
+
+const (
+	DeliverySent      DeliveryStatus = "sent"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryRead      DeliveryStatus = "read"
+)
+
+// deliveryRanks orders delivery statuses so receipts arriving out of order never move a message backwards
+var deliveryRanks = map[DeliveryStatus]int{
+	DeliverySent:      1,
+	DeliveryDelivered: 2,
+	DeliveryRead:      3,
+}
+
+// After reports whether d is a later stage of delivery than other
+func (d DeliveryStatus) After(other DeliveryStatus) bool {
+	return deliveryRanks[d] > deliveryRanks[other]
+}
+
 // AttendanceRequest represents a request to send an invitation
 type AttendanceRequest struct {
 	PhoneNumber string
 	Name        string
 	Message     string
 }
+
+// UnknownContact is a number that messaged the bot without being on the guest list
+type UnknownContact struct {
+	PhoneNumber  string    `json:"phone_number"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	MessageCount int       `json:"message_count"`
+	LastMessage  string    `json:"last_message,omitempty"`
+}
+
+// PendingReply represents an outgoing reply that failed and is waiting to be retried
+type PendingReply struct {
+	PhoneNumber string    `json:"phone_number"`
+	Message     string    `json:"message"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// ScheduledBroadcast is a broadcast message waiting to be sent to every guest with a status at a set time
+type ScheduledBroadcast struct {
+	ID        string     `json:"id"`
+	SendAt    time.Time  `json:"send_at"`
+	Status    RSVPStatus `json:"status"`
+	Message   string     `json:"message"`
+	CreatedAt time.Time  `json:"created_at"`
+}
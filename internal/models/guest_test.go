@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestDisplayPhone(t *testing.T) {
+	tests := []struct {
+		name, phoneNumber, want string
+	}{
+		{"Israeli mobile", "972501234567", "+972501234567"},
+		{"Israeli landline", "97231234567", "+97231234567"},
+		{"US", "15551234567", "+15551234567"},
+		{"UK", "447911123456", "+447911123456"},
+		{"Germany", "4915123456789", "+4915123456789"},
+		{"not a number", "120363012345678901@g.us", "120363012345678901@g.us"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Guest{PhoneNumber: tt.phoneNumber}
+			if got := g.DisplayPhone(); got != tt.want {
+				t.Errorf("DisplayPhone() = %q, want %q", got, tt.want)
+			}
+			if g.PhoneNumber != tt.phoneNumber {
+				t.Errorf("PhoneNumber changed to %q, want the stored digits kept", g.PhoneNumber)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package phone normalizes phone numbers to the E.164 digits used to identify guests
+package phone
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// region describes how local phone numbers are written in a country
+type region struct {
+	countryCode     string
+	trunkPrefix     string // dialed before national numbers, e.g. the 0 in 050-1234567
+	nationalLengths []int  // valid lengths of the national number, without the trunk prefix
+}
+
+// regions holds the dialing rules for the supported default regions, keyed by ISO 3166 code
+var regions = map[string]region{
+	"IL": {countryCode: "972", trunkPrefix: "0", nationalLengths: []int{8, 9}},
+	"US": {countryCode: "1", nationalLengths: []int{10}},
+	"CA": {countryCode: "1", nationalLengths: []int{10}},
+	"GB": {countryCode: "44", trunkPrefix: "0", nationalLengths: []int{9, 10}},
+	"FR": {countryCode: "33", trunkPrefix: "0", nationalLengths: []int{9}},
+	"DE": {countryCode: "49", trunkPrefix: "0", nationalLengths: []int{10, 11}},
+	"AU": {countryCode: "61", trunkPrefix: "0", nationalLengths: []int{9}},
+}
+
+// defaultRegion is used to interpret numbers written without a country code
+var defaultRegion = "IL"
+
+// SetDefaultRegion sets the ISO 3166 region used for numbers entered without a country code
+func SetDefaultRegion(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := regions[code]; !ok {
+		return fmt.Errorf("unsupported phone region %q", code)
+	}
+	defaultRegion = code
+	return nil
+}
+
+// Normalize normalizes phone numbers to E.164 digits (international format without the +).
+// Numbers written without a country code are interpreted using the default region (Israel unless configured).
+func Normalize(phoneNumber string) string {
+	return NormalizeForRegion(phoneNumber, defaultRegion)
+}
+
+// NormalizeForRegion normalizes a phone number, interpreting local numbers using the given region
+func NormalizeForRegion(phoneNumber, regionCode string) string {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	international := strings.HasPrefix(phoneNumber, "+")
+
+	// Remove all non-digit characters
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phoneNumber)
+
+	// 00 is the international dialing prefix in most countries, e.g. 00972...
+	if !international && strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		international = true
+	}
+
+	if !international {
+		if r, ok := regions[regionCode]; ok {
+			// Local format: 05XXXXXXXX -> 9725XXXXXXXX, (555) 123-4567 -> 15551234567
+			national := strings.TrimPrefix(digits, r.trunkPrefix)
+			if r.trunkPrefix != "" && national != digits && slices.Contains(r.nationalLengths, len(national)) {
+				return r.countryCode + national
+			}
+			if slices.Contains(r.nationalLengths, len(digits)) && !strings.HasPrefix(digits, r.countryCode) {
+				return r.countryCode + digits
+			}
+		}
+	}
+
+	// Numbers written with both the country code and the trunk prefix, e.g. 9720501234567.
+	// Drop the 0 after the country code.
+	for _, r := range regions {
+		if r.trunkPrefix != "" && strings.HasPrefix(digits, r.countryCode+r.trunkPrefix) &&
+			slices.Contains(r.nationalLengths, len(digits)-len(r.countryCode)-len(r.trunkPrefix)) {
+			return r.countryCode + digits[len(r.countryCode)+len(r.trunkPrefix):]
+		}
+	}
+
+	return digits
+}
+
+// Plausible lengths of a normalized number; E.164 allows at most 15 digits
+const (
+	minDigits = 7
+	maxDigits = 15
+)
+
+// IsValid reports whether a normalized number is all digits and of a plausible length
+func IsValid(number string) bool {
+	if len(number) < minDigits || len(number) > maxDigits {
+		return false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Format returns a normalized number in E.164 form with its leading +, e.g. +972501234567,
+// for showing to people. Anything that isn't a normalized number, like a group JID, is returned unchanged.
+func Format(number string) string {
+	if !IsValid(number) {
+		return number
+	}
+	return "+" + number
+}
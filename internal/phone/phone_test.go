@@ -0,0 +1,51 @@
+package phone
+
+import "testing"
+
+func TestNormalizeForRegion(t *testing.T) {
+	tests := []struct {
+		region, input, want string
+	}{
+		{"IL", "050-123-4567", "972501234567"},
+		{"IL", "0501234567", "972501234567"},
+		{"IL", "+972 50 123 4567", "972501234567"},
+		{"IL", "00972501234567", "972501234567"},
+		{"IL", "972-050-1234567", "972501234567"},
+		{"IL", "03-1234567", "97231234567"},
+
+		{"US", "(555) 123-4567", "15551234567"},
+		{"US", "555.123.4567", "15551234567"},
+		{"US", "+1 555 123 4567", "15551234567"},
+		{"US", "1-555-123-4567", "15551234567"},
+
+		{"GB", "07911 123456", "447911123456"},
+		{"GB", "+44 7911 123456", "447911123456"},
+		{"GB", "0044 7911 123456", "447911123456"},
+		{"GB", "+44 (0)7911 123456", "447911123456"},
+
+		// A number with a country code is kept whatever the region
+		{"US", "+972 50-123-4567", "972501234567"},
+		{"IL", "+1 (555) 123-4567", "15551234567"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region+" "+tt.input, func(t *testing.T) {
+			if got := NormalizeForRegion(tt.input, tt.region); got != tt.want {
+				t.Errorf("NormalizeForRegion(%q, %s) = %q, want %q", tt.input, tt.region, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDefaultRegion(t *testing.T) {
+	t.Cleanup(func() { defaultRegion = "IL" })
+
+	if err := SetDefaultRegion("us"); err != nil {
+		t.Fatalf("SetDefaultRegion: %v", err)
+	}
+	if got := Normalize("(555) 123-4567"); got != "15551234567" {
+		t.Errorf("Normalize = %q, want the number read as a US one", got)
+	}
+	if err := SetDefaultRegion("XX"); err == nil {
+		t.Error("SetDefaultRegion accepted an unknown region")
+	}
+}
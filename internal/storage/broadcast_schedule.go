@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// BroadcastSchedule persists broadcasts scheduled for later so they survive a restart
+type BroadcastSchedule struct {
+	mu         sync.Mutex
+	broadcasts []models.ScheduledBroadcast
+	file       string
+}
+
+// NewBroadcastSchedule creates a new broadcast schedule backed by the given file
+func NewBroadcastSchedule(filePath string) (*BroadcastSchedule, error) {
+	s := &BroadcastSchedule{
+		broadcasts: make([]models.ScheduledBroadcast, 0),
+		file:       filePath,
+	}
+
+	// Load existing schedule if file exists
+	if _, err := os.Stat(filePath); err == nil {
+		if err := s.load(); err != nil {
+			return nil, fmt.Errorf("failed to load broadcast schedule: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Add schedules a broadcast
+func (s *BroadcastSchedule) Add(broadcast models.ScheduledBroadcast) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.broadcasts = append(s.broadcasts, broadcast)
+	return s.save()
+}
+
+// Due returns the broadcasts whose send time has passed, earliest first
+func (s *BroadcastSchedule) Due(now time.Time) []models.ScheduledBroadcast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []models.ScheduledBroadcast
+	for _, b := range s.broadcasts {
+		if !b.SendAt.After(now) {
+			result = append(result, b)
+		}
+	}
+	sortBroadcasts(result)
+	return result
+}
+
+// Pending returns every scheduled broadcast, earliest first
+func (s *BroadcastSchedule) Pending() []models.ScheduledBroadcast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := slices.Clone(s.broadcasts)
+	sortBroadcasts(result)
+	return result
+}
+
+// Remove drops the scheduled broadcast with the given ID, reporting whether there was one
+func (s *BroadcastSchedule) Remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, b := range s.broadcasts {
+		if b.ID == id {
+			s.broadcasts = append(s.broadcasts[:i], s.broadcasts[i+1:]...)
+			return true, s.save()
+		}
+	}
+	return false, nil
+}
+
+// sortBroadcasts orders broadcasts by send time
+func sortBroadcasts(broadcasts []models.ScheduledBroadcast) {
+	slices.SortStableFunc(broadcasts, func(a, b models.ScheduledBroadcast) int {
+		return a.SendAt.Compare(b.SendAt)
+	})
+}
+
+// save writes the schedule to file
+func (s *BroadcastSchedule) save() error {
+	data, err := json.MarshalIndent(s.broadcasts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	// Ensure directory exists
+	dir := filepath.Dir(s.file)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return writeFileAtomic(s.file, data, fileMode)
+}
+
+// load reads the schedule from file
+func (s *BroadcastSchedule) load() error {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &s.broadcasts); err != nil {
+		return fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// csvDateFormat is the timestamp layout used in CSV exports
+const csvDateFormat = "2006-01-02 15:04"
+
+// ExportCSV writes all guests as CSV with a header row
+func (s *Storage) ExportCSV(w io.Writer) error {
+	return writeGuestsCSV(w, s.GetAllGuests())
+}
+
+// ExportCSV writes all guests as CSV with a header row
+func (s *SQLiteStorage) ExportCSV(w io.Writer) error {
+	return writeGuestsCSV(w, s.GetAllGuests())
+}
+
+// writeGuestsCSV writes guests using RFC 4180 quoting so names with commas survive
+func writeGuestsCSV(w io.Writer, guests []models.Guest) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"name", "phone", "status", "party size", "meal", "rsvp date", "notes", "side", "tier"}); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, g := range guests {
+		rsvpDate := ""
+		if !g.RSVPDate.IsZero() {
+			rsvpDate = g.RSVPDate.Format(csvDateFormat)
+		}
+
+		record := []string{
+			g.Name,
+			g.PhoneNumber,
+			string(g.RSVPStatus),
+			strconv.Itoa(g.PartySize),
+			g.MealPreference,
+			rsvpDate,
+			g.Notes,
+			g.GuestSide(),
+			g.Tier,
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write guest %s: %w", g.PhoneNumber, err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
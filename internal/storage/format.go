@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// formatVersion is the version of the guest file format written by encodeGuests.
+// Version 0 is the original bare array of guests, version 1 wraps it in guestFile.
+const formatVersion = 1
+
+// guestFile is the envelope guest lists and backups are written in
+type guestFile struct {
+	Version int             `json:"version"`
+	Guests  json.RawMessage `json:"guests"`
+}
+
+// migrations upgrade the guests of a file from the version at their index to the next one.
+// Renaming or restructuring a guest field means bumping formatVersion and adding a step here.
+var migrations = []func(guests json.RawMessage) (json.RawMessage, error){
+	// 0 → 1 only added the envelope, the guests are unchanged
+	func(guests json.RawMessage) (json.RawMessage, error) { return guests, nil },
+}
+
+// encodeGuests marshals guests in the current file format
+func encodeGuests(guests []models.Guest) ([]byte, error) {
+	data, err := json.Marshal(guests)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(guestFile{Version: formatVersion, Guests: data}, "", "  ")
+}
+
+// decodeGuests unmarshals a guest file of any version, migrating older versions to the current one
+func decodeGuests(data []byte) ([]models.Guest, error) {
+	var file guestFile
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &file); err != nil {
+			return nil, err
+		}
+	} else {
+		// Unmarshaling into Guests reuses its buffer, so it only points at data when there's no envelope
+		file.Guests = data
+	}
+	if file.Version > formatVersion {
+		return nil, fmt.Errorf("guest file version %d is newer than this version of the bot supports (%d)", file.Version, formatVersion)
+	}
+
+	for version := file.Version; version < formatVersion; version++ {
+		migrated, err := migrations[version](file.Guests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate guest file from version %d: %w", version, err)
+		}
+		file.Guests = migrated
+	}
+
+	guests := make([]models.Guest, 0)
+	if len(file.Guests) == 0 {
+		return guests, nil
+	}
+	if err := json.Unmarshal(file.Guests, &guests); err != nil {
+		return nil, err
+	}
+	return guests, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestDecodeGuests(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"v0 bare array", `[{"phone_number": "972501234567", "name": "Dana"}, {"phone_number": "972509876543", "name": "Yossi"}]`, []string{"Dana", "Yossi"}},
+		{"v0 empty array", `[]`, nil},
+		{"v1 envelope", `{"version": 1, "guests": [{"phone_number": "972501234567", "name": "Dana"}]}`, []string{"Dana"}},
+		{"v1 without guests", `{"version": 1}`, nil},
+		{"empty file", ``, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guests, err := decodeGuests([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("decodeGuests: %v", err)
+			}
+			if len(guests) != len(tt.want) {
+				t.Fatalf("decoded %d guests, want %d", len(guests), len(tt.want))
+			}
+			for i, g := range guests {
+				if g.Name != tt.want[i] {
+					t.Errorf("guest %d = %q, want %q", i, g.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeGuestsRejectsNewerVersion(t *testing.T) {
+	if _, err := decodeGuests([]byte(`{"version": 99, "guests": []}`)); err == nil {
+		t.Error("decodeGuests accepted a file from a newer version")
+	}
+}
+
+func TestLoadUpgradesV0File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "guests.json")
+	v0 := `[{"phone_number": "972501234567", "name": "Dana", "rsvp_status": "accepted"}]`
+	if err := os.WriteFile(file, []byte(v0), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	guest, err := s.GetGuest("972501234567")
+	if err != nil || guest.Name != "Dana" || guest.RSVPStatus != models.RSVPAccepted {
+		t.Fatalf("GetGuest = %+v, %v, want the guest from the v0 file", guest, err)
+	}
+
+	// The next save writes the current format
+	if err := s.UpdatePartySize("972501234567", 2); err != nil {
+		t.Fatalf("UpdatePartySize: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope guestFile
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Version != formatVersion {
+		t.Errorf("saved file = %s, want a version %d envelope", data, formatVersion)
+	}
+}
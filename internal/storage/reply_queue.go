@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+// ReplyQueue persists outgoing replies that failed so they can be retried later
+type ReplyQueue struct {
+	mu      sync.Mutex
+	replies []models.PendingReply
+	file    string
+}
+
+// NewReplyQueue creates a new reply queue backed by the given file
+func NewReplyQueue(filePath string) (*ReplyQueue, error) {
+	q := &ReplyQueue{
+		replies: make([]models.PendingReply, 0),
+		file:    filePath,
+	}
+
+	// Load existing queue if file exists
+	if _, err := os.Stat(filePath); err == nil {
+		if err := q.load(); err != nil {
+			return nil, fmt.Errorf("failed to load reply queue: %w", err)
+		}
+	}
+
+	return q, nil
+}
+
+// Enqueue adds a reply to the queue, replacing any pending reply for the same number
+func (q *ReplyQueue) Enqueue(reply models.PendingReply) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, r := range q.replies {
+		if r.PhoneNumber == reply.PhoneNumber {
+			q.replies[i] = reply
+			return q.save()
+		}
+	}
+
+	q.replies = append(q.replies, reply)
+	return q.save()
+}
+
+// Due returns the replies whose next attempt time has passed
+func (q *ReplyQueue) Due(now time.Time) []models.PendingReply {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var result []models.PendingReply
+	for _, r := range q.replies {
+		if !r.NextAttempt.After(now) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Remove drops the pending reply for the given phone number
+func (q *ReplyQueue) Remove(phoneNumber string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, r := range q.replies {
+		if r.PhoneNumber == phoneNumber {
+			q.replies = append(q.replies[:i], q.replies[i+1:]...)
+			return q.save()
+		}
+	}
+	return nil
+}
+
+// Len returns the number of queued replies
+func (q *ReplyQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.replies)
+}
+
+// save writes the queue to file
+func (q *ReplyQueue) save() error {
+	data, err := json.MarshalIndent(q.replies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	// Ensure directory exists
+	dir := filepath.Dir(q.file)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return writeFileAtomic(q.file, data, fileMode)
+}
+
+// load reads the queue from file
+func (q *ReplyQueue) load() error {
+	data, err := os.ReadFile(q.file)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &q.replies); err != nil {
+		return fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return nil
+}
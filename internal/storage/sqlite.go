@@ -0,0 +1,733 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+)
+
+// sqliteSchema stores each guest as a JSON document keyed by phone number.
+// The status is duplicated into its own column so filtering doesn't need to decode every row,
+// and keeping the rest of the record as JSON means new guest fields don't need a migration.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS guests (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	phone_number TEXT NOT NULL UNIQUE,
+	rsvp_status  TEXT NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS guests_rsvp_status ON guests (rsvp_status);
+CREATE TABLE IF NOT EXISTS unknown_contacts (
+	phone_number  TEXT PRIMARY KEY,
+	first_seen    DATETIME NOT NULL,
+	last_seen     DATETIME NOT NULL,
+	message_count INTEGER NOT NULL,
+	last_message  TEXT NOT NULL
+);
+`
+
+// SQLiteStorage is the SQLite backed implementation of Store
+type SQLiteStorage struct {
+	db   *sql.DB
+	file string
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// NewSQLiteStorage opens (or creates) a SQLite guest database at filePath
+func NewSQLiteStorage(filePath string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000", filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// The database is created with the default permissions; its journal files follow the database's
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := os.Chmod(filePath, fileMode); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set database permissions: %w", err)
+	}
+
+	// SQLite allows a single writer; serialize access instead of failing with "database is locked"
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	return &SQLiteStorage{db: db, file: filePath}, nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// CheckWritable reports an error if the database can't currently be written to.
+// SQLite needs to create its journal next to the database, so the directory has to be writable too.
+func (s *SQLiteStorage) CheckWritable() error {
+	if err := s.db.Ping(); err != nil {
+		return fmt.Errorf("failed to reach database: %w", err)
+	}
+	f, err := os.OpenFile(s.file, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open database for writing: %w", err)
+	}
+	f.Close()
+	return checkDirWritable(filepath.Dir(s.file))
+}
+
+// AddGuest adds a new guest, returning ErrGuestExists if a guest already has the phone number
+func (s *SQLiteStorage) AddGuest(guest models.Guest) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	normalizePhones(&guest)
+	if err := validatePhones(guest); err != nil {
+		return err
+	}
+	existing, err := findGuest(tx, guest.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%s: %w", guest.PhoneNumber, ErrGuestExists)
+	}
+	if err := checkPhonesFree(tx, guest, ""); err != nil {
+		return err
+	}
+
+	if err := putGuest(tx, newGuest(guest)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpsertGuest adds a new guest, or merges the guest into the existing one with the phone number
+// following the rules of mergeGuest
+func (s *SQLiteStorage) UpsertGuest(guest models.Guest) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	normalizePhones(&guest)
+	if err := validatePhones(guest); err != nil {
+		return err
+	}
+	existing, err := findGuest(tx, guest.PhoneNumber)
+	if err != nil {
+		return err
+	}
+
+	owner := ""
+	if existing != nil {
+		guest = mergeGuest(*existing, guest)
+		owner = existing.PhoneNumber
+	} else {
+		guest = newGuest(guest)
+	}
+	if err := checkPhonesFree(tx, guest, owner); err != nil {
+		return err
+	}
+	if err := putGuest(tx, guest); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetGuest retrieves a guest by their primary or an alternate phone number
+func (s *SQLiteStorage) GetGuest(phoneNumber string) (*models.Guest, error) {
+	guest, err := findGuest(s.db, phone.Normalize(phoneNumber))
+	if err != nil {
+		return nil, err
+	}
+	if guest == nil {
+		return nil, fmt.Errorf("guest not found")
+	}
+	return guest, nil
+}
+
+// GetGuestByToken returns the guest whose web RSVP link has the given token
+func (s *SQLiteStorage) GetGuestByToken(token string) (*models.Guest, error) {
+	if token == "" {
+		return nil, fmt.Errorf("guest not found")
+	}
+	guests, err := s.queryGuests("SELECT data FROM guests WHERE json_extract(data, '$.rsvp_token') = ? LIMIT 1", token)
+	if err != nil {
+		return nil, err
+	}
+	if len(guests) == 0 {
+		return nil, fmt.Errorf("guest not found")
+	}
+	return &guests[0], nil
+}
+
+// UpdateRSVP updates the RSVP status for a guest, recording the message that changed it in their history
+func (s *SQLiteStorage) UpdateRSVP(phoneNumber string, status models.RSVPStatus, notes, message string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		applyRSVP(g, status, notes, message)
+	})
+}
+
+// GetHistory returns the guest's RSVP answers, oldest first
+func (s *SQLiteStorage) GetHistory(phoneNumber string) ([]models.RSVPEvent, error) {
+	guest, err := s.GetGuest(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	return guest.History, nil
+}
+
+// UpdateGuest replaces the guest with phoneNumber as their primary or an alternate number with updated.
+// The phone numbers may change as long as they don't collide with another guest's.
+func (s *SQLiteStorage) UpdateGuest(phoneNumber string, updated models.Guest) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	phoneNumber = phone.Normalize(phoneNumber)
+	normalizePhones(&updated)
+	if err := validatePhones(updated); err != nil {
+		return err
+	}
+	existing, err := findGuest(tx, phoneNumber)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("guest not found")
+	}
+	if err := checkPhonesFree(tx, updated, existing.PhoneNumber); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal guest: %w", err)
+	}
+
+	// Update in place so the guest keeps its position in the list
+	if _, err := tx.Exec(
+		"UPDATE guests SET phone_number = ?, rsvp_status = ?, data = ? WHERE phone_number = ?",
+		updated.PhoneNumber, string(updated.RSVPStatus), string(data), existing.PhoneNumber,
+	); err != nil {
+		return fmt.Errorf("failed to update guest: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DeleteGuest removes a guest by their primary or an alternate phone number
+func (s *SQLiteStorage) DeleteGuest(phoneNumber string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	guest, err := findGuest(tx, phone.Normalize(phoneNumber))
+	if err != nil {
+		return err
+	}
+	if guest == nil {
+		return fmt.Errorf("guest not found")
+	}
+	if _, err := tx.Exec("DELETE FROM guests WHERE phone_number = ?", guest.PhoneNumber); err != nil {
+		return fmt.Errorf("failed to delete guest: %w", err)
+	}
+	return tx.Commit()
+}
+
+// UpdatePartySize updates how many people are coming with the guest, including themselves
+func (s *SQLiteStorage) UpdatePartySize(phoneNumber string, size int) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.PartySize = size
+	})
+}
+
+// SetLastReminderDate records when the guest was last sent an RSVP reminder
+func (s *SQLiteStorage) SetLastReminderDate(phoneNumber string, date time.Time) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.LastReminderDate = date
+	})
+}
+
+// SetConfirmationDelivered records whether the RSVP confirmation reached the guest
+func (s *SQLiteStorage) SetConfirmationDelivered(phoneNumber string, delivered bool) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.ConfirmationDelivered = delivered
+	})
+}
+
+// UpdateMeal records the guest's meal preference
+func (s *SQLiteStorage) UpdateMeal(phoneNumber, pref string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.MealPreference = pref
+	})
+}
+
+// AssignTable seats the guest at a table, or unseats them when table is 0
+func (s *SQLiteStorage) AssignTable(phoneNumber string, table int) error {
+	if table < 0 {
+		return fmt.Errorf("invalid table number %d", table)
+	}
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.TableNumber = table
+	})
+}
+
+// RecordMessageSent starts tracking delivery of a message sent to the guest
+func (s *SQLiteStorage) RecordMessageSent(phoneNumber, messageID string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.LastMessageID = messageID
+		g.DeliveryStatus = models.DeliverySent
+		g.Unreachable = false
+		g.LastError = ""
+	})
+}
+
+// MarkUnreachable records that messages can't be sent to the guest
+func (s *SQLiteStorage) MarkUnreachable(phoneNumber, reason string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.Unreachable = true
+		g.LastError = reason
+	})
+}
+
+// RecordSendAttempt adds an attempt to send the guest a message to their send attempts
+func (s *SQLiteStorage) RecordSendAttempt(phoneNumber string, attempt models.SendAttempt) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		appendSendAttempt(g, attempt)
+	})
+}
+
+// IncrementResendCount records that the guest's invitation was sent again
+func (s *SQLiteStorage) IncrementResendCount(phoneNumber string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.ResendCount++
+	})
+}
+
+// UpdateDeliveryStatus advances the delivery status of the guest whose last message has the given ID.
+// Receipts for messages that aren't tracked are ignored.
+func (s *SQLiteStorage) UpdateDeliveryStatus(messageID string, status models.DeliveryStatus) error {
+	var phoneNumber string
+	err := s.db.QueryRow(
+		"SELECT phone_number FROM guests WHERE json_extract(data, '$.last_message_id') = ?", messageID,
+	).Scan(&phoneNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query guest: %w", err)
+	}
+
+	return s.update(phoneNumber, func(g *models.Guest) {
+		if status.After(g.DeliveryStatus) {
+			g.DeliveryStatus = status
+		}
+	})
+}
+
+// SetConversationState records what the bot is waiting for from the guest
+func (s *SQLiteStorage) SetConversationState(phoneNumber string, state models.ConversationState) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.ConversationState = state
+	})
+}
+
+// SetLanguage records the language the guest writes in
+func (s *SQLiteStorage) SetLanguage(phoneNumber, language string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.Language = language
+	})
+}
+
+// SetRSVPToken sets the token of the guest's web RSVP link
+func (s *SQLiteStorage) SetRSVPToken(phoneNumber, token string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.RSVPToken = token
+	})
+}
+
+// MarkNotInvited sets the guest back to not_invited, clearing their invitation and RSVP dates
+func (s *SQLiteStorage) MarkNotInvited(phoneNumber string) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.RSVPStatus = models.RSVPNotInvited
+		g.InvitedDate = time.Time{}
+		g.RSVPDate = time.Time{}
+	})
+}
+
+// SetJID records the WhatsApp JID the guest's number was verified at, empty to forget it
+func (s *SQLiteStorage) SetJID(phoneNumber, jid string, verifiedAt time.Time) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.JID = jid
+		g.JIDVerifiedAt = verifiedAt
+	})
+}
+
+// SetDoNotContact records whether the guest asked to stop being messaged
+func (s *SQLiteStorage) SetDoNotContact(phoneNumber string, doNotContact bool) error {
+	return s.update(phoneNumber, func(g *models.Guest) {
+		g.DoNotContact = doNotContact
+	})
+}
+
+// GetAllGuests returns all guests in the order they were added.
+// A failed query yields an empty list.
+func (s *SQLiteStorage) GetAllGuests() []models.Guest {
+	guests, _ := s.queryGuests("SELECT data FROM guests ORDER BY id")
+	return guests
+}
+
+// GetGuestsByStatus returns guests filtered by RSVP status
+func (s *SQLiteStorage) GetGuestsByStatus(status models.RSVPStatus) []models.Guest {
+	guests, _ := s.queryGuests("SELECT data FROM guests WHERE rsvp_status = ? ORDER BY id", string(status))
+	return guests
+}
+
+// GetReadUnanswered returns pending guests who have read the last message we sent them
+func (s *SQLiteStorage) GetReadUnanswered() []models.Guest {
+	guests, _ := s.queryGuests(
+		"SELECT data FROM guests WHERE rsvp_status = ? AND json_extract(data, '$.delivery_status') = ? ORDER BY id",
+		string(models.RSVPPending), string(models.DeliveryRead),
+	)
+	return guests
+}
+
+// RecordUnknownContact logs a message from a number that isn't on the guest list.
+// It reports whether this was the first message from the number.
+func (s *SQLiteStorage) RecordUnknownContact(phoneNumber, text string) (bool, error) {
+	phoneNumber = phone.Normalize(phoneNumber)
+	now := time.Now()
+
+	res, err := s.db.Exec(`UPDATE unknown_contacts
+		SET last_seen = ?, message_count = message_count + 1, last_message = ?
+		WHERE phone_number = ?`, now, text, phoneNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to update unknown contact: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return false, nil
+	}
+
+	if _, err := s.db.Exec(`INSERT INTO unknown_contacts (phone_number, first_seen, last_seen, message_count, last_message)
+		VALUES (?, ?, ?, 1, ?)`, phoneNumber, now, now, text); err != nil {
+		return false, fmt.Errorf("failed to add unknown contact: %w", err)
+	}
+	return true, nil
+}
+
+// GetUnknownContacts returns the numbers that messaged without being invited, in the order they first did.
+// A failed query yields an empty list.
+func (s *SQLiteStorage) GetUnknownContacts() []models.UnknownContact {
+	contacts := make([]models.UnknownContact, 0)
+	rows, err := s.db.Query(`SELECT phone_number, first_seen, last_seen, message_count, last_message
+		FROM unknown_contacts ORDER BY first_seen`)
+	if err != nil {
+		return contacts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.UnknownContact
+		if err := rows.Scan(&c.PhoneNumber, &c.FirstSeen, &c.LastSeen, &c.MessageCount, &c.LastMessage); err != nil {
+			return contacts
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts
+}
+
+// GetHousehold returns the guests in the household with the given ID
+func (s *SQLiteStorage) GetHousehold(id string) []models.Guest {
+	if id == "" {
+		return make([]models.Guest, 0)
+	}
+	guests, _ := s.queryGuests("SELECT data FROM guests WHERE json_extract(data, '$.household_id') = ? ORDER BY id", id)
+	return guests
+}
+
+// SetHousehold puts the guests with the given phone numbers in household id, or takes them out of
+// their household when id is empty. Nothing is changed if any of the numbers is unknown.
+func (s *SQLiteStorage) SetHousehold(phones []string, id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, phoneNumber := range phones {
+		guest, err := findGuest(tx, phone.Normalize(phoneNumber))
+		if err != nil {
+			return err
+		}
+		if guest == nil {
+			return fmt.Errorf("guest not found: %s", phoneNumber)
+		}
+
+		guest.HouseholdID = id
+		if err := putGuest(tx, *guest); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindDuplicates returns groups of guests whose phone numbers are the same once normalized.
+// Numbers are normalized on insert, so this only finds rows written before normalization changed.
+func (s *SQLiteStorage) FindDuplicates() [][]models.Guest {
+	return findDuplicates(s.GetAllGuests())
+}
+
+// Stats returns RSVP counts and the expected headcount
+func (s *SQLiteStorage) Stats() models.RSVPStats {
+	return computeStats(s.GetAllGuests())
+}
+
+// SearchGuests returns guests whose name or phone number contains the query.
+// Matching is done in Go since SQLite's LIKE is only case-insensitive for ASCII.
+func (s *SQLiteStorage) SearchGuests(query string) []models.Guest {
+	return searchGuests(s.GetAllGuests(), query)
+}
+
+// GetGuestsByName returns the guests with the given full name
+func (s *SQLiteStorage) GetGuestsByName(name string) []models.Guest {
+	return guestsByName(s.GetAllGuests(), name)
+}
+
+// FindGuestsByPhoneSuffix returns guests whose primary or an alternate number ends with suffix
+func (s *SQLiteStorage) FindGuestsByPhoneSuffix(suffix string) []models.Guest {
+	var result []models.Guest
+	for _, g := range s.GetAllGuests() {
+		if hasPhoneSuffix(g, suffix) {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// GetGuestsInvitedBefore returns guests invited before t, leaving out those with no invitation date.
+// Dates are compared in Go since they're stored as text with a time zone offset.
+func (s *SQLiteStorage) GetGuestsInvitedBefore(t time.Time) []models.Guest {
+	return invitedBefore(s.GetAllGuests(), t)
+}
+
+// GetGuestsByRSVPDateRange returns guests who last replied in [from, to), where a zero bound is open
+func (s *SQLiteStorage) GetGuestsByRSVPDateRange(from, to time.Time) []models.Guest {
+	return rsvpDateInRange(s.GetAllGuests(), from, to)
+}
+
+// Backup writes a copy of all guests to path as JSON
+func (s *SQLiteStorage) Backup(path string) error {
+	guests, err := s.queryGuests("SELECT data FROM guests ORDER BY id")
+	if err != nil {
+		return err
+	}
+	return writeGuestsBackup(path, guests)
+}
+
+// Restore replaces all guests with the ones in a backup written by Backup.
+// Nothing is changed if the backup can't be read or isn't a valid guest list.
+func (s *SQLiteStorage) Restore(path string) error {
+	guests, err := readGuestsBackup(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM guests"); err != nil {
+		return fmt.Errorf("failed to clear guests: %w", err)
+	}
+	for _, g := range guests {
+		if err := putGuest(tx, g); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RenormalizePhoneNumbers re-runs normalize over every stored phone number.
+// Guests whose numbers collide after normalization are merged into a single record.
+// The current data is backed up as JSON before anything is changed.
+func (s *SQLiteStorage) RenormalizePhoneNumbers(normalize func(string) string) ([]NumberChange, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanGuests(tx.Query("SELECT data FROM guests ORDER BY id"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	backupPath, err := writeBackup(s.file, current)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to back up guests: %w", err)
+	}
+
+	guests, changes := renormalizeGuests(current, normalize)
+	if len(changes) == 0 {
+		return nil, backupPath, nil
+	}
+
+	if _, err := tx.Exec("DELETE FROM guests"); err != nil {
+		return nil, backupPath, fmt.Errorf("failed to clear guests: %w", err)
+	}
+	for _, g := range guests {
+		if err := putGuest(tx, g); err != nil {
+			return nil, backupPath, err
+		}
+	}
+
+	return changes, backupPath, tx.Commit()
+}
+
+// update applies fn to the stored guest inside a transaction
+func (s *SQLiteStorage) update(phoneNumber string, fn func(*models.Guest)) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	guest, err := findGuest(tx, phone.Normalize(phoneNumber))
+	if err != nil {
+		return err
+	}
+	if guest == nil {
+		return fmt.Errorf("guest not found")
+	}
+
+	fn(guest)
+	if err := putGuest(tx, *guest); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryGuests runs a query selecting the data column and decodes the rows
+func (s *SQLiteStorage) queryGuests(query string, args ...any) ([]models.Guest, error) {
+	return scanGuests(s.db.Query(query, args...))
+}
+
+// checkPhonesFree returns an error if any of the guest's numbers reaches a guest other than the one
+// stored under owner
+func checkPhonesFree(q rowQuerier, guest models.Guest, owner string) error {
+	for _, number := range phoneNumbers(guest) {
+		other, err := findGuest(q, number)
+		if err != nil {
+			return err
+		}
+		if other != nil && other.PhoneNumber != owner {
+			return fmt.Errorf("another guest already has phone number %s", number)
+		}
+	}
+	return nil
+}
+
+// findGuest loads the guest with phoneNumber as their primary or an alternate number,
+// returning nil if there is none
+func findGuest(q rowQuerier, phoneNumber string) (*models.Guest, error) {
+	return scanGuest(q.QueryRow(`
+		SELECT data FROM guests
+		WHERE phone_number = ?1
+			OR EXISTS (SELECT 1 FROM json_each(guests.data, '$.alternate_phones') WHERE value = ?1)
+		ORDER BY phone_number = ?1 DESC
+		LIMIT 1`,
+		phoneNumber,
+	))
+}
+
+// scanGuest decodes the data column of a single row, returning nil if there was no row
+func scanGuest(row *sql.Row) (*models.Guest, error) {
+	var data string
+	err := row.Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guest: %w", err)
+	}
+
+	var guest models.Guest
+	if err := json.Unmarshal([]byte(data), &guest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal guest: %w", err)
+	}
+	return &guest, nil
+}
+
+// putGuest inserts or replaces a guest, keeping its original position
+func putGuest(q rowQuerier, guest models.Guest) error {
+	data, err := json.Marshal(guest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal guest: %w", err)
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO guests (phone_number, rsvp_status, data) VALUES (?, ?, ?)
+		ON CONFLICT (phone_number) DO UPDATE SET rsvp_status = excluded.rsvp_status, data = excluded.data`,
+		guest.PhoneNumber, string(guest.RSVPStatus), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save guest: %w", err)
+	}
+	return nil
+}
+
+// scanGuests decodes the data column of every row
+func scanGuests(rows *sql.Rows, err error) ([]models.Guest, error) {
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guests: %w", err)
+	}
+	defer rows.Close()
+
+	guests := make([]models.Guest, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan guest: %w", err)
+		}
+
+		var guest models.Guest
+		if err := json.Unmarshal([]byte(data), &guest); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal guest: %w", err)
+		}
+		guests = append(guests, guest)
+	}
+	return guests, rows.Err()
+}
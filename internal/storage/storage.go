@@ -2,19 +2,26 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"wedding-whatsapp/internal/models"
 )
 
+// Storage is the JSON file backed implementation of Store
 type Storage struct {
 	mu     sync.RWMutex
 	guests []models.Guest
 	file   string
+
+	// contacts are numbers that messaged without being invited, kept in their own file
+	contacts []models.UnknownContact
 }
 
 // NewStorage creates a new storage instance
@@ -30,70 +37,439 @@ func NewStorage(filePath string) (*Storage, error) {
 			return nil, fmt.Errorf("failed to load storage: %w", err)
 		}
 	}
+	if err := s.loadContacts(); err != nil {
+		return nil, fmt.Errorf("failed to load unknown contacts: %w", err)
+	}
 
 	return s, nil
 }
 
-// AddGuest adds a new guest or updates existing one
+// AddGuest adds a new guest, returning ErrGuestExists if a guest already has the phone number
 func (s *Storage) AddGuest(guest models.Guest) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Check if guest already exists
+	normalizePhones(&guest)
+	if err := validatePhones(guest); err != nil {
+		return err
+	}
+	// However its number was written
+	for _, g := range s.guests {
+		if hasPhone(g, guest.PhoneNumber) {
+			return fmt.Errorf("%s: %w", guest.PhoneNumber, ErrGuestExists)
+		}
+	}
+	if err := s.checkPhonesFree(guest, -1); err != nil {
+		return err
+	}
+
+	s.guests = append(s.guests, newGuest(guest))
+	return s.Save()
+}
+
+// UpsertGuest adds a new guest, or merges the guest into the existing one with the phone number
+// following the rules of mergeGuest
+func (s *Storage) UpsertGuest(guest models.Guest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizePhones(&guest)
+	if err := validatePhones(guest); err != nil {
+		return err
+	}
 	for i, g := range s.guests {
-		if g.PhoneNumber == guest.PhoneNumber {
-			// Update existing guest
-			guest.InvitedDate = g.InvitedDate
-			if guest.RSVPStatus == models.RSVPNotInvited {
-				guest.RSVPStatus = g.RSVPStatus
+		if hasPhone(g, guest.PhoneNumber) {
+			merged := mergeGuest(g, guest)
+			if err := s.checkPhonesFree(merged, i); err != nil {
+				return err
 			}
-			s.guests[i] = guest
+			s.guests[i] = merged
 			return s.Save()
 		}
 	}
 
-	// Add new guest
-	if guest.InvitedDate.IsZero() {
-		guest.InvitedDate = time.Now()
+	if err := s.checkPhonesFree(guest, -1); err != nil {
+		return err
 	}
-	if guest.RSVPStatus == "" {
-		guest.RSVPStatus = models.RSVPPending
-	}
-	s.guests = append(s.guests, guest)
+	s.guests = append(s.guests, newGuest(guest))
 	return s.Save()
 }
 
-// GetGuest retrieves a guest by phone number
+// GetGuest retrieves a guest by their primary or an alternate phone number
 func (s *Storage) GetGuest(phoneNumber string) (*models.Guest, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, g := range s.guests {
-		if g.PhoneNumber == phoneNumber {
+		if hasPhone(g, phoneNumber) {
+			return &g, nil
+		}
+	}
+	return nil, fmt.Errorf("guest not found")
+}
+
+// GetGuestByToken returns the guest whose web RSVP link has the given token
+func (s *Storage) GetGuestByToken(token string) (*models.Guest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if token == "" {
+		return nil, fmt.Errorf("guest not found")
+	}
+	for _, g := range s.guests {
+		if g.RSVPToken == token {
 			return &g, nil
 		}
 	}
 	return nil, fmt.Errorf("guest not found")
 }
 
-// UpdateRSVP updates the RSVP status for a guest
-func (s *Storage) UpdateRSVP(phoneNumber string, status models.RSVPStatus, notes string) error {
+// UpdateRSVP updates the RSVP status for a guest, recording the message that changed it in their history
+func (s *Storage) UpdateRSVP(phoneNumber string, status models.RSVPStatus, notes, message string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for i, g := range s.guests {
-		if g.PhoneNumber == phoneNumber {
-			s.guests[i].RSVPStatus = status
-			s.guests[i].RSVPDate = time.Now()
-			if notes != "" {
-				s.guests[i].Notes = notes
-			}
+		if hasPhone(g, phoneNumber) {
+			applyRSVP(&s.guests[i], status, notes, message)
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// GetHistory returns the guest's RSVP answers, oldest first
+func (s *Storage) GetHistory(phoneNumber string) ([]models.RSVPEvent, error) {
+	guest, err := s.GetGuest(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	return guest.History, nil
+}
+
+// UpdateGuest replaces the guest with phoneNumber as their primary or an alternate number with updated.
+// The phone numbers may change as long as they don't collide with another guest's.
+func (s *Storage) UpdateGuest(phoneNumber string, updated models.Guest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizePhones(&updated)
+	if err := validatePhones(updated); err != nil {
+		return err
+	}
+	index := s.indexOf(phoneNumber)
+	if index == -1 {
+		return fmt.Errorf("guest not found")
+	}
+	if err := s.checkPhonesFree(updated, index); err != nil {
+		return err
+	}
+
+	s.guests[index] = updated
+	return s.Save()
+}
+
+// DeleteGuest removes a guest by their primary or an alternate phone number
+func (s *Storage) DeleteGuest(phoneNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(phoneNumber)
+	if i == -1 {
+		return fmt.Errorf("guest not found")
+	}
+	s.guests = append(s.guests[:i], s.guests[i+1:]...)
+	return s.Save()
+}
+
+// UpdatePartySize updates how many people are coming with the guest, including themselves
+func (s *Storage) UpdatePartySize(phoneNumber string, size int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].PartySize = size
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetLastReminderDate records when the guest was last sent an RSVP reminder
+func (s *Storage) SetLastReminderDate(phoneNumber string, date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].LastReminderDate = date
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetConfirmationDelivered records whether the RSVP confirmation reached the guest
+func (s *Storage) SetConfirmationDelivered(phoneNumber string, delivered bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].ConfirmationDelivered = delivered
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// UpdateMeal records the guest's meal preference
+func (s *Storage) UpdateMeal(phoneNumber, pref string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].MealPreference = pref
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// AssignTable seats the guest at a table, or unseats them when table is 0
+func (s *Storage) AssignTable(phoneNumber string, table int) error {
+	if table < 0 {
+		return fmt.Errorf("invalid table number %d", table)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].TableNumber = table
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetConversationState records what the bot is waiting for from the guest
+func (s *Storage) SetConversationState(phoneNumber string, state models.ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].ConversationState = state
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetLanguage records the language the guest writes in
+func (s *Storage) SetLanguage(phoneNumber, language string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].Language = language
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetRSVPToken sets the token of the guest's web RSVP link
+func (s *Storage) SetRSVPToken(phoneNumber, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].RSVPToken = token
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// MarkNotInvited sets the guest back to not_invited, clearing their invitation and RSVP dates
+func (s *Storage) MarkNotInvited(phoneNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].RSVPStatus = models.RSVPNotInvited
+			s.guests[i].InvitedDate = time.Time{}
+			s.guests[i].RSVPDate = time.Time{}
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetJID records the WhatsApp JID the guest's number was verified at, empty to forget it
+func (s *Storage) SetJID(phoneNumber, jid string, verifiedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].JID = jid
+			s.guests[i].JIDVerifiedAt = verifiedAt
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// SetDoNotContact records whether the guest asked to stop being messaged
+func (s *Storage) SetDoNotContact(phoneNumber string, doNotContact bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].DoNotContact = doNotContact
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// RecordMessageSent starts tracking delivery of a message sent to the guest
+func (s *Storage) RecordMessageSent(phoneNumber, messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].LastMessageID = messageID
+			s.guests[i].DeliveryStatus = models.DeliverySent
+			s.guests[i].Unreachable = false
+			s.guests[i].LastError = ""
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// MarkUnreachable records that messages can't be sent to the guest
+func (s *Storage) MarkUnreachable(phoneNumber, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].Unreachable = true
+			s.guests[i].LastError = reason
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// RecordSendAttempt adds an attempt to send the guest a message to their send attempts
+func (s *Storage) RecordSendAttempt(phoneNumber string, attempt models.SendAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			appendSendAttempt(&s.guests[i], attempt)
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("guest not found")
+}
+
+// IncrementResendCount records that the guest's invitation was sent again
+func (s *Storage) IncrementResendCount(phoneNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if hasPhone(g, phoneNumber) {
+			s.guests[i].ResendCount++
 			return s.Save()
 		}
 	}
 	return fmt.Errorf("guest not found")
 }
 
+// UpdateDeliveryStatus advances the delivery status of the guest whose last message has the given ID.
+// Receipts for messages that aren't tracked are ignored.
+func (s *Storage) UpdateDeliveryStatus(messageID string, status models.DeliveryStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, g := range s.guests {
+		if g.LastMessageID == messageID && status.After(g.DeliveryStatus) {
+			s.guests[i].DeliveryStatus = status
+			return s.Save()
+		}
+	}
+	return nil
+}
+
+// FindDuplicates returns groups of guests whose phone numbers are the same once normalized
+func (s *Storage) FindDuplicates() [][]models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return findDuplicates(s.guests)
+}
+
+// GetHousehold returns the guests in the household with the given ID
+func (s *Storage) GetHousehold(id string) []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]models.Guest, 0)
+	if id == "" {
+		return result
+	}
+	for _, g := range s.guests {
+		if g.HouseholdID == id {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// SetHousehold puts the guests with the given phone numbers in household id, or takes them out of
+// their household when id is empty. Nothing is changed if any of the numbers is unknown.
+func (s *Storage) SetHousehold(phones []string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexes := make([]int, 0, len(phones))
+	for _, phoneNumber := range phones {
+		index := -1
+		for i, g := range s.guests {
+			if hasPhone(g, phoneNumber) {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return fmt.Errorf("guest not found: %s", phoneNumber)
+		}
+		indexes = append(indexes, index)
+	}
+
+	for _, i := range indexes {
+		s.guests[i].HouseholdID = id
+	}
+	return s.Save()
+}
+
 // GetAllGuests returns all guests
 func (s *Storage) GetAllGuests() []models.Guest {
 	s.mu.RLock()
@@ -118,37 +494,290 @@ func (s *Storage) GetGuestsByStatus(status models.RSVPStatus) []models.Guest {
 	return result
 }
 
-// Save saves the guests to file
+// GetReadUnanswered returns pending guests who have read the last message we sent them
+func (s *Storage) GetReadUnanswered() []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []models.Guest
+	for _, g := range s.guests {
+		if g.RSVPStatus == models.RSVPPending && g.DeliveryStatus == models.DeliveryRead {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// RecordUnknownContact logs a message from a number that isn't on the guest list.
+// It reports whether this was the first message from the number.
+func (s *Storage) RecordUnknownContact(phoneNumber, text string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var first bool
+	s.contacts, first = recordContact(s.contacts, phoneNumber, text, time.Now())
+	return first, s.saveContacts()
+}
+
+// GetUnknownContacts returns the numbers that messaged without being invited, in the order they first did
+func (s *Storage) GetUnknownContacts() []models.UnknownContact {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.UnknownContact(nil), s.contacts...)
+}
+
+// Stats returns RSVP counts and the expected headcount
+func (s *Storage) Stats() models.RSVPStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return computeStats(s.guests)
+}
+
+// SearchGuests returns guests whose name or phone number contains the query
+func (s *Storage) SearchGuests(query string) []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return searchGuests(s.guests, query)
+}
+
+// GetGuestsByName returns the guests with the given full name
+func (s *Storage) GetGuestsByName(name string) []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return guestsByName(s.guests, name)
+}
+
+// FindGuestsByPhoneSuffix returns guests whose primary or an alternate number ends with suffix
+func (s *Storage) FindGuestsByPhoneSuffix(suffix string) []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []models.Guest
+	for _, g := range s.guests {
+		if hasPhoneSuffix(g, suffix) {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// GetGuestsInvitedBefore returns guests invited before t, leaving out those with no invitation date
+func (s *Storage) GetGuestsInvitedBefore(t time.Time) []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return invitedBefore(s.guests, t)
+}
+
+// GetGuestsByRSVPDateRange returns guests who last replied in [from, to), where a zero bound is open
+func (s *Storage) GetGuestsByRSVPDateRange(from, to time.Time) []models.Guest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return rsvpDateInRange(s.guests, from, to)
+}
+
+// Backup writes a copy of all guests to path
+func (s *Storage) Backup(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return writeGuestsBackup(path, s.guests)
+}
+
+// Restore replaces all guests with the ones in a backup written by Backup.
+// Nothing is changed if the backup can't be read or isn't a valid guest list.
+func (s *Storage) Restore(path string) error {
+	guests, err := readGuestsBackup(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.guests = guests
+	return s.Save()
+}
+
+// RenormalizePhoneNumbers re-runs normalize over every stored phone number.
+// Guests whose numbers collide after normalization are merged into a single record.
+// The current data is backed up before anything is changed.
+func (s *Storage) RenormalizePhoneNumbers(normalize func(string) string) ([]NumberChange, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backupPath, err := writeBackup(s.file, s.guests)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to back up guests: %w", err)
+	}
+
+	guests, changes := renormalizeGuests(s.guests, normalize)
+	if len(changes) == 0 {
+		return nil, backupPath, nil
+	}
+
+	s.guests = guests
+	return changes, backupPath, s.Save()
+}
+
+// Save saves the guests to file.
+// The data is written to a temp file first and renamed over the target so a crash never leaves it half written.
+// The previous version is moved to a .bak copy first, so Load can recover if the new one is ever corrupted.
 func (s *Storage) Save() error {
-	data, err := json.MarshalIndent(s.guests, "", "  ")
+	data, err := encodeGuests(s.guests)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
 
 	// Ensure directory exists
 	dir := filepath.Dir(s.file)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	return os.WriteFile(s.file, data, 0644)
+	// Only a readable list with guests replaces the backup, so recovering from a damaged file keeps it
+	if previous, err := os.ReadFile(s.file); err == nil {
+		if guests, err := decodeGuests(previous); err == nil && len(guests) > 0 {
+			if err := writeFileAtomic(s.backupFile(), previous, fileMode); err != nil {
+				return fmt.Errorf("failed to write backup: %w", err)
+			}
+		}
+	}
+	return writeFileAtomic(s.file, data, fileMode)
+}
+
+// CheckWritable reports an error if guests can't currently be saved
+func (s *Storage) CheckWritable() error {
+	return checkDirWritable(filepath.Dir(s.file))
 }
 
-// Load loads guests from file
-func (s *Storage) Load() error {
-	data, err := os.ReadFile(s.file)
+// indexOf returns the index of the guest with phoneNumber as their primary or an alternate number,
+// or -1 if there is none. A primary number match wins over an alternate one.
+func (s *Storage) indexOf(phoneNumber string) int {
+	index := -1
+	for i, g := range s.guests {
+		if samePhone(g.PhoneNumber, phoneNumber) {
+			return i
+		}
+		if index == -1 && hasPhone(g, phoneNumber) {
+			index = i
+		}
+	}
+	return index
+}
+
+// checkPhonesFree returns an error if any of the guest's numbers reaches a guest other than the one at skip
+func (s *Storage) checkPhonesFree(guest models.Guest, skip int) error {
+	for i, g := range s.guests {
+		if i == skip {
+			continue
+		}
+		if number := sharedPhone(guest, g); number != "" {
+			return fmt.Errorf("another guest already has phone number %s", number)
+		}
+	}
+	return nil
+}
+
+// contactsFile returns the path unknown contacts are saved to, next to the guest list
+func (s *Storage) contactsFile() string {
+	return strings.TrimSuffix(s.file, filepath.Ext(s.file)) + "_unknown_contacts.json"
+}
+
+// saveContacts writes the unknown contacts to their file
+func (s *Storage) saveContacts() error {
+	data, err := json.MarshalIndent(s.contacts, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), dirMode); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
+	return writeFileAtomic(s.contactsFile(), data, fileMode)
+}
 
-	if len(data) == 0 {
-		s.guests = make([]models.Guest, 0)
+// loadContacts reads the unknown contacts, if any have been saved
+func (s *Storage) loadContacts() error {
+	data, err := os.ReadFile(s.contactsFile())
+	if errors.Is(err, fs.ErrNotExist) || len(data) == 0 {
 		return nil
 	}
-
-	if err := json.Unmarshal(data, &s.guests); err != nil {
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.contacts); err != nil {
 		return fmt.Errorf("failed to unmarshal data: %w", err)
 	}
+	return nil
+}
+
+// backupFile returns the path the previous version of the guest list is kept at
+func (s *Storage) backupFile() string {
+	return s.file + ".bak"
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
 
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
 	return nil
 }
+
+// Load loads guests from file, falling back to the backup copy if the file is corrupt.
+// A valid but empty list is kept, since it's what's left after the last guest is deleted.
+func (s *Storage) Load() error {
+	guests, err := readGuestsFile(s.file)
+	if err != nil {
+		if backup, backupErr := readGuestsFile(s.backupFile()); backupErr == nil && len(backup) > 0 {
+			fmt.Printf("⚠️ %s is unreadable, restored %d guests from %s\n", s.file, len(backup), s.backupFile())
+			s.guests = backup
+			return s.Save()
+		}
+		return err
+	}
+
+	s.guests = guests
+	return nil
+}
+
+// readGuestsFile reads and decodes a JSON guest list, upgrading it if it's in an older format
+func readGuestsFile(path string) ([]models.Guest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	guests, err := decodeGuests(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return guests, nil
+}
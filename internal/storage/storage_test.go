@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestSaveKeepsPreviousVersionAsBackup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "guests.json")
+	s, err := NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	for _, phoneNumber := range []string{"972501234567", "972509876543"} {
+		if err := s.AddGuest(models.Guest{PhoneNumber: phoneNumber}); err != nil {
+			t.Fatalf("AddGuest(%s): %v", phoneNumber, err)
+		}
+	}
+
+	backup, err := readGuestsFile(file + ".bak")
+	if err != nil {
+		t.Fatalf("reading backup: %v", err)
+	}
+	if len(backup) != 1 || backup[0].PhoneNumber != "972501234567" {
+		t.Errorf("backup = %+v, want the list from before the last save", backup)
+	}
+
+	// A bad write is rolled back to the previous version, which stays backed up
+	if err := os.WriteFile(file, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage after corruption: %v", err)
+	}
+	if got := len(reloaded.GetAllGuests()); got != 1 {
+		t.Errorf("restored %d guests, want 1", got)
+	}
+	if backup, err := readGuestsFile(file + ".bak"); err != nil || len(backup) != 1 {
+		t.Errorf("backup after recovery = %+v, %v, want it kept", backup, err)
+	}
+}
+
+func TestDeletingLastGuestSurvivesReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "guests.json")
+	s, err := NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	if err := s.AddGuest(models.Guest{PhoneNumber: "972501234567"}); err != nil {
+		t.Fatalf("AddGuest: %v", err)
+	}
+	if err := s.DeleteGuest("972501234567"); err != nil {
+		t.Fatalf("DeleteGuest: %v", err)
+	}
+
+	reloaded, err := NewStorage(file)
+	if err != nil {
+		t.Fatalf("NewStorage after delete: %v", err)
+	}
+	if guests := reloaded.GetAllGuests(); len(guests) != 0 {
+		t.Errorf("guests after reload = %+v, want the deleted guest to stay deleted", guests)
+	}
+}
@@ -0,0 +1,582 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+	"wedding-whatsapp/internal/phone"
+)
+
+// Store is the guest storage used by the RSVP handler and CLI.
+// It is implemented by the JSON file backed Storage and by SQLiteStorage.
+type Store interface {
+	AddGuest(guest models.Guest) error
+	UpsertGuest(guest models.Guest) error
+	GetGuest(phoneNumber string) (*models.Guest, error)
+	GetGuestByToken(token string) (*models.Guest, error)
+	UpdateRSVP(phoneNumber string, status models.RSVPStatus, notes, message string) error
+	GetHistory(phoneNumber string) ([]models.RSVPEvent, error)
+	UpdateGuest(phoneNumber string, updated models.Guest) error
+	DeleteGuest(phoneNumber string) error
+	UpdatePartySize(phoneNumber string, size int) error
+	SetLastReminderDate(phoneNumber string, date time.Time) error
+	SetConfirmationDelivered(phoneNumber string, delivered bool) error
+	UpdateMeal(phoneNumber, pref string) error
+	AssignTable(phoneNumber string, table int) error
+	RecordMessageSent(phoneNumber, messageID string) error
+	IncrementResendCount(phoneNumber string) error
+	RecordSendAttempt(phoneNumber string, attempt models.SendAttempt) error
+	MarkUnreachable(phoneNumber, reason string) error
+	UpdateDeliveryStatus(messageID string, status models.DeliveryStatus) error
+	SetConversationState(phoneNumber string, state models.ConversationState) error
+	SetLanguage(phoneNumber, language string) error
+	SetRSVPToken(phoneNumber, token string) error
+	MarkNotInvited(phoneNumber string) error
+	SetJID(phoneNumber, jid string, verifiedAt time.Time) error
+	SetDoNotContact(phoneNumber string, doNotContact bool) error
+	GetAllGuests() []models.Guest
+	GetGuestsByStatus(status models.RSVPStatus) []models.Guest
+	GetReadUnanswered() []models.Guest
+	SearchGuests(query string) []models.Guest
+	GetGuestsByName(name string) []models.Guest
+	FindGuestsByPhoneSuffix(suffix string) []models.Guest
+	GetGuestsInvitedBefore(t time.Time) []models.Guest
+	GetGuestsByRSVPDateRange(from, to time.Time) []models.Guest
+	FindDuplicates() [][]models.Guest
+	GetHousehold(id string) []models.Guest
+	SetHousehold(phones []string, id string) error
+	RecordUnknownContact(phoneNumber, text string) (bool, error)
+	GetUnknownContacts() []models.UnknownContact
+	Stats() models.RSVPStats
+	Backup(path string) error
+	Restore(path string) error
+	RenormalizePhoneNumbers(normalize func(string) string) ([]NumberChange, string, error)
+	ExportCSV(w io.Writer) error
+	CheckWritable() error
+}
+
+var (
+	_ Store = (*Storage)(nil)
+	_ Store = (*SQLiteStorage)(nil)
+)
+
+// fileMode and dirMode are the permissions guest data files, and the directories created for them, are given.
+// The files hold guests' names and numbers, so by default only the bot's own user can read them.
+var (
+	fileMode os.FileMode = 0600
+	dirMode  os.FileMode = 0700
+)
+
+// SetPermissions sets the permissions guest data files and the directories created for them are given.
+// Files already written get them the next time they're saved.
+func SetPermissions(file, dir os.FileMode) {
+	fileMode, dirMode = file, dir
+}
+
+// ErrGuestExists is returned by AddGuest when a guest already has the phone number
+var ErrGuestExists = errors.New("guest already exists")
+
+// ErrInvalidPhone is returned when a guest's phone number isn't a plausible number once normalized
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// checkDirWritable reports an error unless a file can be created in dir, e.g. because the disk is full or read-only
+func checkDirWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file in %s: %w", dir, err)
+	}
+	defer os.Remove(probe.Name())
+
+	if err := probe.Close(); err != nil {
+		return fmt.Errorf("failed to write file in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// validatePhones checks the guest's normalized primary and alternate numbers
+func validatePhones(g models.Guest) error {
+	for _, number := range phoneNumbers(g) {
+		if !phone.IsValid(number) {
+			return fmt.Errorf("%q: %w, expected 7-15 digits", number, ErrInvalidPhone)
+		}
+	}
+	return nil
+}
+
+// samePhone reports whether two phone numbers refer to the same person, however they were written
+func samePhone(a, b string) bool {
+	return a == b || phone.Normalize(a) == phone.Normalize(b)
+}
+
+// hasPhone reports whether the number is the guest's primary or one of their alternate numbers
+func hasPhone(g models.Guest, phoneNumber string) bool {
+	if samePhone(g.PhoneNumber, phoneNumber) {
+		return true
+	}
+	for _, alternate := range g.AlternatePhones {
+		if samePhone(alternate, phoneNumber) {
+			return true
+		}
+	}
+	return false
+}
+
+// phoneNumbers returns the guest's primary number followed by their alternate numbers
+func phoneNumbers(g models.Guest) []string {
+	return append([]string{g.PhoneNumber}, g.AlternatePhones...)
+}
+
+// sharedPhone returns the first of the guest's numbers that also reaches other, or "" if none does
+func sharedPhone(g, other models.Guest) string {
+	for _, number := range phoneNumbers(g) {
+		if hasPhone(other, number) {
+			return number
+		}
+	}
+	return ""
+}
+
+// normalizePhones normalizes the guest's numbers, dropping alternates that are empty or repeated
+func normalizePhones(g *models.Guest) {
+	g.PhoneNumber = phone.Normalize(g.PhoneNumber)
+
+	var alternates []string
+	for _, alternate := range g.AlternatePhones {
+		alternate = phone.Normalize(alternate)
+		if alternate != "" && alternate != g.PhoneNumber && !slices.Contains(alternates, alternate) {
+			alternates = append(alternates, alternate)
+		}
+	}
+	g.AlternatePhones = alternates
+}
+
+// findDuplicates groups guests whose phone numbers normalize to the same number
+func findDuplicates(guests []models.Guest) [][]models.Guest {
+	groups := make(map[string][]models.Guest)
+	var order []string
+	for _, g := range guests {
+		number := phone.Normalize(g.PhoneNumber)
+		if _, ok := groups[number]; !ok {
+			order = append(order, number)
+		}
+		groups[number] = append(groups[number], g)
+	}
+
+	duplicates := make([][]models.Guest, 0)
+	for _, number := range order {
+		if len(groups[number]) > 1 {
+			duplicates = append(duplicates, groups[number])
+		}
+	}
+	return duplicates
+}
+
+// newGuest fills in the defaults of a guest being stored for the first time
+func newGuest(guest models.Guest) models.Guest {
+	if guest.InvitedDate.IsZero() && guest.RSVPStatus != models.RSVPNotInvited {
+		guest.InvitedDate = time.Now()
+	}
+	if guest.RSVPStatus == "" {
+		guest.RSVPStatus = models.RSVPPending
+	}
+	return guest
+}
+
+// mergeGuest applies the UpsertGuest rules to an incoming guest with the same number as an existing one:
+//   - The existing primary phone number is kept, so a guest matched by an alternate number stays the same guest.
+//   - Name, notes, custom message, household, side, tier, table, party size, meal, RSVP deadline, language,
+//     RSVP token and alternate numbers are replaced only by non-empty incoming values.
+//   - The RSVP status and date are kept once the guest has been invited; only a guest still
+//     not_invited takes the incoming status, and is then counted as invited now.
+//     Answers change through UpdateRSVP, which records them in the history.
+//   - The history, invitation date, delivery tracking, reminders, conversation state,
+//     unreachable and do-not-contact flags and verified JID are always kept, as they're only changed
+//     by their own methods.
+func mergeGuest(existing, incoming models.Guest) models.Guest {
+	merged := existing
+
+	mergeField(&merged.Name, incoming.Name)
+	mergeField(&merged.Notes, incoming.Notes)
+	mergeField(&merged.CustomMessage, incoming.CustomMessage)
+	mergeField(&merged.HouseholdID, incoming.HouseholdID)
+	mergeField(&merged.Side, incoming.Side)
+	mergeField(&merged.Tier, incoming.Tier)
+	mergeField(&merged.TableNumber, incoming.TableNumber)
+	mergeField(&merged.PartySize, incoming.PartySize)
+	mergeField(&merged.MealPreference, incoming.MealPreference)
+	if !incoming.RSVPDeadline.IsZero() {
+		merged.RSVPDeadline = incoming.RSVPDeadline
+	}
+	mergeField(&merged.Language, incoming.Language)
+	mergeField(&merged.RSVPToken, incoming.RSVPToken)
+	if len(incoming.AlternatePhones) > 0 {
+		merged.AlternatePhones = incoming.AlternatePhones
+		normalizePhones(&merged)
+	}
+
+	notInvited := existing.RSVPStatus == "" || existing.RSVPStatus == models.RSVPNotInvited
+	if notInvited && incoming.RSVPStatus != "" && incoming.RSVPStatus != existing.RSVPStatus {
+		merged.RSVPStatus = incoming.RSVPStatus
+		merged.RSVPDate = incoming.RSVPDate
+		merged.InvitedDate = time.Now()
+	}
+	return merged
+}
+
+// mergeField replaces the existing value with the incoming one unless it's the zero value
+func mergeField[T comparable](existing *T, incoming T) {
+	var zero T
+	if incoming != zero {
+		*existing = incoming
+	}
+}
+
+// recordContact counts a message from an unknown number in contacts, adding it if it's new.
+// It reports whether this was the first message from the number.
+func recordContact(contacts []models.UnknownContact, phoneNumber, text string, now time.Time) ([]models.UnknownContact, bool) {
+	phoneNumber = phone.Normalize(phoneNumber)
+	for i, c := range contacts {
+		if samePhone(c.PhoneNumber, phoneNumber) {
+			contacts[i].LastSeen = now
+			contacts[i].MessageCount++
+			contacts[i].LastMessage = text
+			return contacts, false
+		}
+	}
+	return append(contacts, models.UnknownContact{
+		PhoneNumber:  phoneNumber,
+		FirstSeen:    now,
+		LastSeen:     now,
+		MessageCount: 1,
+		LastMessage:  text,
+	}), true
+}
+
+// applyRSVP sets the guest's RSVP status and appends the answer to their history
+func applyRSVP(g *models.Guest, status models.RSVPStatus, notes, message string) {
+	now := time.Now()
+	g.History = append(g.History, models.RSVPEvent{
+		Timestamp: now,
+		OldStatus: g.RSVPStatus,
+		NewStatus: status,
+		Message:   message,
+	})
+
+	g.RSVPStatus = status
+	g.RSVPDate = now
+	if notes != "" {
+		g.Notes = notes
+	}
+}
+
+// maxSendAttempts is how many send attempts are kept per guest; older ones are dropped
+const maxSendAttempts = 20
+
+// appendSendAttempt adds an attempt to the guest's send attempts
+func appendSendAttempt(g *models.Guest, attempt models.SendAttempt) {
+	g.SendAttempts = recentAttempts(append(g.SendAttempts, attempt))
+}
+
+// recentAttempts drops the oldest send attempts beyond maxSendAttempts
+func recentAttempts(attempts []models.SendAttempt) []models.SendAttempt {
+	if excess := len(attempts) - maxSendAttempts; excess > 0 {
+		return slices.Delete(attempts, 0, excess)
+	}
+	return attempts
+}
+
+// computeStats counts guests by RSVP status, household and side, and sums the accepted party sizes
+func computeStats(guests []models.Guest) models.RSVPStats {
+	stats := models.RSVPStats{Sides: make(map[string]models.SideStats)}
+	households := make(map[string]bool)
+	for _, g := range guests {
+		stats.Total++
+		side := stats.Sides[g.GuestSide()]
+		side.Total++
+		if g.HouseholdID == "" {
+			stats.Households++
+		} else if !households[g.HouseholdID] {
+			households[g.HouseholdID] = true
+			stats.Households++
+		}
+		switch g.RSVPStatus {
+		case models.RSVPPending:
+			stats.Pending++
+			side.Pending++
+		case models.RSVPAccepted:
+			stats.Accepted++
+			stats.Headcount += g.PartySize
+			side.Accepted++
+			side.Headcount += g.PartySize
+		case models.RSVPDeclined:
+			stats.Declined++
+			side.Declined++
+		case models.RSVPMaybe:
+			stats.Maybe++
+			side.Maybe++
+		case models.RSVPNotInvited:
+			stats.NotInvited++
+		}
+		stats.Sides[g.GuestSide()] = side
+		if g.DoNotContact {
+			stats.DoNotContact++
+		}
+	}
+	return stats
+}
+
+// searchGuests returns the guests whose name or phone number contains query, ignoring case
+// and extra whitespace. It never returns nil.
+func searchGuests(guests []models.Guest, query string) []models.Guest {
+	query = normalizeSearchText(query)
+	result := make([]models.Guest, 0)
+	if query == "" {
+		return result
+	}
+
+	for _, g := range guests {
+		if strings.Contains(normalizeSearchText(g.Name), query) || strings.Contains(g.PhoneNumber, query) {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// guestsByName returns the guests whose full name is name, ignoring case and extra whitespace.
+// Names aren't unique, so there may be several. It never returns nil.
+func guestsByName(guests []models.Guest, name string) []models.Guest {
+	name = normalizeSearchText(name)
+	result := make([]models.Guest, 0)
+	if name == "" {
+		return result
+	}
+
+	for _, g := range guests {
+		if normalizeSearchText(g.Name) == name {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// hasPhoneSuffix reports whether the guest's primary or an alternate number ends with suffix
+func hasPhoneSuffix(g models.Guest, suffix string) bool {
+	if suffix == "" {
+		return false
+	}
+	if strings.HasSuffix(g.PhoneNumber, suffix) {
+		return true
+	}
+	for _, alternate := range g.AlternatePhones {
+		if strings.HasSuffix(alternate, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSearchText lowercases text and collapses surrounding and repeated whitespace
+func normalizeSearchText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// NumberChange describes a phone number rewritten by RenormalizePhoneNumbers
+type NumberChange struct {
+	Name      string
+	OldNumber string
+	NewNumber string
+	Merged    bool // the guest collided with an existing record and was merged into it
+}
+
+// renormalizeGuests re-runs normalize over the guests' phone numbers, merging any that collide
+func renormalizeGuests(guests []models.Guest, normalize func(string) string) ([]models.Guest, []NumberChange) {
+	var changes []NumberChange
+	merged := make([]models.Guest, 0, len(guests))
+	index := make(map[string]int)
+
+	for _, g := range guests {
+		change := NumberChange{Name: g.Name, OldNumber: g.PhoneNumber, NewNumber: normalize(g.PhoneNumber)}
+		g.PhoneNumber = change.NewNumber
+		// The JID was verified for the old number
+		if change.NewNumber != change.OldNumber {
+			g.JID, g.JIDVerifiedAt = "", time.Time{}
+		}
+
+		alternates := make([]string, 0, len(g.AlternatePhones))
+		for _, alternate := range g.AlternatePhones {
+			alternates = append(alternates, normalize(alternate))
+		}
+		if len(alternates) > 0 {
+			g.AlternatePhones = alternates
+		}
+
+		if i, ok := index[g.PhoneNumber]; ok {
+			merged[i] = mergeGuests(merged[i], g)
+			change.Merged = true
+		} else {
+			index[g.PhoneNumber] = len(merged)
+			merged = append(merged, g)
+		}
+
+		if change.Merged || change.OldNumber != change.NewNumber {
+			changes = append(changes, change)
+		}
+	}
+
+	return merged, changes
+}
+
+// mergeGuests combines two records for the same phone number.
+// The most recent RSVP wins, while the earliest invitation date is kept.
+func mergeGuests(a, b models.Guest) models.Guest {
+	result := a
+	if b.RSVPDate.After(a.RSVPDate) {
+		result = b
+	}
+
+	if !a.InvitedDate.IsZero() && (b.InvitedDate.IsZero() || a.InvitedDate.Before(b.InvitedDate)) {
+		result.InvitedDate = a.InvitedDate
+	} else {
+		result.InvitedDate = b.InvitedDate
+	}
+
+	if result.Name == "" {
+		result.Name = a.Name + b.Name
+	}
+	if result.Notes == "" {
+		result.Notes = a.Notes + b.Notes
+	}
+	if result.TableNumber == 0 {
+		result.TableNumber = max(a.TableNumber, b.TableNumber)
+	}
+	if result.PartySize == 0 {
+		result.PartySize = max(a.PartySize, b.PartySize)
+	}
+	if result.MealPreference == "" {
+		result.MealPreference = a.MealPreference + b.MealPreference
+	}
+	if result.Side == "" {
+		result.Side = a.Side + b.Side
+	}
+	if result.Tier == "" {
+		result.Tier = a.Tier + b.Tier
+	}
+	// Asking either number to stop messaging counts for the merged guest
+	result.DoNotContact = a.DoNotContact || b.DoNotContact
+
+	// The history is append-only, so both records' answers are kept, in the order they were given
+	history := slices.Concat(a.History, b.History)
+	slices.SortStableFunc(history, func(x, y models.RSVPEvent) int {
+		return x.Timestamp.Compare(y.Timestamp)
+	})
+	result.History = history
+
+	// Either record's numbers still reach the merged guest
+	var alternates []string
+	for _, alternate := range slices.Concat(a.AlternatePhones, b.AlternatePhones) {
+		if alternate != result.PhoneNumber && !slices.Contains(alternates, alternate) {
+			alternates = append(alternates, alternate)
+		}
+	}
+	result.AlternatePhones = alternates
+
+	// Both records' send attempts are kept, in the order they happened
+	attempts := slices.Concat(a.SendAttempts, b.SendAttempts)
+	slices.SortStableFunc(attempts, func(x, y models.SendAttempt) int {
+		return x.Timestamp.Compare(y.Timestamp)
+	})
+	result.SendAttempts = recentAttempts(attempts)
+
+	return result
+}
+
+// invitedBefore returns guests invited before t. Guests with no invitation date are left out.
+func invitedBefore(guests []models.Guest, t time.Time) []models.Guest {
+	result := make([]models.Guest, 0)
+	for _, g := range guests {
+		if !g.InvitedDate.IsZero() && g.InvitedDate.Before(t) {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// rsvpDateInRange returns guests who last replied at or after from and before to.
+// A zero from or to leaves that end of the range open. Guests who never replied are left out.
+func rsvpDateInRange(guests []models.Guest, from, to time.Time) []models.Guest {
+	result := make([]models.Guest, 0)
+	for _, g := range guests {
+		if g.RSVPDate.IsZero() || g.RSVPDate.Before(from) || (!to.IsZero() && !g.RSVPDate.Before(to)) {
+			continue
+		}
+		result = append(result, g)
+	}
+	return result
+}
+
+// DefaultBackupPath returns a timestamped backup file name in dir
+func DefaultBackupPath(dir string) string {
+	return filepath.Join(dir, "backups", fmt.Sprintf("guests-%s.json", time.Now().Format("20060102-150405")))
+}
+
+// writeGuestsBackup writes the guests to path as JSON
+func writeGuestsBackup(path string, guests []models.Guest) error {
+	data, err := encodeGuests(guests)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return writeFileAtomic(path, data, fileMode)
+}
+
+// readGuestsBackup reads a backup written by Backup, refusing files that don't hold a usable guest list
+func readGuestsBackup(path string) ([]models.Guest, error) {
+	guests, err := readGuestsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(guests) == 0 {
+		return nil, fmt.Errorf("backup %s has no guests", path)
+	}
+
+	seen := make(map[string]bool, len(guests))
+	for i, g := range guests {
+		if g.PhoneNumber == "" || g.Name == "" {
+			return nil, fmt.Errorf("guest %d in backup is missing a name or phone number", i+1)
+		}
+		if seen[g.PhoneNumber] {
+			return nil, fmt.Errorf("backup has phone number %s more than once", g.PhoneNumber)
+		}
+		seen[g.PhoneNumber] = true
+	}
+	return guests, nil
+}
+
+// writeBackup writes the guests as JSON next to file with a timestamp suffix
+func writeBackup(file string, guests []models.Guest) (string, error) {
+	data, err := encodeGuests(guests)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), dirMode); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	backupPath := fmt.Sprintf("%s.%s.bak", file, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backupPath, data, fileMode); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return backupPath, nil
+}
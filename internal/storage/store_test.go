@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"wedding-whatsapp/internal/models"
+)
+
+func TestMergeGuestsKeepsBothHistories(t *testing.T) {
+	day := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := models.Guest{
+		PhoneNumber: "972501234567",
+		RSVPStatus:  models.RSVPDeclined,
+		RSVPDate:    day.Add(2 * time.Hour),
+		History: []models.RSVPEvent{
+			{Timestamp: day, OldStatus: models.RSVPPending, NewStatus: models.RSVPAccepted, Message: "yes"},
+			{Timestamp: day.Add(2 * time.Hour), OldStatus: models.RSVPAccepted, NewStatus: models.RSVPDeclined, Message: "sorry, no"},
+		},
+	}
+	b := models.Guest{
+		PhoneNumber: "972501234567",
+		RSVPStatus:  models.RSVPMaybe,
+		RSVPDate:    day.Add(time.Hour),
+		History: []models.RSVPEvent{
+			{Timestamp: day.Add(time.Hour), OldStatus: models.RSVPPending, NewStatus: models.RSVPMaybe, Message: "maybe"},
+		},
+	}
+
+	for _, merged := range []models.Guest{mergeGuests(a, b), mergeGuests(b, a)} {
+		if merged.RSVPStatus != models.RSVPDeclined {
+			t.Errorf("status = %s, want the most recent answer %s", merged.RSVPStatus, models.RSVPDeclined)
+		}
+		var messages []string
+		for _, event := range merged.History {
+			messages = append(messages, event.Message)
+		}
+		if len(messages) != 3 || messages[0] != "yes" || messages[1] != "maybe" || messages[2] != "sorry, no" {
+			t.Errorf("history = %q, want both records' answers in order", messages)
+		}
+	}
+}
+
+// testStores opens an empty guest list in a temp dir for each backend
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	dir := t.TempDir()
+	jsonStore, err := NewStorage(filepath.Join(dir, "guests.json"))
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	sqliteStore, err := NewSQLiteStorage(filepath.Join(dir, "guests.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage: %v", err)
+	}
+	t.Cleanup(func() { sqliteStore.Close() })
+	return map[string]Store{"json": jsonStore, "sqlite": sqliteStore}
+}
+
+func TestMergeGuestsKeepsBothAlternatePhones(t *testing.T) {
+	a := models.Guest{PhoneNumber: "972501234567", AlternatePhones: []string{"972521111111", "972532222222"}}
+	b := models.Guest{PhoneNumber: "972501234567", AlternatePhones: []string{"972532222222", "972543333333", "972501234567"}}
+
+	merged := mergeGuests(a, b)
+	want := []string{"972521111111", "972532222222", "972543333333"}
+	if !slices.Equal(merged.AlternatePhones, want) {
+		t.Errorf("alternate phones = %q, want %q", merged.AlternatePhones, want)
+	}
+}
+
+func TestUpdateAndDeleteByAlternatePhone(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			guest := models.Guest{PhoneNumber: "972501234567", Name: "Dana", AlternatePhones: []string{"972521111111"}}
+			if err := store.AddGuest(guest); err != nil {
+				t.Fatalf("AddGuest: %v", err)
+			}
+
+			guest.Name = "Dana Levi"
+			if err := store.UpdateGuest("052-111-1111", guest); err != nil {
+				t.Fatalf("UpdateGuest by alternate: %v", err)
+			}
+			if updated, err := store.GetGuest("972501234567"); err != nil || updated.Name != "Dana Levi" {
+				t.Errorf("GetGuest = %+v, %v, want the renamed guest", updated, err)
+			}
+
+			if err := store.DeleteGuest("972521111111"); err != nil {
+				t.Fatalf("DeleteGuest by alternate: %v", err)
+			}
+			if _, err := store.GetGuest("972501234567"); err == nil {
+				t.Error("guest still stored after deleting by their alternate number")
+			}
+		})
+	}
+}
+
+func TestPhoneCollisionsRejected(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			dana := models.Guest{PhoneNumber: "972501234567", Name: "Dana", AlternatePhones: []string{"972521111111"}}
+			yossi := models.Guest{PhoneNumber: "972509876543", Name: "Yossi"}
+			for _, g := range []models.Guest{dana, yossi} {
+				if err := store.AddGuest(g); err != nil {
+					t.Fatalf("AddGuest(%s): %v", g.Name, err)
+				}
+			}
+
+			if err := store.AddGuest(models.Guest{PhoneNumber: "972521111111"}); !errors.Is(err, ErrGuestExists) {
+				t.Errorf("AddGuest with another guest's alternate as primary = %v, want ErrGuestExists", err)
+			}
+			if err := store.AddGuest(models.Guest{PhoneNumber: "972547777777", AlternatePhones: []string{"972509876543"}}); err == nil {
+				t.Error("AddGuest with another guest's primary as an alternate succeeded")
+			}
+			if err := store.UpsertGuest(models.Guest{PhoneNumber: "972547777777", AlternatePhones: []string{"972521111111"}}); err == nil {
+				t.Error("UpsertGuest with another guest's alternate succeeded")
+			}
+			if err := store.UpsertGuest(models.Guest{PhoneNumber: "972509876543", AlternatePhones: []string{"972521111111"}}); err == nil {
+				t.Error("UpsertGuest merging in another guest's alternate succeeded")
+			}
+
+			yossi.AlternatePhones = []string{"972521111111"}
+			if err := store.UpdateGuest(yossi.PhoneNumber, yossi); err == nil {
+				t.Error("UpdateGuest taking another guest's alternate succeeded")
+			}
+			yossi.AlternatePhones = nil
+			yossi.PhoneNumber = "972501234567"
+			if err := store.UpdateGuest("972509876543", yossi); err == nil {
+				t.Error("UpdateGuest taking another guest's primary succeeded")
+			}
+
+			// A guest keeping their own numbers isn't a collision
+			dana.Name = "Dana Levi"
+			if err := store.UpdateGuest(dana.PhoneNumber, dana); err != nil {
+				t.Errorf("UpdateGuest keeping own numbers: %v", err)
+			}
+			if err := store.UpsertGuest(dana); err != nil {
+				t.Errorf("UpsertGuest keeping own numbers: %v", err)
+			}
+			if got := len(store.GetAllGuests()); got != 2 {
+				t.Errorf("stored %d guests, want 2", got)
+			}
+		})
+	}
+}
+
+func TestInvalidPhonesRejected(t *testing.T) {
+	invalid := []string{"", "abc", "12345", "1234567890123456", "+", "---"}
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, number := range invalid {
+				if err := store.AddGuest(models.Guest{PhoneNumber: number}); !errors.Is(err, ErrInvalidPhone) {
+					t.Errorf("AddGuest(%q) = %v, want ErrInvalidPhone", number, err)
+				}
+				if err := store.UpsertGuest(models.Guest{PhoneNumber: number}); !errors.Is(err, ErrInvalidPhone) {
+					t.Errorf("UpsertGuest(%q) = %v, want ErrInvalidPhone", number, err)
+				}
+			}
+			err := store.AddGuest(models.Guest{PhoneNumber: "972501234567", AlternatePhones: []string{"12345"}})
+			if !errors.Is(err, ErrInvalidPhone) {
+				t.Errorf("AddGuest with an invalid alternate = %v, want ErrInvalidPhone", err)
+			}
+			if got := len(store.GetAllGuests()); got != 0 {
+				t.Errorf("stored %d guests, want none", got)
+			}
+		})
+	}
+}
+
+func TestUpsertKeepsRSVPStatus(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.AddGuest(models.Guest{PhoneNumber: "972501234567", Name: "Dana", RSVPStatus: models.RSVPPending}); err != nil {
+				t.Fatalf("AddGuest: %v", err)
+			}
+			if err := store.UpdateRSVP("972501234567", models.RSVPAccepted, "", "yes"); err != nil {
+				t.Fatalf("UpdateRSVP: %v", err)
+			}
+			before, err := store.GetGuest("972501234567")
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+
+			// Inviting the guest again, e.g. from a re-imported list
+			err = store.UpsertGuest(models.Guest{PhoneNumber: "050-123-4567", Name: "Dana Levi", RSVPStatus: models.RSVPPending})
+			if err != nil {
+				t.Fatalf("UpsertGuest: %v", err)
+			}
+			after, err := store.GetGuest("972501234567")
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+			if after.RSVPStatus != models.RSVPAccepted || !after.RSVPDate.Equal(before.RSVPDate) {
+				t.Errorf("status = %s on %v, want the answer kept: %s on %v", after.RSVPStatus, after.RSVPDate, before.RSVPStatus, before.RSVPDate)
+			}
+			if len(after.History) != 1 {
+				t.Errorf("history = %+v, want the answer kept", after.History)
+			}
+			if after.Name != "Dana Levi" {
+				t.Errorf("name = %q, want it updated", after.Name)
+			}
+
+			// Empty fields don't clear what's stored
+			if err := store.UpsertGuest(models.Guest{PhoneNumber: "972501234567"}); err != nil {
+				t.Fatalf("UpsertGuest: %v", err)
+			}
+			if after, _ := store.GetGuest("972501234567"); after.Name != "Dana Levi" || after.RSVPStatus != models.RSVPAccepted {
+				t.Errorf("guest = %+v, want name and status kept", after)
+			}
+		})
+	}
+}
+
+func TestUpsertInvitesGuestNotInvitedYet(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.AddGuest(models.Guest{PhoneNumber: "972501234567", RSVPStatus: models.RSVPNotInvited}); err != nil {
+				t.Fatalf("AddGuest: %v", err)
+			}
+			if err := store.UpsertGuest(models.Guest{PhoneNumber: "972501234567", RSVPStatus: models.RSVPPending}); err != nil {
+				t.Fatalf("UpsertGuest: %v", err)
+			}
+			guest, err := store.GetGuest("972501234567")
+			if err != nil {
+				t.Fatalf("GetGuest: %v", err)
+			}
+			if guest.RSVPStatus != models.RSVPPending || guest.InvitedDate.IsZero() {
+				t.Errorf("guest = %s invited %v, want pending with an invitation date", guest.RSVPStatus, guest.InvitedDate)
+			}
+		})
+	}
+}
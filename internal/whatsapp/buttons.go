@@ -0,0 +1,46 @@
+package whatsapp
+
+import (
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Button IDs of the RSVP buttons sent with the invitation.
+// Button replies carry the selected ID, which the RSVP handler maps back to a status.
+const (
+	ButtonAccept  = "rsvp_accept"
+	ButtonDecline = "rsvp_decline"
+)
+
+// buttonsMessage builds an invitation with Accept and Decline buttons
+func buttonsMessage(text string) *waE2E.Message {
+	footer := "Tap a button to RSVP"
+	headerType := waE2E.ButtonsMessage_EMPTY
+
+	return &waE2E.Message{
+		ButtonsMessage: &waE2E.ButtonsMessage{
+			HeaderType:  &headerType,
+			ContentText: &text,
+			FooterText:  &footer,
+			Buttons: []*waE2E.ButtonsMessage_Button{
+				rsvpButton(ButtonAccept, "✅ Accept"),
+				rsvpButton(ButtonDecline, "❌ Decline"),
+			},
+		},
+	}
+}
+
+// rsvpButton builds a single quick-reply button
+func rsvpButton(id, label string) *waE2E.ButtonsMessage_Button {
+	buttonType := waE2E.ButtonsMessage_Button_RESPONSE
+	return &waE2E.ButtonsMessage_Button{
+		ButtonID:   &id,
+		ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{DisplayText: &label},
+		Type:       &buttonType,
+	}
+}
+
+// SelectedButton returns the ID of the button the sender tapped, or "" if msg isn't a button reply
+func SelectedButton(msg *events.Message) string {
+	return msg.Message.GetButtonsResponseMessage().GetSelectedButtonID()
+}
@@ -0,0 +1,122 @@
+package whatsapp
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// maxDocumentSize is the largest file WhatsApp accepts as a document
+const maxDocumentSize = 100 << 20
+
+// SendDocument sends the file at filePath, such as a PDF invitation, keeping its file name
+func (s *Service) SendDocument(phoneNumber, filePath, caption string) error {
+	data, err := readDocument(filePath)
+	if err != nil {
+		return err
+	}
+
+	phoneNumber = NormalizePhoneNumber(phoneNumber)
+	if err := s.checkRecipient(phoneNumber); err != nil {
+		return err
+	}
+
+	jid, err := s.verifiedJID(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	message := &waE2E.Message{DocumentMessage: documentPlaceholder(filePath, caption)}
+	if !s.cfg.DryRun {
+		document, err := s.uploadDocument(data, filePath, caption)
+		if err != nil {
+			return err
+		}
+		message = &waE2E.Message{DocumentMessage: document}
+	}
+
+	sentMsg, err := s.sendWithRetry(jid, message)
+	if err != nil {
+		return fmt.Errorf("failed to send document: %w", err)
+	}
+
+	s.log.Info().Str("phone", phoneNumber).Str("id", sentMsg.ID).Str("file", filepath.Base(filePath)).Msg("Document sent")
+	return nil
+}
+
+// sendInvitationDocument follows an invitation with the configured invitation document, if any.
+// The invitation has already gone out, so a failure is only logged.
+func (s *Service) sendInvitationDocument(phoneNumber string) {
+	if s.cfg.InvitationDocumentPath == "" {
+		return
+	}
+	if err := s.SendDocument(phoneNumber, s.cfg.InvitationDocumentPath, ""); err != nil {
+		s.log.Warn().Err(err).Str("phone", phoneNumber).Str("path", s.cfg.InvitationDocumentPath).Msg("Failed to send invitation document")
+	}
+}
+
+// readDocument reads a file to send as a document, checking it's within WhatsApp's size limit
+func readDocument(filePath string) ([]byte, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("document %s is a directory", filePath)
+	}
+	if info.Size() > maxDocumentSize {
+		return nil, fmt.Errorf("document %s is %d MB, WhatsApp allows up to %d MB", filePath, info.Size()>>20, maxDocumentSize>>20)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return data, nil
+}
+
+// uploadDocument uploads a document to WhatsApp and returns a message referencing it
+func (s *Service) uploadDocument(data []byte, filePath, caption string) (*waE2E.DocumentMessage, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	uploaded, err := s.client.Upload(ctx, data, whatsmeow.MediaDocument)
+	if err != nil {
+		err = timeoutError(err)
+		return nil, fmt.Errorf("failed to upload document: %w", err)
+	}
+
+	document := documentPlaceholder(filePath, caption)
+	if document.GetMimetype() == "" {
+		mimetype := http.DetectContentType(data)
+		document.Mimetype = &mimetype
+	}
+	document.URL = &uploaded.URL
+	document.DirectPath = &uploaded.DirectPath
+	document.MediaKey = uploaded.MediaKey
+	document.FileEncSHA256 = uploaded.FileEncSHA256
+	document.FileSHA256 = uploaded.FileSHA256
+	document.FileLength = &uploaded.FileLength
+	return document, nil
+}
+
+// documentPlaceholder describes a document by name and caption, before its upload fills in the rest
+func documentPlaceholder(filePath, caption string) *waE2E.DocumentMessage {
+	fileName := filepath.Base(filePath)
+	document := &waE2E.DocumentMessage{
+		FileName: &fileName,
+		Title:    &fileName,
+	}
+	if caption != "" {
+		document.Caption = &caption
+	}
+	if mimetype := mime.TypeByExtension(filepath.Ext(filePath)); mimetype != "" {
+		document.Mimetype = &mimetype
+	}
+	return document
+}
@@ -0,0 +1,42 @@
+package whatsapp
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// dryRunSend logs the message that would have been sent and returns a made-up response for it.
+// While offline the message is also recorded for SentMessages.
+func (s *Service) dryRunSend(jid types.JID, message *waE2E.Message) whatsmeow.SendResponse {
+	resp := whatsmeow.SendResponse{ID: types.MessageID(s.newMessageID()), Timestamp: time.Now()}
+	if s.IsOffline() {
+		s.outbox.record(jid, resp.ID, messageText(message), resp.Timestamp)
+		s.log.Debug().Str("jid", jid.String()).Str("id", resp.ID).Msg("Offline, message recorded")
+		return resp
+	}
+	s.log.Info().
+		Str("jid", jid.String()).
+		Str("id", resp.ID).
+		Str("text", messageText(message)).
+		Msg("Dry run, message not sent")
+	return resp
+}
+
+// messageText returns the text shown to the recipient of a message we build
+func messageText(message *waE2E.Message) string {
+	switch {
+	case message.GetButtonsMessage() != nil:
+		return message.GetButtonsMessage().GetContentText()
+	case message.GetImageMessage() != nil:
+		return message.GetImageMessage().GetCaption()
+	case message.GetDocumentMessage() != nil:
+		return "📄 " + message.GetDocumentMessage().GetFileName()
+	case message.GetLocationMessage() != nil:
+		return "📍 " + message.GetLocationMessage().GetName()
+	default:
+		return message.GetConversation()
+	}
+}
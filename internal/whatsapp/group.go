@@ -0,0 +1,58 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ErrNotInGroup is returned when the bot isn't a participant of the group it's asked to post to
+var ErrNotInGroup = errors.New("the bot isn't a participant of the group")
+
+// SendToGroup posts a text message to a group chat the bot's number is a participant of,
+// given its JID (e.g. 120363012345678901@g.us)
+func (s *Service) SendToGroup(groupJID, message string) error {
+	jid, err := types.ParseJID(strings.TrimSpace(groupJID))
+	if err != nil || jid.Server != types.GroupServer || jid.User == "" {
+		return fmt.Errorf("invalid group JID %q, expected e.g. 120363012345678901@g.us", groupJID)
+	}
+	if s.blocked[jid.String()] {
+		s.log.Info().Str("group", jid.String()).Msg("Skipping message to excluded group")
+		return fmt.Errorf("%s: %w", jid, ErrNumberExcluded)
+	}
+
+	// Checking membership first gives a clear error instead of a failed send
+	if err := s.checkGroupMembership(jid); err != nil {
+		return err
+	}
+
+	sentMsg, err := s.sendWithRetry(jid, &waE2E.Message{Conversation: &message})
+	if err != nil {
+		return fmt.Errorf("%w to group %s: %w", ErrSendFailed, jid, err)
+	}
+
+	s.log.Info().Str("group", jid.String()).Str("id", sentMsg.ID).Msg("Group message sent")
+	return nil
+}
+
+// checkGroupMembership returns ErrNotInGroup if the bot can't post to the group
+func (s *Service) checkGroupMembership(jid types.JID) error {
+	if s.IsOffline() {
+		return nil
+	}
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	_, err := s.client.GetGroupInfo(ctx, jid)
+	switch {
+	case errors.Is(err, whatsmeow.ErrNotInGroup), errors.Is(err, whatsmeow.ErrGroupNotFound):
+		return fmt.Errorf("%w %s: add the bot's number to the group first", ErrNotInGroup, jid)
+	case err != nil:
+		return fmt.Errorf("failed to look up group %s: %w", jid, timeoutError(err))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package whatsapp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// JIDHandler is called when a number's JID is verified on WhatsApp, or with an empty JID
+// when a send shows the one verified earlier is stale, so it can be stored for the next run
+type JIDHandler func(phoneNumber, jid string, verifiedAt time.Time)
+
+// cachedJID is a number's JID and when it was verified
+type cachedJID struct {
+	jid        types.JID
+	verifiedAt time.Time
+}
+
+// jidCache remembers the JIDs numbers were verified at, so repeated sends skip the lookup
+type jidCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedJID
+}
+
+// newJIDCache creates a cache whose entries expire after ttl; zero disables caching
+func newJIDCache(ttl time.Duration) *jidCache {
+	return &jidCache{ttl: ttl, entries: make(map[string]cachedJID)}
+}
+
+// get returns the JID cached for the number, unless it's missing or expired
+func (c *jidCache) get(phoneNumber string, now time.Time) (types.JID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[phoneNumber]
+	if !ok || now.Sub(entry.verifiedAt) >= c.ttl {
+		return types.JID{}, false
+	}
+	return entry.jid, true
+}
+
+// put caches the JID the number was verified at
+func (c *jidCache) put(phoneNumber string, jid types.JID, verifiedAt time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[phoneNumber] = cachedJID{jid: jid, verifiedAt: verifiedAt}
+}
+
+// forget drops the cache entries for jid and returns the numbers they were for
+func (c *jidCache) forget(jid types.JID) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var numbers []string
+	for phoneNumber, entry := range c.entries {
+		if entry.jid == jid {
+			delete(c.entries, phoneNumber)
+			numbers = append(numbers, phoneNumber)
+		}
+	}
+	return numbers
+}
+
+// RememberJID seeds the cache with a JID verified earlier, e.g. one stored with a guest,
+// so the number isn't looked up again until it expires
+func (s *Service) RememberJID(phoneNumber, jid string, verifiedAt time.Time) error {
+	parsed, err := types.ParseJID(jid)
+	if err != nil {
+		return fmt.Errorf("invalid JID %q: %w", jid, err)
+	}
+	s.jids.put(NormalizePhoneNumber(phoneNumber), parsed, verifiedAt)
+	return nil
+}
+
+// SetJIDHandler sets the handler told about verified and stale JIDs
+func (s *Service) SetJIDHandler(handler JIDHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	s.jidHandler = handler
+}
+
+// notifyJID passes a verified or stale JID to the JID handler, if one is set
+func (s *Service) notifyJID(phoneNumber, jid string, verifiedAt time.Time) {
+	s.handlersMu.RLock()
+	handler := s.jidHandler
+	s.handlersMu.RUnlock()
+
+	if handler != nil {
+		handler(phoneNumber, jid, verifiedAt)
+	}
+}
+
+// forgetJID drops a JID a send to failed permanently, so the number is verified again next time
+func (s *Service) forgetJID(jid types.JID) {
+	for _, phoneNumber := range s.jids.forget(jid) {
+		s.log.Debug().Str("phone", phoneNumber).Str("jid", jid.String()).Msg("Forgetting stale JID")
+		s.notifyJID(phoneNumber, "", time.Time{})
+	}
+}
@@ -0,0 +1,63 @@
+package whatsapp
+
+import (
+	"fmt"
+	"math"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SendLocation sends a location pin labeled with name, such as the wedding venue
+func (s *Service) SendLocation(phoneNumber string, lat, lng float64, name string) error {
+	if err := validateCoordinates(lat, lng); err != nil {
+		return err
+	}
+
+	phoneNumber = NormalizePhoneNumber(phoneNumber)
+	if err := s.checkRecipient(phoneNumber); err != nil {
+		return err
+	}
+
+	jid, err := s.verifiedJID(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	sentMsg, err := s.sendWithRetry(jid, &waE2E.Message{
+		LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  &lat,
+			DegreesLongitude: &lng,
+			Name:             &name,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send location: %w", err)
+	}
+
+	s.log.Info().Str("phone", phoneNumber).Str("id", sentMsg.ID).Msg("Location sent")
+	return nil
+}
+
+// verifiedJID looks the number up on WhatsApp and returns the JID to message it at
+func (s *Service) verifiedJID(phoneNumber string) (types.JID, error) {
+	resp, err := s.isOnWhatsApp(phoneNumber)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("failed to verify number on WhatsApp: %w", err)
+	}
+	if len(resp) == 0 || !resp[0].IsIn {
+		return types.JID{}, fmt.Errorf("%w: %s", ErrNotOnWhatsApp, phoneNumber)
+	}
+	return resp[0].JID, nil
+}
+
+// validateCoordinates checks that lat and lng are a point on Earth
+func validateCoordinates(lat, lng float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("invalid latitude %v, must be between -90 and 90", lat)
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("invalid longitude %v, must be between -180 and 180", lng)
+	}
+	return nil
+}
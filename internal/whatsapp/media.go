@@ -0,0 +1,60 @@
+package whatsapp
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+// invitationMessage builds the invitation, attaching the configured image with the text as its caption.
+// It falls back to a text-only message if there is no image or it can't be uploaded.
+func (s *Service) invitationMessage(text string) *waE2E.Message {
+	if s.cfg.InvitationImagePath == "" {
+		return &waE2E.Message{Conversation: &text}
+	}
+	if s.cfg.DryRun {
+		s.log.Info().Str("path", s.cfg.InvitationImagePath).Msg("Dry run, invitation image not uploaded")
+		return &waE2E.Message{Conversation: &text}
+	}
+
+	image, err := s.uploadImage(s.cfg.InvitationImagePath, text)
+	if err != nil {
+		s.log.Warn().Err(err).Str("path", s.cfg.InvitationImagePath).Msg("Sending text-only invitation")
+		return &waE2E.Message{Conversation: &text}
+	}
+
+	s.log.Info().Str("path", s.cfg.InvitationImagePath).Msg("Sending invitation with image")
+	return &waE2E.Message{ImageMessage: image}
+}
+
+// uploadImage uploads the image at path to WhatsApp and returns a message referencing it
+func (s *Service) uploadImage(path, caption string) (*waE2E.ImageMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	uploaded, err := s.client.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		err = timeoutError(err)
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	mimetype := http.DetectContentType(data)
+	return &waE2E.ImageMessage{
+		Caption:       &caption,
+		Mimetype:      &mimetype,
+		URL:           &uploaded.URL,
+		DirectPath:    &uploaded.DirectPath,
+		MediaKey:      uploaded.MediaKey,
+		FileEncSHA256: uploaded.FileEncSHA256,
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    &uploaded.FileLength,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package whatsapp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// OutboundMessage is a message the service sent while offline, recorded instead of going to WhatsApp
+type OutboundMessage struct {
+	ID        string
+	To        string // the phone number, or the JID of a group
+	Text      string
+	Timestamp time.Time
+}
+
+// outbox records the messages sent while offline
+type outbox struct {
+	mu       sync.Mutex
+	messages []OutboundMessage
+}
+
+// NewOfflineService creates a service that never connects to WhatsApp, for development and demos.
+// Every number counts as being on WhatsApp, sent messages are recorded for SentMessages instead of
+// being sent, and InjectMessage feeds in messages as if guests had written them.
+func NewOfflineService(cfg *Config) (*Service, error) {
+	quiet, err := parseQuietHours(cfg.QuietStart, cfg.QuietEnd, cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	if level == zerolog.NoLevel {
+		level = zerolog.InfoLevel
+	}
+	logger := zerolog.New(os.Stdout).Level(level).With().Str("component", "WhatsApp (offline)").Logger()
+
+	// Nothing is uploaded or marked as read, just as in a dry run
+	offlineCfg := *cfg
+	offlineCfg.DryRun = true
+
+	service := &Service{
+		cfg:     &offlineCfg,
+		log:     logger,
+		allowed: numberSet(cfg.AllowedNumbers),
+		blocked: numberSet(cfg.BlockedNumbers),
+
+		disconnected: make(chan struct{}),
+		quiet:        quiet,
+		jids:         newJIDCache(cfg.JIDCacheTTL),
+		outbox:       &outbox{},
+	}
+
+	service.loadDeferred()
+	if quiet != nil || len(service.deferred) > 0 {
+		go service.runDeferred()
+	}
+	service.startWorkers()
+
+	return service, nil
+}
+
+// IsOffline reports whether the service was created by NewOfflineService
+func (s *Service) IsOffline() bool {
+	return s.outbox != nil
+}
+
+// InjectMessage handles a text message from phoneNumber as if it had arrived from WhatsApp,
+// returning once the message handler is done with it, so its replies are in SentMessages.
+// It only works on a service created by NewOfflineService.
+func (s *Service) InjectMessage(phoneNumber, text string) error {
+	if !s.IsOffline() {
+		return fmt.Errorf("messages can only be injected while offline")
+	}
+
+	phoneNumber = NormalizePhoneNumber(phoneNumber)
+	if phoneNumber == "" {
+		return fmt.Errorf("phone number is empty")
+	}
+	sender := types.NewJID(phoneNumber, types.DefaultUserServer)
+
+	s.handleMessage(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: sender, Sender: sender},
+			ID:            s.newMessageID(),
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{Conversation: &text},
+	})
+	return nil
+}
+
+// SentMessages returns the messages sent while offline, oldest first
+func (s *Service) SentMessages() []OutboundMessage {
+	if !s.IsOffline() {
+		return nil
+	}
+
+	s.outbox.mu.Lock()
+	defer s.outbox.mu.Unlock()
+	return append([]OutboundMessage(nil), s.outbox.messages...)
+}
+
+// record adds a message sent while offline to the outbox
+func (o *outbox) record(jid types.JID, id, text string, timestamp time.Time) {
+	to := jid.User
+	if jid.Server == types.GroupServer {
+		to = jid.String()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, OutboundMessage{ID: id, To: to, Text: text, Timestamp: timestamp})
+}
+
+// newMessageID returns an ID for a message that isn't going through WhatsApp
+func (s *Service) newMessageID() string {
+	if s.client != nil {
+		return string(s.client.GenerateMessageID())
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	return "OFFLINE" + strings.ToUpper(hex.EncodeToString(id))
+}
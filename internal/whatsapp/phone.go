@@ -0,0 +1,9 @@
+package whatsapp
+
+import "wedding-whatsapp/internal/phone"
+
+// NormalizePhoneNumber normalizes phone numbers to international format.
+// See phone.Normalize for the rules applied.
+func NormalizePhoneNumber(phoneNumber string) string {
+	return phone.Normalize(phoneNumber)
+}
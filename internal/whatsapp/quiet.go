@@ -0,0 +1,260 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// deferredCheckInterval is how often messages held back during quiet hours are checked
+const deferredCheckInterval = time.Minute
+
+// SentHandler is a callback function for messages sent after being held back during quiet hours.
+// sendErr is why the message couldn't be sent, in which case messageID is empty.
+type SentHandler func(phoneNumber, messageID string, sendErr error) error
+
+// quietHours is a daily window, in local wall-clock time, during which messages that aren't replies are held back
+type quietHours struct {
+	start time.Duration // offset from midnight
+	end   time.Duration
+	loc   *time.Location
+}
+
+// deferredMessagesFile is where messages held back during quiet hours are saved in DataDir
+const deferredMessagesFile = "deferred_messages.json"
+
+// deferredMessage is a message waiting for quiet hours to end
+type deferredMessage struct {
+	SendAt      time.Time `json:"send_at"`
+	PhoneNumber string    `json:"phone_number"`
+	Text        string    `json:"text"`
+	Invitation  bool      `json:"invitation,omitempty"`
+}
+
+// parseQuietHours parses start and end times (15:04) in the given IANA timezone.
+// It returns nil, meaning no quiet hours, when start and end are both empty.
+func parseQuietHours(start, end, timezone string) (*quietHours, error) {
+	if start == "" && end == "" {
+		return nil, nil
+	}
+
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours start %q, expected HH:MM", start)
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours end %q, expected HH:MM", end)
+	}
+
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &quietHours{start: startTime.Sub(midnight), end: endTime.Sub(midnight), loc: loc}, nil
+}
+
+// until returns when the quiet window containing t ends, or the zero time if t is outside quiet hours.
+// A window whose end is before its start, like 22:00-08:00, runs past midnight.
+func (q *quietHours) until(t time.Time) time.Time {
+	if q == nil || q.start == q.end {
+		return time.Time{}
+	}
+
+	local := t.In(q.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, q.loc)
+	offset := local.Sub(midnight)
+
+	switch {
+	case q.start < q.end && offset >= q.start && offset < q.end:
+		return midnight.Add(q.end)
+	case q.start > q.end && offset >= q.start:
+		return midnight.AddDate(0, 0, 1).Add(q.end)
+	case q.start > q.end && offset < q.end:
+		return midnight.Add(q.end)
+	default:
+		return time.Time{}
+	}
+}
+
+// deferIfQuiet holds back a message that isn't a reply if it's quiet hours, replacing any message
+// of the same kind already waiting for the number. It reports whether the message was held back.
+func (s *Service) deferIfQuiet(phoneNumber, text string, invitation bool) bool {
+	sendAt := s.quiet.until(time.Now())
+	if sendAt.IsZero() {
+		return false
+	}
+
+	phoneNumber = NormalizePhoneNumber(phoneNumber)
+	msg := deferredMessage{SendAt: sendAt, PhoneNumber: phoneNumber, Text: text, Invitation: invitation}
+
+	s.deferredMu.Lock()
+	defer s.deferredMu.Unlock()
+
+	s.log.Info().Str("phone", phoneNumber).Time("send_at", sendAt).Msg("Quiet hours, deferring message")
+	for i, d := range s.deferred {
+		if d.PhoneNumber == phoneNumber && d.Invitation == invitation {
+			s.deferred[i] = msg
+			s.saveDeferred()
+			return true
+		}
+	}
+	s.deferred = append(s.deferred, msg)
+	s.saveDeferred()
+	return true
+}
+
+// runDeferred sends held back messages once quiet hours are over, until Disconnect is called.
+// The queue is saved in DataDir, so messages still waiting when the bot stops are sent after it restarts.
+func (s *Service) runDeferred() {
+	ticker := time.NewTicker(deferredCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.disconnected:
+			return
+		case now := <-ticker.C:
+			for _, msg := range s.dueDeferred(now) {
+				s.sendDeferred(msg)
+			}
+		}
+	}
+}
+
+// dueDeferred removes and returns the held back messages whose time has come
+func (s *Service) dueDeferred(now time.Time) []deferredMessage {
+	s.deferredMu.Lock()
+	defer s.deferredMu.Unlock()
+
+	var due, waiting []deferredMessage
+	for _, msg := range s.deferred {
+		if msg.SendAt.After(now) {
+			waiting = append(waiting, msg)
+		} else {
+			due = append(due, msg)
+		}
+	}
+	s.deferred = waiting
+	if len(due) > 0 {
+		s.saveDeferred()
+	}
+	return due
+}
+
+// sendDeferred sends a held back message and passes its ID, or why it failed, to the sent handler.
+// The message was reported as sent when it was held back, so a failure has to be passed on to be noticed.
+func (s *Service) sendDeferred(msg deferredMessage) {
+	send := s.SendMessage
+	if msg.Invitation {
+		send = s.sendInvitation
+	}
+
+	messageID, err := send(msg.PhoneNumber, msg.Text)
+	if err != nil {
+		s.log.Error().Err(err).Str("phone", msg.PhoneNumber).Msg("Failed to send deferred message")
+	}
+
+	s.handlersMu.RLock()
+	handler := s.sentHandler
+	s.handlersMu.RUnlock()
+
+	if handler != nil {
+		if err := handler(msg.PhoneNumber, messageID, err); err != nil {
+			s.log.Error().Err(err).Msg("Error handling deferred message")
+		}
+	}
+}
+
+// loadDeferred reads the messages that were still held back when the bot last stopped
+func (s *Service) loadDeferred() {
+	if s.cfg.DataDir == "" {
+		return
+	}
+	path := filepath.Join(s.cfg.DataDir, deferredMessagesFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &s.deferred)
+	}
+	if err != nil {
+		s.log.Error().Err(err).Str("file", path).Msg("Failed to load deferred messages, they won't be sent")
+		return
+	}
+	if len(s.deferred) > 0 {
+		s.log.Info().Int("count", len(s.deferred)).Msg("Loaded messages deferred for quiet hours")
+	}
+}
+
+// saveDeferred writes the held back messages to DataDir, replacing the file in one step
+// so a crash part way through doesn't lose the queue. The caller must hold deferredMu.
+func (s *Service) saveDeferred() {
+	if s.cfg.DataDir == "" {
+		return
+	}
+	fileMode, dirMode := s.cfg.FileMode, s.cfg.DirMode
+	if fileMode == 0 {
+		fileMode = 0600
+	}
+	if dirMode == 0 {
+		dirMode = 0700
+	}
+
+	data, err := json.MarshalIndent(s.deferred, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(s.cfg.DataDir, dirMode)
+	}
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(s.cfg.DataDir, deferredMessagesFile), data, fileMode)
+	}
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to save deferred messages, they'll be lost if the bot stops")
+	}
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it into place
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// SetSentHandler sets a custom handler for messages sent after quiet hours
+func (s *Service) SetSentHandler(handler SentHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	s.sentHandler = handler
+}
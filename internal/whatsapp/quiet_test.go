@@ -0,0 +1,72 @@
+package whatsapp
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// newQuietService creates an offline service in dir whose quiet hours cover the current time
+func newQuietService(t *testing.T, dir string) *Service {
+	t.Helper()
+	now := time.Now().UTC()
+	service, err := NewOfflineService(&Config{
+		DataDir:    dir,
+		LogLevel:   "error",
+		QuietStart: now.Add(-time.Hour).Format("15:04"),
+		QuietEnd:   now.Add(time.Hour).Format("15:04"),
+		Timezone:   "UTC",
+	})
+	if err != nil {
+		t.Fatalf("NewOfflineService: %v", err)
+	}
+	t.Cleanup(service.Disconnect)
+	return service
+}
+
+func TestDeferredMessagesSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	service := newQuietService(t, dir)
+	if id, err := service.SendInvitation("972501234567", "You're invited!"); err != nil || id != "" {
+		t.Fatalf("SendInvitation = %q, %v, want it deferred", id, err)
+	}
+	service.Disconnect()
+
+	restarted := newQuietService(t, dir)
+	if len(restarted.deferred) != 1 || restarted.deferred[0].Text != "You're invited!" || !restarted.deferred[0].Invitation {
+		t.Fatalf("deferred after restart = %+v, want the invitation", restarted.deferred)
+	}
+
+	// Once sent, a message isn't loaded again
+	if due := restarted.dueDeferred(time.Now().Add(24 * time.Hour)); len(due) != 1 {
+		t.Fatalf("due = %+v, want the invitation", due)
+	}
+	restarted.Disconnect()
+	if again := newQuietService(t, dir); len(again.deferred) != 0 {
+		t.Errorf("deferred after sending = %+v, want none", again.deferred)
+	}
+
+	// The queue is replaced in one step, leaving no temp files behind
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 || entries[0].Name() != deferredMessagesFile {
+		t.Errorf("data dir = %v, %v, want only %s", entries, err, deferredMessagesFile)
+	}
+}
+
+func TestFailedDeferredMessageIsReported(t *testing.T) {
+	const blocked = "972501234567"
+	service := newQuietService(t, t.TempDir())
+	service.blocked = numberSet([]string{blocked})
+
+	var gotID string
+	var gotErr error
+	service.SetSentHandler(func(phoneNumber, messageID string, sendErr error) error {
+		gotID, gotErr = messageID, sendErr
+		return nil
+	})
+
+	service.sendDeferred(deferredMessage{PhoneNumber: blocked, Text: "Don't forget to RSVP"})
+	if gotID != "" || !errors.Is(gotErr, ErrNumberExcluded) {
+		t.Errorf("sent handler got %q, %v, want the send failure", gotID, gotErr)
+	}
+}
@@ -0,0 +1,26 @@
+package whatsapp
+
+import (
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// MarkRead sends a read receipt for an incoming message, so the sender sees it was read (blue ticks)
+func (s *Service) MarkRead(msg *events.Message) error {
+	if s.cfg.DryRun {
+		s.log.Info().Str("sender", msg.Info.Sender.String()).Str("id", msg.Info.ID).Msg("Dry run, message not marked as read")
+		return nil
+	}
+
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	err := s.client.MarkRead(ctx, []types.MessageID{msg.Info.ID}, time.Now(), msg.Info.Chat, msg.Info.Sender)
+	if err != nil {
+		return fmt.Errorf("failed to mark message as read: %w", timeoutError(err))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package whatsapp
+
+import (
+	"errors"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// Reconnection backoff, used after an unexpected disconnect
+const (
+	defaultMaxReconnectAttempts = 10
+	reconnectBaseDelay          = 2 * time.Second
+	maxReconnectDelay           = 5 * time.Minute
+)
+
+// reconnect tries to connect again after an unexpected disconnect, doubling the delay between attempts.
+// It gives up after MaxReconnectAttempts or as soon as Disconnect is called.
+func (s *Service) reconnect() {
+	// A burst of disconnect events should only start one reconnect loop
+	if !s.reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.reconnecting.Store(false)
+
+	maxAttempts := s.cfg.MaxReconnectAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxReconnectAttempts
+	}
+
+	delay := reconnectBaseDelay
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		s.log.Info().Int("attempt", attempt).Dur("delay", delay).Msg("Reconnecting to WhatsApp")
+
+		select {
+		case <-s.disconnected:
+			return
+		case <-time.After(delay):
+		}
+
+		err := s.connect()
+		if err == nil || errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+			s.log.Info().Int("attempt", attempt).Msg("Reconnected to WhatsApp")
+			return
+		}
+
+		s.log.Warn().Err(err).Int("attempt", attempt).Msg("Failed to reconnect to WhatsApp")
+		delay = min(delay*2, maxReconnectDelay)
+	}
+
+	s.log.Error().Int("attempts", maxAttempts).Msg("Giving up reconnecting to WhatsApp, restart the bot to try again")
+}
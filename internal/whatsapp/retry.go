@@ -0,0 +1,99 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// sendRetryBaseDelay is the wait before the first retry, doubled on each further attempt
+const sendRetryBaseDelay = time.Second
+
+// unreachableSendErrors are the send failures that are down to the recipient: the number isn't on WhatsApp,
+// its account is gone, or it won't take our messages. Other permanent failures, like bad media or a server
+// error, say nothing about the guest, so they don't make them unreachable.
+var unreachableSendErrors = []error{
+	ErrNotOnWhatsApp,
+	whatsmeow.ErrIQNotFound,
+	whatsmeow.ErrIQForbidden,
+	whatsmeow.ErrIQGone,
+}
+
+// sendWithRetry sends a message, retrying transient failures with exponential backoff.
+// Permanent failures (unknown recipient, server rejection) are returned immediately, wrapping
+// ErrUnreachable when they're down to the recipient.
+func (s *Service) sendWithRetry(jid types.JID, message *waE2E.Message) (whatsmeow.SendResponse, error) {
+	if s.cfg.DryRun {
+		return s.dryRunSend(jid, message), nil
+	}
+
+	delay := sendRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		s.waitForSendSlot()
+		resp, err := s.sendOnce(jid, message)
+		if err != nil && isUnreachableSendError(err) {
+			s.cfg.Metrics.SendError()
+			// The number may have moved to a new JID since it was verified
+			s.forgetJID(jid)
+			return resp, fmt.Errorf("%w: %w", ErrUnreachable, err)
+		}
+		if err == nil || !isTransientSendError(err) || attempt >= s.cfg.MaxSendRetries {
+			if err != nil {
+				s.cfg.Metrics.SendError()
+			}
+			return resp, err
+		}
+
+		s.log.Warn().Err(err).
+			Str("jid", jid.String()).
+			Int("attempt", attempt+1).
+			Dur("retry_in", delay).
+			Msg("Transient send failure, retrying")
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// sendOnce makes a single attempt at sending a message, giving up after the request timeout
+func (s *Service) sendOnce(jid types.JID, message *waE2E.Message) (whatsmeow.SendResponse, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	resp, err := s.client.SendMessage(ctx, jid, message)
+	return resp, timeoutError(err)
+}
+
+// isTransientSendError reports whether a send failure is worth retrying
+func isTransientSendError(err error) bool {
+	if errors.Is(err, whatsmeow.ErrIQTimedOut) ||
+		errors.Is(err, whatsmeow.ErrMessageTimedOut) ||
+		errors.Is(err, whatsmeow.ErrNotConnected) ||
+		errors.Is(err, ErrRequestTimeout) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var disconnectedErr *whatsmeow.DisconnectedError
+	if errors.As(err, &disconnectedErr) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isUnreachableSendError reports whether a send failure means the recipient can't be messaged
+func isUnreachableSendError(err error) bool {
+	for _, unreachable := range unreachableSendErrors {
+		if errors.Is(err, unreachable) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func TestIsUnreachableSendError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not on WhatsApp", ErrNotOnWhatsApp, true},
+		{"not on WhatsApp, wrapped", fmt.Errorf("failed to verify: %w", ErrNotOnWhatsApp), true},
+		{"user not found", whatsmeow.ErrIQNotFound, true},
+		{"user not found, as returned by the server", fmt.Errorf("failed to get devices: %w", &whatsmeow.IQError{Code: 404, Text: "item-not-found"}), true},
+		{"blocked", &whatsmeow.IQError{Code: 403, Text: "forbidden"}, true},
+		{"account gone", whatsmeow.ErrIQGone, true},
+
+		{"not logged in", whatsmeow.ErrNotLoggedIn, false},
+		{"client is nil", whatsmeow.ErrClientIsNil, false},
+		{"not connected", whatsmeow.ErrNotConnected, false},
+		{"timed out", context.DeadlineExceeded, false},
+		{"request timeout", ErrRequestTimeout, false},
+		{"server error", fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 500), false},
+		{"internal server error", whatsmeow.ErrIQInternalServerError, false},
+		{"rate limited", whatsmeow.ErrIQRateOverLimit, false},
+		{"bad request", whatsmeow.ErrIQBadRequest, false},
+		{"bad media", whatsmeow.ErrInvalidImageFormat, false},
+		{"no encryption session", whatsmeow.ErrNoSession, false},
+		{"not in contacts", whatsmeow.ErrUnknownServer, false},
+		{"unknown", fmt.Errorf("failed to marshal message"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnreachableSendError(tt.err); got != tt.want {
+				t.Errorf("isUnreachableSendError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
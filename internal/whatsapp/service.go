@@ -2,9 +2,16 @@ package whatsapp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wedding-whatsapp/internal/metrics"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rs/zerolog"
@@ -19,27 +26,190 @@ import (
 // messageHandler is a callback function for handling messages
 type MessageHandler func(*events.Message) error
 
+// ReceiptHandler is a callback function for handling delivery and read receipts
+type ReceiptHandler func(*events.Receipt) error
+
 type Config struct {
 	DataDir string
+
+	// SessionPath is the session database, whatsmeow.db in DataDir if empty. It's given FileMode,
+	// and its directory DirMode when created; zero modes default to 0600 and 0700.
+	SessionPath string
+	FileMode    os.FileMode
+	DirMode     os.FileMode
+
+	// LogLevel is the minimum level logged: debug, info, warn or error
+	LogLevel string
+
+	// AllowedNumbers restricts the bot to these numbers when non-empty
+	AllowedNumbers []string
+	// BlockedNumbers are never messaged and their messages are ignored, as are messages in
+	// any group chats listed by ID (e.g. 120363012345678901@g.us)
+	BlockedNumbers []string
+
+	// MinSendInterval is the minimum time between outgoing messages, plus up to SendJitter
+	// of random delay, so bulk sends don't get the account flagged
+	MinSendInterval time.Duration
+	SendJitter      time.Duration
+
+	// InteractiveButtons sends invitations with Accept/Decline buttons instead of text instructions
+	InteractiveButtons bool
+
+	// InvitationImagePath is an optional image sent with the invitation text as its caption
+	InvitationImagePath string
+
+	// InvitationDocumentPath is an optional file, like a PDF invitation, sent after the invitation
+	InvitationDocumentPath string
+
+	// DryRun logs outgoing messages and their recipients instead of sending them,
+	// without looking the numbers up on WhatsApp
+	DryRun bool
+
+	// Metrics counts invitations, incoming messages and send errors, nil to disable
+	Metrics *metrics.Metrics
+
+	// MaxSendRetries is how many times a send is retried after a transient (network/timeout) failure
+	MaxSendRetries int
+
+	// RequestTimeout bounds each call to WhatsApp, like sending a message or looking up a number,
+	// so a hung connection can't block forever. Defaults to 30 seconds.
+	RequestTimeout time.Duration
+
+	// QuietStart and QuietEnd (HH:MM in Timezone, or local time if empty) bound a daily window
+	// during which invitations and reminders are deferred. Replies are always sent straight away.
+	QuietStart string
+	QuietEnd   string
+	Timezone   string
+
+	// QRTimeout is how long Connect waits for the QR code to be scanned when the bot isn't linked yet,
+	// zero to wait until WhatsApp stops issuing new codes
+	QRTimeout time.Duration
+
+	// MaxReconnectAttempts is how many times to try reconnecting after an unexpected disconnect
+	MaxReconnectAttempts int
+
+	// JIDCacheTTL is how long a number's verified JID is reused before it's looked up on WhatsApp again,
+	// zero to look it up before every send
+	JIDCacheTTL time.Duration
+
+	// SkipWhatsAppCheck messages numbers that weren't verified at a JID built from the number
+	// instead of looking them up first, for when the lookup wrongly reports them as not on WhatsApp
+	SkipWhatsAppCheck bool
+
+	// MessageWorkers is the number of goroutines processing incoming messages,
+	// each with a queue of up to MessageQueueSize messages
+	MessageWorkers   int
+	MessageQueueSize int
 }
 
+// ErrNumberExcluded is returned when a number is filtered out by the allow/deny lists
+var ErrNumberExcluded = errors.New("number is excluded by the allow/deny lists")
+
+// ErrOwnNumber is returned when asked to message the number the bot itself is linked to
+var ErrOwnNumber = errors.New("number is the bot's own linked number")
+
+// ErrQRTimeout is returned by Connect when the QR code to link the bot isn't scanned in time
+var ErrQRTimeout = errors.New("QR code was not scanned in time")
+
+// ErrUnreachable is returned when the number isn't on WhatsApp or the recipient can't be sent messages
+var ErrUnreachable = errors.New("recipient is unreachable")
+
+// ErrNotOnWhatsApp is returned when the number isn't registered on WhatsApp. It's also an ErrUnreachable.
+var ErrNotOnWhatsApp = fmt.Errorf("%w: number is not registered on WhatsApp", ErrUnreachable)
+
+// ErrNotInContacts is returned when WhatsApp won't deliver to the recipient's JID,
+// usually because the number isn't in the phone's contacts yet
+var ErrNotInContacts = errors.New("recipient is not in the contacts")
+
+// ErrSendFailed is returned when a message couldn't be sent once the number was verified
+var ErrSendFailed = errors.New("failed to send message")
+
 type Service struct {
 	client         *whatsmeow.Client
 	cfg            *Config
 	log            zerolog.Logger
 	messageHandler MessageHandler
+	receiptHandler ReceiptHandler
+	allowed        map[string]bool
+	blocked        map[string]bool
+
+	// handlersMu guards messageHandler, receiptHandler, sentHandler and jidHandler,
+	// which can be changed at runtime while events are being handled
+	handlersMu sync.RWMutex
+
+	sendMu   sync.Mutex
+	lastSend time.Time
+
+	// queues feed incoming messages to the worker pool, sharded by sender.
+	// queueMu guards closing them on shutdown, and pending counts messages queued or being handled.
+	queues  []chan *events.Message
+	queueMu sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+	pending atomic.Int64
+
+	// disconnected is closed by Disconnect to stop background loops,
+	// so an explicit disconnect isn't undone by reconnecting
+	disconnected   chan struct{}
+	disconnectOnce sync.Once
+	reconnecting   atomic.Bool
+
+	// Messages that aren't replies are held back during quiet hours
+	quiet       *quietHours
+	deferredMu  sync.Mutex
+	deferred    []deferredMessage
+	sentHandler SentHandler
+
+	// jids caches the JIDs numbers were verified at, jidHandler is told when they change
+	jids       *jidCache
+	jidHandler JIDHandler
+
+	// outbox records sent messages instead of the client sending them, nil unless offline
+	outbox *outbox
 }
 
 // NewService creates a new WhatsApp service
 func NewService(cfg *Config) (*Service, error) {
 	ctx := context.Background()
-	logger := zerolog.New(os.Stdout).With().Str("component", "WhatsApp").Logger()
+
+	quiet, err := parseQuietHours(cfg.QuietStart, cfg.QuietEnd, cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	if level == zerolog.NoLevel {
+		level = zerolog.InfoLevel
+	}
+	logger := zerolog.New(os.Stdout).Level(level).With().Str("component", "WhatsApp").Logger()
+
+	sessionPath := cfg.SessionPath
+	if sessionPath == "" {
+		sessionPath = filepath.Join(cfg.DataDir, "whatsmeow.db")
+	}
+	fileMode, dirMode := cfg.FileMode, cfg.DirMode
+	if fileMode == 0 {
+		fileMode = 0600
+	}
+	if dirMode == 0 {
+		dirMode = 0700
+	}
+	if err := os.MkdirAll(filepath.Dir(sessionPath), dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
 
 	// Use nil logger - sqlstore will use a no-op logger by default
-	container, err := sqlstore.New(ctx, "sqlite3", fmt.Sprintf("file:%s/whatsmeow.db?_foreign_keys=on", cfg.DataDir), nil)
+	container, err := sqlstore.New(ctx, "sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on", sessionPath), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database: %w", err)
 	}
+	// The session holds the account's keys, so it shouldn't be readable by other users
+	if err := os.Chmod(sessionPath, fileMode); err != nil {
+		return nil, fmt.Errorf("failed to set session database permissions: %w", err)
+	}
 
 	deviceStore, err := container.GetFirstDevice(ctx)
 	if err != nil {
@@ -48,13 +218,30 @@ func NewService(cfg *Config) (*Service, error) {
 
 	// Use nil logger - whatsmeow will use a no-op logger by default
 	client := whatsmeow.NewClient(deviceStore, nil)
+	// Reconnection is handled by the service so attempts are logged and capped
+	client.EnableAutoReconnect = false
 
 	service := &Service{
-		client: client,
-		cfg:    cfg,
-		log:    logger,
+		client:  client,
+		cfg:     cfg,
+		log:     logger,
+		allowed: numberSet(cfg.AllowedNumbers),
+		blocked: numberSet(cfg.BlockedNumbers),
+
+		disconnected: make(chan struct{}),
+		quiet:        quiet,
+		jids:         newJIDCache(cfg.JIDCacheTTL),
+	}
+
+	// Messages held back during quiet hours, including any left from the last run, are sent once the window ends
+	service.loadDeferred()
+	if quiet != nil || len(service.deferred) > 0 {
+		go service.runDeferred()
 	}
 
+	// Incoming messages are processed by a worker pool so slow handlers don't stall event delivery
+	service.startWorkers()
+
 	// Register event handlers
 	client.AddEventHandler(func(evt interface{}) {
 		service.eventHandler(evt)
@@ -63,89 +250,172 @@ func NewService(cfg *Config) (*Service, error) {
 	return service, nil
 }
 
-// NormalizePhoneNumber normalizes phone numbers to international format
-// Handles Israeli numbers that start with 0 by converting to +972 format
-func NormalizePhoneNumber(phoneNumber string) string {
-	// Remove all non-digit characters
-	phoneNumber = strings.ReplaceAll(phoneNumber, "+", "")
-	phoneNumber = strings.ReplaceAll(phoneNumber, " ", "")
-	phoneNumber = strings.ReplaceAll(phoneNumber, "-", "")
-	phoneNumber = strings.ReplaceAll(phoneNumber, "(", "")
-	phoneNumber = strings.ReplaceAll(phoneNumber, ")", "")
+// numberSet builds a lookup set of normalized phone numbers.
+// Entries that are full chat IDs, like a group's 120363012345678901@g.us, are kept as written.
+func numberSet(numbers []string) map[string]bool {
+	set := make(map[string]bool, len(numbers))
+	for _, number := range numbers {
+		if strings.Contains(number, "@") {
+			set[strings.ToLower(strings.TrimSpace(number))] = true
+			continue
+		}
+		set[NormalizePhoneNumber(number)] = true
+	}
+	return set
+}
 
-	// Handle Israeli phone numbers (starting with 0)
-	// Israeli format: 05XXXXXXXX -> 9725XXXXXXXX
-	if strings.HasPrefix(phoneNumber, "0") && len(phoneNumber) == 10 {
-		// Remove leading 0 and add country code 972
-		phoneNumber = "972" + phoneNumber[1:]
+// IsPermitted reports whether the bot may interact with the given number
+func (s *Service) IsPermitted(phoneNumber string) bool {
+	phoneNumber = NormalizePhoneNumber(phoneNumber)
+	if s.blocked[phoneNumber] {
+		return false
 	}
+	return len(s.allowed) == 0 || s.allowed[phoneNumber]
+}
 
-	// Handle Israeli numbers already with country code but wrong format
-	// If it starts with 9720, remove the 0 after 972
-	if strings.HasPrefix(phoneNumber, "9720") {
-		phoneNumber = "972" + phoneNumber[4:]
+// IsOwnNumber reports whether the number is the one the bot is linked to
+func (s *Service) IsOwnNumber(phoneNumber string) bool {
+	if s.IsOffline() {
+		return false
 	}
+	id := s.client.Store.ID
+	return id != nil && id.User == NormalizePhoneNumber(phoneNumber)
+}
 
-	return phoneNumber
+// checkRecipient returns an error if the bot must not message the number
+func (s *Service) checkRecipient(phoneNumber string) error {
+	phoneNumber = NormalizePhoneNumber(phoneNumber)
+	if !s.IsPermitted(phoneNumber) {
+		s.log.Info().Str("phone", phoneNumber).Msg("Skipping message to excluded number")
+		return fmt.Errorf("%s: %w", phoneNumber, ErrNumberExcluded)
+	}
+	if s.IsOwnNumber(phoneNumber) {
+		s.log.Warn().Str("phone", phoneNumber).Msg("Refusing to message the bot's own number")
+		return fmt.Errorf("%s: %w", phoneNumber, ErrOwnNumber)
+	}
+	return nil
 }
 
-// Connect connects to WhatsApp
+// Connect connects to WhatsApp, first linking the bot as a device by QR code if it isn't linked yet.
+// It returns ErrQRTimeout if no code is scanned in time, leaving the bot unlinked.
 func (s *Service) Connect() error {
-	if s.client.Store.ID == nil {
-		qrChan, _ := s.client.GetQRChannel(context.Background())
-		err := s.client.Connect()
-		if err != nil {
+	if s.IsOffline() {
+		s.log.Info().Msg("Offline, not connecting to WhatsApp")
+		return nil
+	}
+	if s.client.Store.ID != nil {
+		if err := s.connect(); err != nil {
 			return fmt.Errorf("failed to connect: %w", err)
 		}
-		for evt := range qrChan {
-			if evt.Event == "code" {
-				// Generate and display QR code in terminal
-				q, err := qrcode.New(evt.Code, qrcode.Medium)
-				if err != nil {
-					fmt.Printf("QR Code: %s\n", evt.Code)
-					fmt.Println("Please scan this QR code with WhatsApp to connect.")
-				} else {
-					fmt.Println("\n" + q.ToSmallString(false))
-					fmt.Println("📱 Please scan the QR code above with WhatsApp:")
-					fmt.Println("   1. Open WhatsApp on your phone")
-					fmt.Println("   2. Go to Settings > Linked Devices")
-					fmt.Println("   3. Tap 'Link a Device'")
-					fmt.Println("   4. Scan the QR code shown above\n")
-				}
-			} else {
-				fmt.Printf("Login event: %s\n", evt.Event)
-			}
-		}
-	} else {
-		err := s.client.Connect()
-		if err != nil {
-			return fmt.Errorf("failed to connect: %w", err)
+		return nil
+	}
+
+	ctx := context.Background()
+	if s.cfg.QRTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.QRTimeout)
+		defer cancel()
+	}
+
+	qrChan, err := s.client.GetQRChannel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get QR code: %w", err)
+	}
+	if err := s.connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	for evt := range qrChan {
+		switch evt.Event {
+		case whatsmeow.QRChannelEventCode:
+			printQRCode(evt.Code)
+		case whatsmeow.QRChannelSuccess.Event:
+			return nil
+		case whatsmeow.QRChannelTimeout.Event:
+			return ErrQRTimeout
+		case whatsmeow.QRChannelEventError:
+			return fmt.Errorf("failed to link device: %w", evt.Error)
+		default:
+			return fmt.Errorf("failed to link device: %s", evt.Event)
 		}
 	}
-	return nil
+
+	// The channel is closed without a final event when QRTimeout runs out
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w within %s", ErrQRTimeout, s.cfg.QRTimeout)
+	}
+	return fmt.Errorf("failed to link device: QR code channel closed")
+}
+
+// printQRCode shows a QR code to link the bot in the terminal, with instructions for scanning it
+func printQRCode(code string) {
+	q, err := qrcode.New(code, qrcode.Medium)
+	if err != nil {
+		fmt.Printf("QR Code: %s\n", code)
+		fmt.Println("Please scan this QR code with WhatsApp to connect.")
+		return
+	}
+
+	fmt.Println("\n" + q.ToSmallString(false))
+	fmt.Println("📱 Please scan the QR code above with WhatsApp:")
+	fmt.Println("   1. Open WhatsApp on your phone")
+	fmt.Println("   2. Go to Settings > Linked Devices")
+	fmt.Println("   3. Tap 'Link a Device'")
+	fmt.Print("   4. Scan the QR code shown above\n\n")
 }
 
-// Disconnect disconnects from WhatsApp
+// Disconnect disconnects from WhatsApp and stops any reconnection attempts
 func (s *Service) Disconnect() {
-	s.client.Disconnect()
+	s.disconnectOnce.Do(func() {
+		close(s.disconnected)
+	})
+	if !s.IsOffline() {
+		s.client.Disconnect()
+	}
 }
 
-// SendInvitation sends a wedding invitation with RSVP buttons
-func (s *Service) SendInvitation(phoneNumber, name, weddingDate, weddingLocation, brideName, groomName string) error {
-	message := fmt.Sprintf(
-		"🎉 *Wedding Invitation*\n\n"+
-			"Dear %s,\n\n"+
-			"You are cordially invited to celebrate the wedding of\n\n"+
-			"*%s* & *%s*\n\n"+
-			"📅 Date: %s\n"+
-			"📍 Location: %s\n\n"+
-			"Please confirm your attendance by selecting one of the options below.",
-		name, brideName, groomName, weddingDate, weddingLocation,
-	)
+// IsConnected reports whether the bot is currently connected to WhatsApp.
+// An offline service has nothing to connect to, so it always is.
+func (s *Service) IsConnected() bool {
+	if s.IsOffline() {
+		return true
+	}
+	return s.client.IsConnected()
+}
 
+// SendInvitation sends a wedding invitation with RSVP buttons and returns its message ID.
+// During quiet hours the invitation is deferred and an empty ID is returned.
+func (s *Service) SendInvitation(phoneNumber, message string) (string, error) {
+	if err := s.checkRecipient(phoneNumber); err != nil {
+		return "", err
+	}
+	if s.deferIfQuiet(phoneNumber, message, true) {
+		return "", nil
+	}
+	return s.sendInvitation(phoneNumber, message)
+}
+
+// SendReminder sends a message that isn't a reply, like a reminder, and returns its message ID.
+// During quiet hours the message is deferred and an empty ID is returned.
+func (s *Service) SendReminder(phoneNumber, message string) (string, error) {
+	if err := s.checkRecipient(phoneNumber); err != nil {
+		return "", err
+	}
+	if s.deferIfQuiet(phoneNumber, message, false) {
+		return "", nil
+	}
+	return s.SendMessage(phoneNumber, message)
+}
+
+// sendInvitation sends the invitation immediately
+func (s *Service) sendInvitation(phoneNumber, message string) (string, error) {
 	// Normalize phone number before parsing
 	phoneNumber = NormalizePhoneNumber(phoneNumber)
 
+	if err := s.checkRecipient(phoneNumber); err != nil {
+		return "", err
+	}
+
 	// Create JID - try with + prefix first (WhatsApp sometimes prefers this format)
 	var jid types.JID
 	var err error
@@ -164,52 +434,61 @@ func (s *Service) SendInvitation(phoneNumber, name, weddingDate, weddingLocation
 	}
 
 	// Verify the number is on WhatsApp before sending
-	resp, verifyErr := s.client.IsOnWhatsApp(context.Background(), []string{phoneNumber})
+	resp, verifyErr := s.isOnWhatsApp(phoneNumber)
 	if verifyErr != nil {
-		return fmt.Errorf("failed to verify number on WhatsApp: %w", verifyErr)
+		return "", fmt.Errorf("failed to verify number on WhatsApp: %w", verifyErr)
 	}
 
 	if len(resp) == 0 || !resp[0].IsIn {
-		return fmt.Errorf("number %s is not registered on WhatsApp or not in contacts. Please ensure: 1) The number has WhatsApp, 2) The number is saved in your phone contacts with country code (e.g., +972...), 3) WhatsApp has synced contacts", phoneNumber)
+		return "", fmt.Errorf("%w: %s. Please ensure: 1) The number has WhatsApp, 2) The number is saved in your phone contacts with country code (e.g., +972...), 3) WhatsApp has synced contacts", ErrNotOnWhatsApp, phoneNumber)
 	}
 
 	// Use the verified JID from WhatsApp
 	jid = resp[0].JID
 
-	// Log verification result
-	fmt.Printf("✓ Number verified on WhatsApp: %s (JID: %s)\n", phoneNumber, jid.String())
-
-	// For now, we'll send a simple message with text instructions
-	// as interactive buttons require specific WhatsApp Business API setup
+	s.log.Debug().Str("phone", phoneNumber).Str("jid", jid.String()).Msg("Number verified on WhatsApp")
+
+	// Interactive buttons aren't available to every account,
+	// so fall back to a simple message with text instructions if they can't be sent
+	if s.cfg.InteractiveButtons {
+		sentMsg, err := s.sendWithRetry(jid, buttonsMessage(message))
+		if err == nil {
+			s.log.Info().Str("phone", phoneNumber).Str("id", sentMsg.ID).Time("timestamp", sentMsg.Timestamp).Msg("Message sent")
+			s.cfg.Metrics.InvitationSent()
+			s.sendInvitationDocument(phoneNumber)
+			return sentMsg.ID, nil
+		}
+		s.log.Warn().Err(err).Str("jid", jid.String()).Msg("Failed to send button message, falling back to text")
+	}
 	message += "\n\nReply with:\n✅ *YES* to accept\n❌ *NO* to decline"
 
 	// Log the JID being used for debugging
 	s.log.Debug().Str("jid", jid.String()).Str("phone", phoneNumber).Msg("Attempting to send message")
 
-	sentMsg, err := s.client.SendMessage(context.Background(), jid, &waE2E.Message{
-		Conversation: &message,
-	})
+	sentMsg, err := s.sendWithRetry(jid, s.invitationMessage(message))
 
 	if err == nil {
-		fmt.Printf("✓ Message sent successfully! ID: %s, Timestamp: %v\n", sentMsg.ID, sentMsg.Timestamp)
+		s.log.Info().Str("phone", phoneNumber).Str("id", sentMsg.ID).Time("timestamp", sentMsg.Timestamp).Msg("Message sent")
+		s.cfg.Metrics.InvitationSent()
+		s.sendInvitationDocument(phoneNumber)
 	}
 
 	if err != nil {
-		// Provide more helpful error message
-		if strings.Contains(err.Error(), "unknown server") || strings.Contains(err.Error(), "can't send message") {
-			return fmt.Errorf("failed to send message to %s (JID: %s): %w. Note: The recipient must be in your WhatsApp contacts. Try: 1) Ensure the number is in your phone contacts with country code (972...), 2) Wait for WhatsApp to sync contacts (may take a few minutes), 3) Or have them message you first", phoneNumber, jid.String(), err)
-		}
-		return fmt.Errorf("failed to send message: %w", err)
+		return "", sendError(phoneNumber, jid, err)
 	}
 
-	return nil
+	return sentMsg.ID, nil
 }
 
-// SendMessage sends a simple text message
-func (s *Service) SendMessage(phoneNumber, message string) error {
+// SendMessage sends a simple text message and returns its message ID
+func (s *Service) SendMessage(phoneNumber, message string) (string, error) {
 	// Normalize phone number before parsing
 	phoneNumber = NormalizePhoneNumber(phoneNumber)
 
+	if err := s.checkRecipient(phoneNumber); err != nil {
+		return "", err
+	}
+
 	// Create JID - try with + prefix first (WhatsApp sometimes prefers this format)
 	var jid types.JID
 	var err error
@@ -228,41 +507,45 @@ func (s *Service) SendMessage(phoneNumber, message string) error {
 	}
 
 	// Verify the number is on WhatsApp before sending
-	resp, verifyErr := s.client.IsOnWhatsApp(context.Background(), []string{phoneNumber})
+	resp, verifyErr := s.isOnWhatsApp(phoneNumber)
 	if verifyErr != nil {
-		return fmt.Errorf("failed to verify number on WhatsApp: %w", verifyErr)
+		return "", fmt.Errorf("failed to verify number on WhatsApp: %w", verifyErr)
 	}
 
 	if len(resp) == 0 || !resp[0].IsIn {
-		return fmt.Errorf("number %s is not registered on WhatsApp or not in contacts. Please ensure: 1) The number has WhatsApp, 2) The number is saved in your phone contacts with country code (e.g., +972...), 3) WhatsApp has synced contacts", phoneNumber)
+		return "", fmt.Errorf("%w: %s. Please ensure: 1) The number has WhatsApp, 2) The number is saved in your phone contacts with country code (e.g., +972...), 3) WhatsApp has synced contacts", ErrNotOnWhatsApp, phoneNumber)
 	}
 
 	// Use the verified JID from WhatsApp
 	jid = resp[0].JID
 
-	// Log verification result
-	fmt.Printf("✓ Number verified on WhatsApp: %s (JID: %s)\n", phoneNumber, jid.String())
+	s.log.Debug().Str("phone", phoneNumber).Str("jid", jid.String()).Msg("Number verified on WhatsApp")
 
 	// Log the JID being used for debugging
 	s.log.Debug().Str("jid", jid.String()).Str("phone", phoneNumber).Msg("Attempting to send message")
 
-	sentMsg, err := s.client.SendMessage(context.Background(), jid, &waE2E.Message{
+	sentMsg, err := s.sendWithRetry(jid, &waE2E.Message{
 		Conversation: &message,
 	})
 
 	if err == nil {
-		fmt.Printf("✓ Message sent successfully! ID: %s, Timestamp: %v\n", sentMsg.ID, sentMsg.Timestamp)
+		s.log.Info().Str("phone", phoneNumber).Str("id", sentMsg.ID).Time("timestamp", sentMsg.Timestamp).Msg("Message sent")
 	}
 
 	if err != nil {
-		// Provide more helpful error message
-		if strings.Contains(err.Error(), "unknown server") || strings.Contains(err.Error(), "can't send message") {
-			return fmt.Errorf("failed to send message to %s (JID: %s): %w. Note: The recipient must be in your WhatsApp contacts. Try: 1) Ensure the number is in your phone contacts with country code (972...), 2) Wait for WhatsApp to sync contacts (may take a few minutes), 3) Or have them message you first", phoneNumber, jid.String(), err)
-		}
-		return fmt.Errorf("failed to send message: %w", err)
+		return "", sendError(phoneNumber, jid, err)
 	}
 
-	return nil
+	return sentMsg.ID, nil
+}
+
+// sendError describes a failed send as an ErrSendFailed, which is also an ErrNotInContacts when
+// WhatsApp didn't know where to deliver it, keeping err as the underlying cause
+func sendError(phoneNumber string, jid types.JID, err error) error {
+	if errors.Is(err, whatsmeow.ErrUnknownServer) {
+		return fmt.Errorf("%w to %s (JID: %s): %w: %w. Note: The recipient must be in your WhatsApp contacts. Try: 1) Ensure the number is in your phone contacts with country code (972...), 2) Wait for WhatsApp to sync contacts (may take a few minutes), 3) Or have them message you first", ErrSendFailed, phoneNumber, jid.String(), ErrNotInContacts, err)
+	}
+	return fmt.Errorf("%w: %w", ErrSendFailed, err)
 }
 
 // eventHandler handles incoming WhatsApp events
@@ -272,11 +555,14 @@ func (s *Service) eventHandler(evt interface{}) {
 	}
 	switch evt := evt.(type) {
 	case *events.Message:
-		s.handleMessage(evt)
+		s.enqueueMessage(evt)
+	case *events.Receipt:
+		s.handleReceipt(evt)
 	case *events.Connected:
 		s.log.Info().Msg("Connected to WhatsApp")
 	case *events.Disconnected:
 		s.log.Info().Msg("Disconnected from WhatsApp")
+		go s.reconnect()
 	case *events.LoggedOut:
 		s.log.Info().Msg("Logged out from WhatsApp")
 	}
@@ -289,20 +575,79 @@ func (s *Service) handleMessage(msg *events.Message) {
 		return
 	}
 
+	// Skip messages from numbers, or in group chats, excluded by the allow/deny lists
+	if s.blocked[msg.Info.Chat.ToNonAD().String()] || !s.IsPermitted(msg.Info.Sender.User) {
+		s.log.Info().Str("sender", msg.Info.Sender.String()).Str("chat", msg.Info.Chat.String()).Msg("Ignoring message from excluded number")
+		return
+	}
+
+	s.cfg.Metrics.MessageReceived()
+
 	// Call custom message handler if set
-	if s.messageHandler != nil {
-		if err := s.messageHandler(msg); err != nil {
+	s.handlersMu.RLock()
+	handler := s.messageHandler
+	s.handlersMu.RUnlock()
+
+	if handler != nil {
+		if err := handler(msg); err != nil {
 			s.log.Error().Err(err).Msg("Error handling message")
 		}
 	} else {
 		s.log.Info().
 			Str("sender", msg.Info.Sender.String()).
-			Str("message", msg.Message.GetConversation()).
+			Str("message", MessageText(msg)).
 			Msg("Received message")
 	}
 }
 
+// MessageText returns the text of an incoming message. Replies that quote another message,
+// like the invitation, arrive as an ExtendedTextMessage rather than a plain Conversation.
+func MessageText(msg *events.Message) string {
+	if text := msg.Message.GetConversation(); text != "" {
+		return text
+	}
+	return msg.Message.GetExtendedTextMessage().GetText()
+}
+
 // SetMessageHandler sets a custom handler for incoming messages
 func (s *Service) SetMessageHandler(handler MessageHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
 	s.messageHandler = handler
 }
+
+// handleReceipt passes delivery and read receipts for messages we sent to the receipt handler
+func (s *Service) handleReceipt(receipt *events.Receipt) {
+	s.handlersMu.RLock()
+	handler := s.receiptHandler
+	s.handlersMu.RUnlock()
+
+	if receipt.IsFromMe || handler == nil {
+		return
+	}
+
+	if err := handler(receipt); err != nil {
+		s.log.Error().Err(err).Msg("Error handling receipt")
+	}
+}
+
+// SetReceiptHandler sets a custom handler for delivery and read receipts
+func (s *Service) SetReceiptHandler(handler ReceiptHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+
+	s.receiptHandler = handler
+}
+
+// QuotedMessageID returns the ID of the message a reply quotes, or "" if msg doesn't quote one
+func QuotedMessageID(msg *events.Message) string {
+	return msg.Message.GetExtendedTextMessage().GetContextInfo().GetStanzaID()
+}
+
+// Reaction returns the ID of the message a reaction is on and its emoji, which is empty when
+// a reaction is removed. Both are empty if msg isn't a reaction.
+func Reaction(msg *events.Message) (messageID, emoji string) {
+	reaction := msg.Message.GetReactionMessage()
+	return reaction.GetKey().GetID(), reaction.GetText()
+}
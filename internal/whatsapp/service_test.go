@@ -0,0 +1,151 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// newLinkedService creates a service whose client is linked to ownNumber, without connecting it
+func newLinkedService(ownNumber string) *Service {
+	id := types.NewJID(ownNumber, types.DefaultUserServer)
+	return &Service{
+		client: &whatsmeow.Client{Store: &store.Device{ID: &id}},
+		cfg:    &Config{},
+		log:    zerolog.Nop(),
+	}
+}
+
+func TestRefusesToMessageOwnNumber(t *testing.T) {
+	s := newLinkedService("972501234567")
+
+	for _, number := range []string{"972501234567", "+972 50-123-4567", "050-123-4567"} {
+		if !s.IsOwnNumber(number) {
+			t.Errorf("IsOwnNumber(%q) = false, want true", number)
+		}
+		if _, err := s.SendMessage(number, "hi"); !errors.Is(err, ErrOwnNumber) {
+			t.Errorf("SendMessage(%q) = %v, want ErrOwnNumber", number, err)
+		}
+		if _, err := s.SendInvitation(number, "You're invited!"); !errors.Is(err, ErrOwnNumber) {
+			t.Errorf("SendInvitation(%q) = %v, want ErrOwnNumber", number, err)
+		}
+	}
+
+	if s.IsOwnNumber("972509876543") {
+		t.Error("IsOwnNumber reported another number as the bot's own")
+	}
+}
+
+// Run with -race: the handler is swapped while messages are being dispatched
+func TestSetMessageHandlerWhileDispatching(t *testing.T) {
+	service := newTestService(t, Config{})
+
+	var handled atomic.Int64
+	handler := func(*events.Message) error {
+		handled.Add(1)
+		return nil
+	}
+	service.SetMessageHandler(handler)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			service.SetMessageHandler(handler)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			service.enqueueMessage(incomingMessage("972501234567", "yes"))
+		}
+	}()
+	wg.Wait()
+
+	if _, err := service.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got := handled.Load(); got != 200 {
+		t.Errorf("handled %d messages, want 200", got)
+	}
+}
+
+func TestMessageText(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *waE2E.Message
+		want    string
+	}{
+		{"conversation", &waE2E.Message{Conversation: ptr("yes")}, "yes"},
+		{"extended text", &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: ptr("yes, quoting you")}}, "yes, quoting you"},
+		{"conversation wins", &waE2E.Message{
+			Conversation:        ptr("yes"),
+			ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: ptr("no")},
+		}, "yes"},
+		{"no text", &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: ptr("👍")}}, ""},
+		{"empty", &waE2E.Message{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MessageText(&events.Message{Message: tt.message}); got != tt.want {
+				t.Errorf("MessageText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to a copy of v, for building protobuf messages
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestHandleMessageAllowAndBlockLists(t *testing.T) {
+	group := types.NewJID("120363012345678901", types.GroupServer)
+	inGroup := func(from string) *events.Message {
+		msg := incomingMessage(from, "yes")
+		msg.Info.Chat = group
+		msg.Info.IsGroup = true
+		return msg
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		msg  *events.Message
+		want bool
+	}{
+		{"no lists", Config{}, incomingMessage("972501234567", "yes"), true},
+		{"blocked number", Config{BlockedNumbers: []string{"+972 50-123-4567"}}, incomingMessage("972501234567", "yes"), false},
+		{"other number", Config{BlockedNumbers: []string{"972509876543"}}, incomingMessage("972501234567", "yes"), true},
+		{"blocked group", Config{BlockedNumbers: []string{"120363012345678901@g.us"}}, inGroup("972501234567"), false},
+		{"other group", Config{BlockedNumbers: []string{"120363099999999999@g.us"}}, inGroup("972501234567"), true},
+		{"allowed number", Config{AllowedNumbers: []string{"050-123-4567"}}, incomingMessage("972501234567", "yes"), true},
+		{"not allowed", Config{AllowedNumbers: []string{"972509876543"}}, incomingMessage("972501234567", "yes"), false},
+		{"allowed but blocked", Config{AllowedNumbers: []string{"972501234567"}, BlockedNumbers: []string{"972501234567"}}, incomingMessage("972501234567", "yes"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestService(t, tt.cfg)
+			handled := false
+			service.SetMessageHandler(func(*events.Message) error {
+				handled = true
+				return nil
+			})
+
+			service.handleMessage(tt.msg)
+			if handled != tt.want {
+				t.Errorf("handled = %v, want %v", handled, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package whatsapp
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// waitForSendSlot blocks until the minimum interval (plus random jitter) has passed since the last send.
+// Concurrent callers are serialized so bulk operations can't burst past the limit.
+func (s *Service) waitForSendSlot() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if !s.lastSend.IsZero() {
+		wait := s.cfg.MinSendInterval
+		if s.cfg.SendJitter > 0 {
+			wait += rand.N(s.cfg.SendJitter)
+		}
+		if remaining := time.Until(s.lastSend.Add(wait)); remaining > 0 {
+			s.log.Debug().Dur("wait", remaining).Msg("Throttling outgoing message")
+			time.Sleep(remaining)
+		}
+	}
+
+	s.lastSend = time.Now()
+}
@@ -0,0 +1,81 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// defaultRequestTimeout bounds calls to WhatsApp when Config.RequestTimeout isn't set
+const defaultRequestTimeout = 30 * time.Second
+
+// ErrRequestTimeout is returned when a call to WhatsApp takes longer than the request timeout.
+// Sends that hit it are retried like other transient failures.
+var ErrRequestTimeout = errors.New("WhatsApp request timed out")
+
+// requestTimeout returns how long a single call to WhatsApp may take
+func (s *Service) requestTimeout() time.Duration {
+	if s.cfg.RequestTimeout > 0 {
+		return s.cfg.RequestTimeout
+	}
+	return defaultRequestTimeout
+}
+
+// requestContext returns a context for a single call to WhatsApp, cancelled after the request timeout
+func (s *Service) requestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.requestTimeout())
+}
+
+// timeoutError wraps err in ErrRequestTimeout when the call ran out of time
+func timeoutError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrRequestTimeout, err)
+	}
+	return err
+}
+
+// isOnWhatsApp looks the number up on WhatsApp, giving up after the request timeout.
+// Numbers verified within the JID cache TTL are answered from the cache.
+func (s *Service) isOnWhatsApp(phoneNumber string) ([]types.IsOnWhatsAppResponse, error) {
+	if jid, ok := s.jids.get(phoneNumber, time.Now()); ok {
+		return []types.IsOnWhatsAppResponse{{Query: phoneNumber, JID: jid, IsIn: true}}, nil
+	}
+	// A dry run doesn't contact WhatsApp, so offline or not every number is taken to be on it
+	if s.cfg.DryRun {
+		return []types.IsOnWhatsAppResponse{{Query: phoneNumber, JID: types.NewJID(phoneNumber, types.DefaultUserServer), IsIn: true}}, nil
+	}
+	// The send itself fails if the number really isn't on WhatsApp. The JID isn't cached, as it wasn't verified.
+	if s.cfg.SkipWhatsAppCheck {
+		jid := types.NewJID(phoneNumber, types.DefaultUserServer)
+		s.log.Warn().Str("phone", phoneNumber).Str("jid", jid.String()).Msg("Skipped checking the number is on WhatsApp (SKIP_WHATSAPP_CHECK)")
+		return []types.IsOnWhatsAppResponse{{Query: phoneNumber, JID: jid, IsIn: true}}, nil
+	}
+
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	resp, err := s.client.IsOnWhatsApp(ctx, []string{phoneNumber})
+	if err == nil && len(resp) > 0 && resp[0].IsIn {
+		now := time.Now()
+		s.jids.put(phoneNumber, resp[0].JID, now)
+		s.notifyJID(phoneNumber, resp[0].JID.String(), now)
+	}
+	return resp, timeoutError(err)
+}
+
+// connect opens the connection to WhatsApp, giving up if it isn't established within the request timeout.
+// The context can't simply time out since the connection keeps using it once it's open,
+// so it's only cancelled when connecting takes too long.
+func (s *Service) connect() error {
+	ctx, cancel := context.WithCancel(s.client.BackgroundEventCtx)
+	timer := time.AfterFunc(s.requestTimeout(), cancel)
+
+	err := s.client.ConnectContext(ctx)
+	if !timer.Stop() {
+		return fmt.Errorf("%w: not connected after %s", ErrRequestTimeout, s.requestTimeout())
+	}
+	return err
+}
@@ -0,0 +1,21 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestDryRunSkipsWhatsAppLookup(t *testing.T) {
+	// Without a client, any lookup over the network would panic
+	s := &Service{cfg: &Config{DryRun: true}, jids: newJIDCache(0)}
+
+	resp, err := s.isOnWhatsApp("972501234567")
+	if err != nil {
+		t.Fatalf("isOnWhatsApp: %v", err)
+	}
+	want := types.NewJID("972501234567", types.DefaultUserServer)
+	if len(resp) != 1 || !resp[0].IsIn || resp[0].JID != want {
+		t.Errorf("isOnWhatsApp = %+v, want %s taken to be on WhatsApp", resp, want)
+	}
+}
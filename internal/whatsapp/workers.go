@@ -0,0 +1,100 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Defaults used when the worker pool isn't configured
+const (
+	defaultMessageWorkers   = 4
+	defaultMessageQueueSize = 100
+)
+
+// startWorkers starts the pool of goroutines that process incoming messages.
+// Each worker has its own queue and messages are sharded by sender,
+// so replies from the same guest are still handled in the order they arrived.
+func (s *Service) startWorkers() {
+	workers := s.cfg.MessageWorkers
+	if workers <= 0 {
+		workers = defaultMessageWorkers
+	}
+	queueSize := s.cfg.MessageQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultMessageQueueSize
+	}
+
+	s.queues = make([]chan *events.Message, workers)
+	for i := range s.queues {
+		s.queues[i] = make(chan *events.Message, queueSize)
+		s.workers.Add(1)
+		go s.runWorker(s.queues[i])
+	}
+}
+
+// runWorker handles messages from a single queue
+func (s *Service) runWorker(queue <-chan *events.Message) {
+	defer s.workers.Done()
+
+	for msg := range queue {
+		s.handleMessage(msg)
+		s.pending.Add(-1)
+	}
+}
+
+// enqueueMessage hands an incoming message to the worker responsible for its sender.
+// If that worker's queue is full the event goroutine waits rather than dropping the message.
+func (s *Service) enqueueMessage(msg *events.Message) {
+	s.queueMu.RLock()
+	defer s.queueMu.RUnlock()
+
+	if s.closed {
+		s.log.Warn().Str("sender", msg.Info.Sender.String()).Msg("Shutting down, ignoring message")
+		return
+	}
+	s.pending.Add(1)
+
+	hash := fnv.New32a()
+	hash.Write([]byte(msg.Info.Sender.User))
+	queue := s.queues[hash.Sum32()%uint32(len(s.queues))]
+
+	select {
+	case queue <- msg:
+	default:
+		s.log.Warn().Str("sender", msg.Info.Sender.String()).Msg("Message queue full, waiting for a worker")
+		queue <- msg
+	}
+}
+
+// Shutdown stops accepting incoming messages and waits until the queued and in-flight ones have been handled,
+// or ctx is done. It returns how many messages were drained.
+func (s *Service) Shutdown(ctx context.Context) (int, error) {
+	s.queueMu.Lock()
+	if s.closed {
+		s.queueMu.Unlock()
+		return 0, nil
+	}
+	s.closed = true
+	pending := int(s.pending.Load())
+	for _, queue := range s.queues {
+		close(queue)
+	}
+	s.queueMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return pending, nil
+	case <-ctx.Done():
+		remaining := int(s.pending.Load())
+		return pending - remaining, fmt.Errorf("%d message(s) still being handled: %w", remaining, ctx.Err())
+	}
+}
@@ -0,0 +1,82 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// newTestService creates an offline service with its data in a temp dir
+func newTestService(t *testing.T, cfg Config) *Service {
+	t.Helper()
+	cfg.DataDir = t.TempDir()
+	cfg.LogLevel = "error"
+	service, err := NewOfflineService(&cfg)
+	if err != nil {
+		t.Fatalf("NewOfflineService: %v", err)
+	}
+	t.Cleanup(service.Disconnect)
+	return service
+}
+
+// incomingMessage is a text message from a number, as it arrives from WhatsApp
+func incomingMessage(from, text string) *events.Message {
+	sender := types.NewJID(from, types.DefaultUserServer)
+	return &events.Message{
+		Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: sender, Sender: sender}},
+		Message: &waE2E.Message{Conversation: &text},
+	}
+}
+
+func TestWorkersKeepEachSendersOrder(t *testing.T) {
+	service := newTestService(t, Config{MessageWorkers: 4, MessageQueueSize: 2})
+
+	var mu sync.Mutex
+	received := make(map[string][]int)
+	service.SetMessageHandler(func(msg *events.Message) error {
+		n, err := strconv.Atoi(msg.Message.GetConversation())
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received[msg.Info.Sender.User] = append(received[msg.Info.Sender.User], n)
+		return nil
+	})
+
+	const senders, perSender = 10, 20
+	for n := range perSender {
+		for i := range senders {
+			service.enqueueMessage(incomingMessage(fmt.Sprintf("9725012345%02d", i), strconv.Itoa(n)))
+		}
+	}
+	drained, err := service.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if drained > senders*perSender {
+		t.Errorf("drained %d messages, want at most %d", drained, senders*perSender)
+	}
+
+	if len(received) != senders {
+		t.Fatalf("got messages from %d senders, want %d", len(received), senders)
+	}
+	for sender, numbers := range received {
+		if len(numbers) != perSender {
+			t.Errorf("%s: handled %d messages, want %d", sender, len(numbers), perSender)
+			continue
+		}
+		for i, n := range numbers {
+			if n != i {
+				t.Errorf("%s: messages handled out of order: %v", sender, numbers)
+				break
+			}
+		}
+	}
+}